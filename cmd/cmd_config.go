@@ -150,15 +150,27 @@ func addHConfigFlags(commandRun *cobra.Command) {
 	commandRun.Flags().BoolVar(&defaultConfigs.InboundOptions.EnableTunService, "tun-service", false, "Enable Tun Service")
 	commandRun.Flags().BoolVar(&defaultConfigs.InboundOptions.SetSystemProxy, "system-proxy", false, "Enable System Proxy")
 	commandRun.Flags().Uint16Var(&defaultConfigs.InboundOptions.MixedPort, "in-proxy-port", 2334, "Input Mixed Port")
+	commandRun.Flags().Uint16Var(&defaultConfigs.InboundOptions.TProxyPort, "tproxy-port", 0, "Input TProxy Port (linux only, 0 to disable)")
+	commandRun.Flags().Uint16Var(&defaultConfigs.InboundOptions.RedirectPort, "redirect-port", 0, "Input Redirect Port (linux and macOS only, 0 to disable)")
 	commandRun.Flags().BoolVar(&defaultConfigs.TLSTricks.EnableFragment, "fragment", false, "Enable Fragment")
-	commandRun.Flags().StringVar(&defaultConfigs.TLSTricks.FragmentSize, "fragment-size", "2-4", "FragmentSize")
-	commandRun.Flags().StringVar(&defaultConfigs.TLSTricks.FragmentSleep, "fragment-sleep", "2-4", "FragmentSleep")
+	commandRun.Flags().StringVar(&defaultConfigs.TLSTricks.FragmentSize, "fragment-size", "", "FragmentSize (not supported by sing-box, must be empty)")
+	commandRun.Flags().StringVar(&defaultConfigs.TLSTricks.FragmentSleep, "fragment-sleep", "", "FragmentSleep (not supported by sing-box, must be empty)")
+	commandRun.Flags().BoolVar(&defaultConfigs.TLSTricks.RecordFragment, "record-fragment", false, "Enable TLS Record Fragment")
 
-	commandRun.Flags().BoolVar(&defaultConfigs.TLSTricks.EnablePadding, "padding", false, "Enable Padding")
-	commandRun.Flags().StringVar(&defaultConfigs.TLSTricks.PaddingSize, "padding-size", "1300-1400", "PaddingSize")
+	commandRun.Flags().BoolVar(&defaultConfigs.TLSTricks.EnablePadding, "padding", false, "Enable Padding (not supported by sing-box)")
+	commandRun.Flags().StringVar(&defaultConfigs.TLSTricks.PaddingSize, "padding-size", "", "PaddingSize (not supported by sing-box)")
 
 	commandRun.Flags().BoolVar(&defaultConfigs.TLSTricks.MixedSNICase, "mixed-sni-case", false, "MixedSNICase")
 
+	commandRun.Flags().BoolVar(&defaultConfigs.Mux.Enable, "mux", false, "Enable Mux")
+	commandRun.Flags().StringVar(&defaultConfigs.Mux.Protocol, "mux-protocol", "h2mux", "Mux Protocol (h2mux, smux, yamux)")
+	commandRun.Flags().IntVar(&defaultConfigs.Mux.MaxStreams, "mux-max-streams", 8, "Mux Max Streams")
+	commandRun.Flags().IntVar(&defaultConfigs.Mux.MinStreams, "mux-min-streams", 0, "Mux Min Streams")
+	commandRun.Flags().IntVar(&defaultConfigs.Mux.MaxConnections, "mux-max-connections", 0, "Mux Max Connections")
+	commandRun.Flags().BoolVar(&defaultConfigs.Mux.Brutal.Enable, "mux-brutal", false, "Enable Mux TCP Brutal")
+	commandRun.Flags().IntVar(&defaultConfigs.Mux.Brutal.UpMbps, "mux-brutal-up", 0, "Mux TCP Brutal Upload Mbps")
+	commandRun.Flags().IntVar(&defaultConfigs.Mux.Brutal.DownMbps, "mux-brutal-down", 0, "Mux TCP Brutal Download Mbps")
+
 	commandRun.Flags().StringVar(&defaultConfigs.RemoteDnsAddress, "dns-remote", "1.1.1.1", "RemoteDNS (1.1.1.1, https://1.1.1.1/dns-query)")
 	commandRun.Flags().StringVar(&defaultConfigs.DirectDnsAddress, "dns-direct", "1.1.1.1", "DirectDNS (1.1.1.1, https://1.1.1.1/dns-query)")
 	commandRun.Flags().StringVar(&defaultConfigs.ClashApiSecret, "web-secret", "", "Web Server Secret")
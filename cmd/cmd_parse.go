@@ -10,7 +10,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var commandParseOutputPath string
+var (
+	commandParseOutputPath string
+	commandParsePartial    bool
+)
 
 var commandParse = &cobra.Command{
 	Use:   "parse",
@@ -26,6 +29,7 @@ var commandParse = &cobra.Command{
 
 func init() {
 	commandParse.Flags().StringVarP(&commandParseOutputPath, "output", "o", "", "write result to file path instead of stdout")
+	commandParse.Flags().BoolVar(&commandParsePartial, "partial", false, "drop invalid outbounds instead of failing and print a report")
 
 	mainCommand.AddCommand(commandParse)
 }
@@ -34,19 +38,36 @@ func parse(path string) error {
 	if workingDir != "" {
 		path = filepath.Join(workingDir, path)
 	}
-	config, err := config.ParseConfig(path, true)
+	var result []byte
+	var err error
+	if commandParsePartial {
+		var report *config.ParseReport
+		result, report, err = config.ParseConfigWithReport(path, true)
+		if report != nil {
+			printParseReport(report)
+		}
+	} else {
+		result, err = config.ParseConfig(path, true)
+	}
 	if err != nil {
 		return err
 	}
 	if commandParseOutputPath != "" {
 		outputPath, _ := filepath.Abs(filepath.Join(workingDir, commandParseOutputPath))
-		err = os.WriteFile(outputPath, config, 0644)
+		err = os.WriteFile(outputPath, result, 0644)
 		if err != nil {
 			return err
 		}
 		fmt.Println("result successfully written to ", outputPath)
 	} else {
-		os.Stdout.Write(config)
+		os.Stdout.Write(result)
 	}
 	return nil
 }
+
+func printParseReport(report *config.ParseReport) {
+	fmt.Fprintf(os.Stderr, "[%s] accepted %d of %d outbounds\n", report.Parser, report.Accepted, report.Total)
+	for _, diagnostic := range report.Diagnostics {
+		fmt.Fprintf(os.Stderr, "  dropped %s %q (%s %d): %s\n", diagnostic.Type, diagnostic.Tag, diagnostic.Source, diagnostic.Index, diagnostic.Error)
+	}
+}
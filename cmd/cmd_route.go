@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hiddify/hiddify-core/config"
+	"github.com/sagernet/sing-box/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	commandRouteConfigPath  string
+	commandRouteSettingPath string
+	commandRouteAssetsDir   string
+	commandRouteQuery       config.RouteQuery
+)
+
+var commandRoute = &cobra.Command{
+	Use:   "route",
+	Short: "Routing tools",
+}
+
+var commandRouteTest = &cobra.Command{
+	Use:   "test",
+	Short: "Show which rule, outbound and DNS server a connection would use",
+	Run: func(cmd *cobra.Command, args []string) {
+		err := routeTest(commandRouteConfigPath, commandRouteSettingPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	flags := commandRouteTest.Flags()
+	flags.StringVarP(&commandRouteConfigPath, "config", "c", "", "config path, a built config unless --hiddify is given")
+	commandRouteTest.MarkFlagRequired("config")
+	flags.StringVarP(&commandRouteSettingPath, "hiddify", "d", "", "Hiddify Setting JSON Path, builds the config with it first")
+	flags.StringVar(&commandRouteAssetsDir, "assets", "", "directory of downloaded rule-sets to use for remote rule-sets")
+	flags.StringVar(&commandRouteQuery.Domain, "domain", "", "destination domain")
+	flags.StringVar(&commandRouteQuery.IP, "ip", "", "destination ip")
+	flags.Uint16Var(&commandRouteQuery.Port, "port", 443, "destination port")
+	flags.StringVar(&commandRouteQuery.Network, "network", "tcp", "network (tcp, udp)")
+	flags.StringVar(&commandRouteQuery.Protocol, "protocol", "", "sniffed protocol (tls, http, quic, dns, ...)")
+	flags.StringVar(&commandRouteQuery.ProcessName, "process", "", "process name")
+	flags.StringVar(&commandRouteQuery.ProcessPath, "process-path", "", "process path")
+	flags.StringVar(&commandRouteQuery.PackageName, "package", "", "android package name")
+	flags.StringVar(&commandRouteQuery.Inbound, "inbound", "", "inbound tag")
+	flags.StringVar(&commandRouteQuery.SourceIP, "source-ip", "", "source ip")
+	flags.Uint16Var(&commandRouteQuery.SourcePort, "source-port", 0, "source port")
+	flags.StringVar(&commandRouteQuery.ClashMode, "clash-mode", "", "clash mode, the default mode when empty")
+	flags.StringVar(&commandRouteQuery.WifiSSID, "wifi-ssid", "", "wifi ssid")
+
+	commandRoute.AddCommand(commandRouteTest)
+	mainCommand.AddCommand(commandRoute)
+}
+
+func routeTest(path string, optionsPath string) error {
+	if workingDir != "" {
+		path = filepath.Join(workingDir, path)
+		if optionsPath != "" {
+			optionsPath = filepath.Join(workingDir, optionsPath)
+		}
+	}
+	options, err := readConfigAt(path)
+	if err != nil {
+		return err
+	}
+	if optionsPath != "" {
+		hiddifyOptions, err := readHiddifyOptionsAt(optionsPath)
+		if err != nil {
+			return err
+		}
+		options, err = config.BuildConfig(*hiddifyOptions, *options)
+		if err != nil {
+			return err
+		}
+	}
+	if commandRouteAssetsDir != "" {
+		config.LocalizeRuleSets(options, commandRouteAssetsDir, nil)
+	}
+	if commandRouteQuery.Domain == "" && commandRouteQuery.IP == "" {
+		return fmt.Errorf("--domain or --ip is required")
+	}
+	explanation, err := config.ExplainRoute(options, commandRouteQuery)
+	if err != nil {
+		return err
+	}
+	printRouteExplanation(explanation)
+	return nil
+}
+
+func printRouteExplanation(explanation *config.RouteExplanation) {
+	if explanation.RuleIndex >= 0 {
+		fmt.Printf("rule:     [%d] %s\n", explanation.RuleIndex, explanation.Rule)
+	} else {
+		fmt.Println("rule:     final")
+	}
+	fmt.Printf("action:   %s\n", explanation.Action)
+	if explanation.Outbound != "" {
+		fmt.Printf("outbound: %s\n", explanation.Outbound)
+	}
+	if explanation.DNSServer != "" {
+		if explanation.DNSRuleIndex >= 0 {
+			fmt.Printf("dns rule: [%d] %s\n", explanation.DNSRuleIndex, explanation.DNSRule)
+		} else {
+			fmt.Println("dns rule: final")
+		}
+		fmt.Printf("dns:      %s\n", explanation.DNSServer)
+	}
+	for _, note := range explanation.Notes {
+		fmt.Printf("note:     %s\n", note)
+	}
+}
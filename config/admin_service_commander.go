@@ -95,13 +95,28 @@ func startTunnelRequest(opt HiddifyOptions, installService bool) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	_, _ = c.Stop(ctx, &pb.Empty{})
-	res, err := c.Start(ctx, &pb.TunnelStartRequest{
-		Ipv6:                   opt.IPv6Mode == option.DomainStrategy(dns.DomainStrategyUseIPv4),
+	request := &pb.TunnelStartRequest{
+		Ipv6:                   opt.IPv6Mode != option.DomainStrategy(dns.DomainStrategyUseIPv4),
 		ServerPort:             int32(opt.InboundOptions.MixedPort),
 		StrictRoute:            opt.InboundOptions.StrictRoute,
-		EndpointIndependentNat: true,
+		EndpointIndependentNat: !opt.InboundOptions.TUNDisableEndpointIndependentNat,
 		Stack:                  opt.InboundOptions.TUNStack,
-	})
+		PerAppMode:             opt.PerAppProxy.Mode,
+		ProcessNames:           opt.PerAppProxy.ProcessNames,
+		ProcessPaths:           opt.PerAppProxy.ProcessPaths,
+		InterfaceName:          opt.InboundOptions.TUNInterfaceName,
+		Inet4Address:           opt.InboundOptions.TUNInet4Address,
+		Inet6Address:           opt.InboundOptions.TUNInet6Address,
+		RouteAddress:           opt.InboundOptions.TUNRouteAddress,
+		RouteExcludeAddress:    opt.InboundOptions.TUNExcludeAddress,
+		AutoRedirect:           opt.InboundOptions.TUNAutoRedirect,
+		Mtu:                    opt.InboundOptions.MTU,
+	}
+	if users := opt.LANSharing.Users; len(users) > 0 {
+		request.ProxyUsername = users[0].Username
+		request.ProxyPassword = users[0].Password
+	}
+	res, err := c.Start(ctx, request)
 	if err != nil {
 		log.Printf("could not greet: %+v %+v", res, err)
 
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+// AssetFileName is the name of the file holding the rule-set with the tag.
+func AssetFileName(tag string) string {
+	return tag + ".srs"
+}
+
+// LocalizeRuleSets replaces the remote binary rule-sets of options whose
+// file exists in dir by local rule-sets, so routing works before anything
+// can be downloaded. sing-box reloads local rule-sets when the file changes.
+// When accept is not nil, only the rule-sets it accepts are localized, so a
+// file downloaded for another url under the same tag is not used.
+// It returns every remote binary rule-set found, localized or not.
+func LocalizeRuleSets(options *option.Options, dir string, accept func(option.RuleSet) bool) []option.RuleSet {
+	if options.Route == nil {
+		return nil
+	}
+	var assets []option.RuleSet
+	for i, ruleSet := range options.Route.RuleSet {
+		if ruleSet.Type != C.RuleSetTypeRemote || ruleSet.Format != C.RuleSetFormatBinary {
+			continue
+		}
+		assets = append(assets, ruleSet)
+		if dir == "" || accept != nil && !accept(ruleSet) {
+			continue
+		}
+		path := filepath.Join(dir, AssetFileName(ruleSet.Tag))
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			continue
+		}
+		options.Route.RuleSet[i] = option.RuleSet{
+			Type:         C.RuleSetTypeLocal,
+			Tag:          ruleSet.Tag,
+			Format:       ruleSet.Format,
+			LocalOptions: option.LocalRuleSet{Path: path},
+		}
+	}
+	return assets
+}
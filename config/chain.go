@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+)
+
+// ProxyChain sends the outbounds whose tag matches Filter through a front
+// proxy. The front proxy is either Detour, the tag of an outbound, endpoint
+// or group, or Link, a share link added as its own outbound. An empty Filter
+// matches every proxy. Outbounds that already have a detour are left alone.
+type ProxyChain struct {
+	Name          string `json:"name"`
+	Filter        string `json:"filter"`
+	ExcludeFilter string `json:"exclude-filter"`
+	Detour        string `json:"detour"`
+	Link          string `json:"link"`
+}
+
+func (c *ProxyChain) detourTag() string {
+	if c.Detour != "" {
+		return c.Detour
+	}
+	return fmt.Sprintf("%s-%s", OutboundChainTagPrefix, c.Name)
+}
+
+func (c *ProxyChain) matches(tag string) (bool, error) {
+	if c.Filter != "" {
+		filter, err := regexp.Compile(c.Filter)
+		if err != nil {
+			return false, fmt.Errorf("chain %s: invalid filter: %w", c.Name, err)
+		}
+		if !filter.MatchString(tag) {
+			return false, nil
+		}
+	}
+	if c.ExcludeFilter != "" {
+		excludeFilter, err := regexp.Compile(c.ExcludeFilter)
+		if err != nil {
+			return false, fmt.Errorf("chain %s: invalid exclude-filter: %w", c.Name, err)
+		}
+		if excludeFilter.MatchString(tag) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// patchOutboundDetour applies the first chain matching the outbound. Front
+// proxies of any chain are never chained themselves.
+func patchOutboundDetour(outbound *option.Outbound, configOpt HiddifyOptions) error {
+	wrapper, ok := outbound.Options.(option.DialerOptionsWrapper)
+	if !ok || len(configOpt.Chains) == 0 {
+		return nil
+	}
+	for _, chain := range configOpt.Chains {
+		if chain.detourTag() == outbound.Tag {
+			return nil
+		}
+	}
+	dialerOptions := wrapper.TakeDialerOptions()
+	if dialerOptions.Detour != "" {
+		return nil
+	}
+	for _, chain := range configOpt.Chains {
+		matched, err := chain.matches(outbound.Tag)
+		if err != nil {
+			return err
+		}
+		if matched {
+			dialerOptions.Detour = chain.detourTag()
+			wrapper.ReplaceDialerOptions(dialerOptions)
+			return nil
+		}
+	}
+	return nil
+}
+
+// buildChainOutbounds checks the chains against the profile and returns the
+// front proxies declared by link.
+func buildChainOutbounds(opt *HiddifyOptions, input *option.Options) ([]option.Outbound, error) {
+	knownTags := make(map[string]bool)
+	for _, outbound := range input.Outbounds {
+		knownTags[outbound.Tag] = true
+	}
+	for _, endpoint := range input.Endpoints {
+		knownTags[endpoint.Tag] = true
+	}
+	for _, group := range opt.Groups {
+		knownTags[group.Name] = true
+	}
+	var outbounds []option.Outbound
+	for _, chain := range opt.Chains {
+		if chain.Name == "" {
+			return nil, fmt.Errorf("chain without a name")
+		}
+		switch {
+		case chain.Detour != "":
+			if !knownTags[chain.Detour] {
+				return nil, fmt.Errorf("chain %s: detour %s not found", chain.Name, chain.Detour)
+			}
+		case chain.Link != "":
+			outbound, err := parseShareLink(strings.TrimSpace(chain.Link))
+			if err != nil {
+				return nil, fmt.Errorf("chain %s: %w", chain.Name, err)
+			}
+			outbound.Tag = chain.detourTag()
+			outbounds = append(outbounds, outbound)
+		default:
+			return nil, fmt.Errorf("chain %s: detour or link required", chain.Name)
+		}
+	}
+	return outbounds, nil
+}
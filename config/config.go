@@ -9,6 +9,7 @@ import (
 	"net/netip"
 	"net/url"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,12 +22,14 @@ import (
 )
 
 const (
-	DNSRemoteTag       = "dns-remote"
-	DNSLocalTag        = "dns-local"
-	DNSDirectTag       = "dns-direct"
-	DNSBlockTag        = "dns-block"
-	DNSFakeTag         = "dns-fake"
-	DNSTricksDirectTag = "dns-trick-direct"
+	DNSRemoteTag         = "dns-remote"
+	DNSLocalTag          = "dns-local"
+	DNSDirectTag         = "dns-direct"
+	DNSBlockTag          = "dns-block"
+	DNSFakeTag           = "dns-fake"
+	DNSTricksDirectTag   = "dns-trick-direct"
+	DNSHostsTag          = "dns-hosts"
+	DNSOverrideTagPrefix = "dns-override"
 
 	OutboundDirectTag         = "direct"
 	OutboundBypassTag         = "bypass"
@@ -36,9 +39,17 @@ const (
 	OutboundDNSTag            = "dns-out"
 	OutboundDirectFragmentTag = "direct-fragment"
 
-	InboundTUNTag   = "tun-in"
-	InboundMixedTag = "mixed-in"
-	InboundDNSTag   = "dns-in"
+	InboundTUNTag         = "tun-in"
+	InboundMixedTag       = "mixed-in"
+	InboundTProxyTag      = "tproxy-in"
+	InboundRedirectTag    = "redirect-in"
+	InboundDNSTag         = "dns-in"
+	InboundSocksTag       = "socks-in"
+	InboundHTTPTag        = "http-in"
+	InboundShadowsocksTag = "shadowsocks-in"
+
+	UserRuleSetTagPrefix   = "user-rule-set"
+	OutboundChainTagPrefix = "chain"
 )
 
 var OutboundMainProxyTag = OutboundSelectTag
@@ -60,11 +71,40 @@ func BuildConfigJson(configOpt HiddifyOptions, input option.Options) (string, er
 
 func BuildConfig(opt HiddifyOptions, input option.Options) (*option.Options, error) {
 	fmt.Printf("config options: %++v\n", opt)
+	if err := opt.PerAppProxy.validate(); err != nil {
+		return nil, err
+	}
+	for i := range opt.Rules {
+		if err := opt.Rules[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := opt.InboundOptions.validateTUN(); err != nil {
+		return nil, err
+	}
+	if err := opt.LANSharing.validate(&opt.InboundOptions); err != nil {
+		return nil, err
+	}
+	if err := opt.FakeIP.validate(opt.EnableFakeDNS, opt.EnableDNSRouting, input.Route != nil); err != nil {
+		return nil, err
+	}
+	if err := opt.DNSOptions.validateServers(); err != nil {
+		return nil, err
+	}
+	if err := opt.DNSOptions.validateOverrides(); err != nil {
+		return nil, err
+	}
+	if err := opt.Mux.validate(); err != nil {
+		return nil, err
+	}
+	if err := opt.TLSTricks.validate(); err != nil {
+		return nil, err
+	}
 
 	options := option.Options{
 		Inbounds:     []option.Inbound{},
 		DNS:          input.DNS,
-		Outbounds:    input.Outbounds,
+		Endpoints:    input.Endpoints,
 		Route:        input.Route,
 		Experimental: input.Experimental,
 	}
@@ -89,28 +129,32 @@ func BuildConfig(opt HiddifyOptions, input option.Options) (*option.Options, err
 		rewriteRCodeDNSServers(options.DNS)
 	}
 
-	if len(options.Outbounds) == 0 {
-		err := setOutbounds(&options, &input, &opt)
-		if err != nil {
-			return nil, err
-		}
+	err := setOutbounds(&options, &input, &opt)
+	if err != nil {
+		return nil, err
 	}
 
 	if options.Route == nil {
 		setRoutingOptions(&options, &opt)
 	}
+	setDNSOverrides(&options, &opt)
+	if useLocalDNS {
+		setDNSServerPolicy(&options, &opt)
+	}
 
 	return &options, nil
 }
 
 func addForceDirect(options *option.Options, opt *HiddifyOptions, directDNSDomains map[string]bool) {
-	remoteDNSAddress := opt.RemoteDnsAddress
-	if strings.Contains(remoteDNSAddress, "://") {
-		remoteDNSAddress = strings.SplitAfter(remoteDNSAddress, "://")[1]
-	}
-	parsedUrl, err := url.Parse(fmt.Sprintf("https://%s", remoteDNSAddress))
-	if err == nil && net.ParseIP(parsedUrl.Host) == nil {
-		directDNSDomains[parsedUrl.Host] = true
+	for _, server := range dnsServerRoles(opt)[0].servers {
+		remoteDNSAddress := server.Address
+		if strings.Contains(remoteDNSAddress, "://") {
+			remoteDNSAddress = strings.SplitAfter(remoteDNSAddress, "://")[1]
+		}
+		parsedUrl, err := url.Parse(fmt.Sprintf("https://%s", remoteDNSAddress))
+		if err == nil && net.ParseIP(parsedUrl.Hostname()) == nil {
+			directDNSDomains["full:"+parsedUrl.Hostname()] = true
+		}
 	}
 	if len(directDNSDomains) > 0 {
 		// trickDnsDomains := []string{}
@@ -135,22 +179,44 @@ func addForceDirect(options *option.Options, opt *HiddifyOptions, directDNSDomai
 		// options.DNS.Rules = append([]option.DNSRule{{Type: C.RuleTypeDefault, DefaultOptions: trickDnsRule}}, options.DNS.Rules...)
 
 		directDNSDomainskeys := make([]string, 0, len(directDNSDomains))
-		for key := range directDNSDomains {
+		for _, key := range sortedKeys(directDNSDomains) {
 			directDNSDomainskeys = append(directDNSDomainskeys, key)
 		}
 
 		domains := strings.Join(directDNSDomainskeys, ",")
 		directRule := Rule{Domains: domains, Outbound: OutboundBypassTag}
-		dnsRule := directRule.MakeDNSRule()
-		dnsRule.DNSRuleAction = dnsRouteActionForServer(DNSDirectTag)
-		options.DNS.Rules = append([]option.DNSRule{{Type: C.RuleTypeDefault, DefaultOptions: dnsRule}}, options.DNS.Rules...)
+		dnsRule := directRule.MakeDNSRule(nil)
+		setDNSRuleAction(&dnsRule, dnsRouteActionForServer(DNSDirectTag))
+		options.DNS.Rules = append([]option.DNSRule{dnsRule}, options.DNS.Rules...)
 	}
 }
 
+// setOutbounds patches the proxies of the input and adds the select and
+// urltest groups and the outbounds the built routing relies on. An input with
+// its own route keeps its groups, direct, block and dns outbounds, which that
+// route refers to; the added outbounds only fill in the tags it does not use.
 func setOutbounds(options *option.Options, input *option.Options, opt *HiddifyOptions) error {
+	ownRoute := input.Route != nil
 	directDNSDomains := make(map[string]bool)
 	var outbounds []option.Outbound
+	var proxies []option.Outbound
 	var tags []string
+
+	chainOutbounds, err := buildChainOutbounds(opt, input)
+	if err != nil {
+		return err
+	}
+	for _, out := range chainOutbounds {
+		outbound, serverDomain, err := patchOutbound(out, *opt)
+		if err != nil {
+			return err
+		}
+		if serverDomain != "" {
+			directDNSDomains[serverDomain] = true
+		}
+		outbounds = append(outbounds, *outbound)
+	}
+
 	for _, out := range input.Outbounds {
 		outbound, serverDomain, err := patchOutbound(out, *opt)
 		if err != nil {
@@ -161,13 +227,15 @@ func setOutbounds(options *option.Options, input *option.Options, opt *HiddifyOp
 			directDNSDomains[serverDomain] = true
 		}
 		switch outbound.Type {
-		case C.TypeDirect, C.TypeBlock, C.TypeDNS:
-			continue
-		case C.TypeSelector, C.TypeURLTest:
+		case C.TypeDirect, C.TypeBlock, C.TypeDNS, C.TypeSelector, C.TypeURLTest:
+			if ownRoute {
+				outbounds = append(outbounds, *outbound)
+			}
 			continue
 		default:
 			if !strings.Contains(outbound.Tag, "§hide§") {
 				tags = append(tags, outbound.Tag)
+				proxies = append(proxies, *outbound)
 			}
 			outbounds = append(outbounds, *outbound)
 		}
@@ -204,11 +272,15 @@ func setOutbounds(options *option.Options, input *option.Options, opt *HiddifyOp
 		},
 	}
 
-	outbounds = append([]option.Outbound{selector, urlTest}, outbounds...)
+	groups, err := buildProxyGroups(opt.Groups, proxies, opt)
+	if err != nil {
+		return err
+	}
+	outbounds = append(append(missingOutbounds(outbounds, selector, urlTest), groups...), outbounds...)
 
 	options.Outbounds = append(
 		outbounds,
-		[]option.Outbound{
+		missingOutbounds(outbounds, []option.Outbound{
 			{
 				Tag:     OutboundDNSTag,
 				Type:    C.TypeDNS,
@@ -242,13 +314,24 @@ func setOutbounds(options *option.Options, input *option.Options, opt *HiddifyOp
 				Type:    C.TypeBlock,
 				Options: option.StubOptions{},
 			},
-		}...,
+		}...)...,
 	)
 
 	addForceDirect(options, opt, directDNSDomains)
 	return nil
 }
 
+// missingOutbounds returns the outbounds whose tags are not used in existing.
+func missingOutbounds(existing []option.Outbound, outbounds ...option.Outbound) []option.Outbound {
+	var missing []option.Outbound
+	for _, outbound := range outbounds {
+		if !slices.ContainsFunc(existing, func(other option.Outbound) bool { return other.Tag == outbound.Tag }) {
+			missing = append(missing, outbound)
+		}
+	}
+	return missing
+}
+
 func setClashAPI(options *option.Options, opt *HiddifyOptions) {
 	if opt.EnableClashApi {
 		if opt.ClashApiSecret == "" {
@@ -266,6 +349,17 @@ func setClashAPI(options *option.Options, opt *HiddifyOptions) {
 			},
 		}
 	}
+	if opt.ClashModes.DefaultMode != "" {
+		// The mode switch works without the external controller, so the
+		// default mode is set even when the clash api is disabled.
+		if options.Experimental == nil {
+			options.Experimental = &option.ExperimentalOptions{}
+		}
+		if options.Experimental.ClashAPI == nil {
+			options.Experimental.ClashAPI = &option.ClashAPIOptions{}
+		}
+		options.Experimental.ClashAPI.DefaultMode = opt.ClashModes.DefaultMode
+	}
 }
 
 func setLog(options *option.Options, opt *HiddifyOptions) {
@@ -289,30 +383,19 @@ func setInbound(options *option.Options, opt *HiddifyOptions) {
 		ActivateTunnelService(*opt)
 	} else if opt.EnableTun {
 		tunOptions := option.TunInboundOptions{
-			Stack:                  opt.TUNStack,
-			MTU:                    opt.MTU,
-			AutoRoute:              true,
-			StrictRoute:            opt.StrictRoute,
-			EndpointIndependentNat: true,
+			Stack:       opt.TUNStack,
+			MTU:         opt.MTU,
+			AutoRoute:   true,
+			StrictRoute: opt.StrictRoute,
 			InboundOptions: option.InboundOptions{
 				SniffEnabled:             true,
 				SniffOverrideDestination: false,
 				DomainStrategy:           inboundDomainStrategy,
 			},
 		}
-		switch opt.IPv6Mode {
-		case option.DomainStrategy(dns.DomainStrategyUseIPv4):
-			tunOptions.Address = append(tunOptions.Address, netip.MustParsePrefix("172.19.0.1/30"))
-		case option.DomainStrategy(dns.DomainStrategyUseIPv6):
-			tunOptions.Address = append(tunOptions.Address, netip.MustParsePrefix("2001:0470:f9da:fdfa::1/64"))
-		default:
-			tunOptions.Address = append(
-				tunOptions.Address,
-				netip.MustParsePrefix("172.19.0.1/30"),
-				netip.MustParsePrefix("2001:0470:f9da:fdfa::1/64"),
-			)
-		}
-		tunOptions.RouteExcludeAddressSet = append(tunOptions.RouteExcludeAddressSet, "geoip-cn")
+		setTunAddressing(&tunOptions, opt)
+		setTunRouteExclusions(&tunOptions, opt)
+		setTunPerAppProxy(&tunOptions, opt.PerAppProxy)
 		options.Inbounds = append(options.Inbounds, option.Inbound{
 			Type:    C.TypeTun,
 			Tag:     InboundTUNTag,
@@ -328,6 +411,7 @@ func setInbound(options *option.Options, opt *HiddifyOptions) {
 		bind = "127.0.0.1"
 	}
 
+	mixedBind, mixedUsers := mixedInboundAccess(opt)
 	options.Inbounds = append(
 		options.Inbounds,
 		option.Inbound{
@@ -336,7 +420,7 @@ func setInbound(options *option.Options, opt *HiddifyOptions) {
 			Options: option.HTTPMixedInboundOptions{
 				ListenOptions: option.ListenOptions{
 					Listen: func() *badoption.Addr {
-						addr := badoption.Addr(netip.MustParseAddr(bind))
+						addr := badoption.Addr(netip.MustParseAddr(mixedBind))
 						return &addr
 					}(),
 					ListenPort: opt.MixedPort,
@@ -346,10 +430,62 @@ func setInbound(options *option.Options, opt *HiddifyOptions) {
 						DomainStrategy:           inboundDomainStrategy,
 					},
 				},
+				Users:          mixedUsers,
 				SetSystemProxy: opt.SetSystemProxy,
 			},
 		},
 	)
+	if opt.AllowConnectionFromLAN {
+		options.Inbounds = append(options.Inbounds, opt.LANSharing.inbounds(bind, inboundDomainStrategy)...)
+	}
+
+	if opt.TProxyPort != 0 && runtime.GOOS == "linux" {
+		options.Inbounds = append(
+			options.Inbounds,
+			option.Inbound{
+				Type: C.TypeTProxy,
+				Tag:  InboundTProxyTag,
+				Options: option.TProxyInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen: func() *badoption.Addr {
+							addr := badoption.Addr(netip.MustParseAddr(bind))
+							return &addr
+						}(),
+						ListenPort: opt.TProxyPort,
+						InboundOptions: option.InboundOptions{
+							SniffEnabled:             true,
+							SniffOverrideDestination: true,
+							DomainStrategy:           inboundDomainStrategy,
+						},
+					},
+				},
+			},
+		)
+	}
+
+	if opt.RedirectPort != 0 && (runtime.GOOS == "linux" || runtime.GOOS == "darwin") {
+		options.Inbounds = append(
+			options.Inbounds,
+			option.Inbound{
+				Type: C.TypeRedirect,
+				Tag:  InboundRedirectTag,
+				Options: option.RedirectInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen: func() *badoption.Addr {
+							addr := badoption.Addr(netip.MustParseAddr(bind))
+							return &addr
+						}(),
+						ListenPort: opt.RedirectPort,
+						InboundOptions: option.InboundOptions{
+							SniffEnabled:             true,
+							SniffOverrideDestination: true,
+							DomainStrategy:           inboundDomainStrategy,
+						},
+					},
+				},
+			},
+		)
+	}
 
 	options.Inbounds = append(
 		options.Inbounds,
@@ -376,12 +512,11 @@ func setDns(options *option.Options, opt *HiddifyOptions, input *option.Options)
 			return
 		}
 	}
-	servers := []option.DNSServerOptions{
-		buildDNSServer(DNSRemoteTag, opt.RemoteDnsAddress, "", opt.RemoteDnsDomainStrategy, ""),
-		buildDNSServer(DNSDirectTag, opt.DirectDnsAddress, DNSLocalTag, opt.DirectDnsDomainStrategy, ""),
-		buildDNSServer(DNSLocalTag, "local", "", option.DomainStrategy(0), ""),
+	servers := buildRoleDNSServers(dnsServerRoles(opt))
+	servers = append(servers,
+		option.DNSServerOptions{Tag: DNSLocalTag, Type: C.DNSTypeLocal, Options: &option.LocalDNSServerOptions{}},
 		buildDNSServer(DNSBlockTag, "rcode://success", "", option.DomainStrategy(0), ""),
-	}
+	)
 
 	options.DNS = &option.DNSOptions{
 		RawDNSOptions: option.RawDNSOptions{
@@ -478,38 +613,53 @@ func buildDNSServer(tag, address, resolver string, strategy option.DomainStrateg
 
 func setFakeDns(options *option.Options, opt *HiddifyOptions) {
 	if opt.EnableFakeDNS {
-		inet4Range := netip.MustParsePrefix("198.18.0.0/15")
-		inet6Range := netip.MustParsePrefix("fc00::/18")
-		inet4Prefix := badoption.Prefix(inet4Range)
-		inet6Prefix := badoption.Prefix(inet6Range)
+		// The ranges are checked by BuildConfig.
+		ranges, _ := opt.FakeIP.ranges()
 		options.DNS.RawDNSOptions.Servers = append(
 			options.DNS.RawDNSOptions.Servers,
 			option.DNSServerOptions{
 				Tag:  DNSFakeTag,
 				Type: C.DNSTypeFakeIP,
 				Options: option.FakeIPDNSServerOptions{
-					Inet4Range: &inet4Prefix,
-					Inet6Range: &inet6Prefix,
+					Inet4Range: ranges[0],
+					Inet6Range: ranges[1],
 				},
 			},
 		)
-		dnsRule := option.DefaultDNSRule{
-			RawDefaultDNSRule: option.RawDefaultDNSRule{
-				Inbound: []string{InboundTUNTag},
-			},
+		inbounds := opt.FakeIP.inbounds()
+		excludeRule := restrictDNSRuleInbound(opt.FakeIP.excludeRule(), inbounds)
+		setDNSRuleAction(&excludeRule, dnsRouteActionForServer(DNSLocalTag))
+		options.DNS.Rules = append(options.DNS.Rules, excludeRule)
+		if !opt.FakeIP.ProxyRulesOnly {
+			dnsRule := option.DefaultDNSRule{
+				RawDefaultDNSRule: option.RawDefaultDNSRule{
+					Inbound: inbounds,
+				},
+			}
+			dnsRule.DNSRuleAction = option.DNSRuleAction{
+				Action: C.RuleActionTypeRoute,
+				RouteOptions: option.DNSRouteActionOptions{
+					Server:       DNSFakeTag,
+					DisableCache: true,
+				},
+			}
+			options.DNS.Rules = append(
+				options.DNS.Rules,
+				option.DNSRule{Type: C.RuleTypeDefault, DefaultOptions: dnsRule},
+			)
 		}
-		dnsRule.DNSRuleAction = option.DNSRuleAction{
-			Action: C.RuleActionTypeRoute,
-			RouteOptions: option.DNSRouteActionOptions{
-				Server:       DNSFakeTag,
-				DisableCache: true,
-			},
+		if opt.FakeIP.Persist {
+			if options.Experimental == nil {
+				options.Experimental = &option.ExperimentalOptions{}
+			}
+			if options.Experimental.CacheFile == nil {
+				options.Experimental.CacheFile = &option.CacheFileOptions{
+					Enabled: true,
+					Path:    "clash.db",
+				}
+			}
+			options.Experimental.CacheFile.StoreFakeIP = true
 		}
-		options.DNS.Rules = append(
-			options.DNS.Rules,
-			option.DNSRule{Type: C.RuleTypeDefault, DefaultOptions: dnsRule},
-		)
-
 	}
 }
 
@@ -534,6 +684,48 @@ func dnsRouteActionForServer(server string) option.DNSRuleAction {
 	}
 }
 
+func setRuleAction(rule *option.Rule, action option.RuleAction) {
+	if rule.Type == C.RuleTypeLogical {
+		rule.LogicalOptions.RuleAction = action
+	} else {
+		rule.DefaultOptions.RuleAction = action
+	}
+}
+
+func setDNSRuleAction(rule *option.DNSRule, action option.DNSRuleAction) {
+	if rule.Type == C.RuleTypeLogical {
+		rule.LogicalOptions.DNSRuleAction = action
+	} else {
+		rule.DefaultOptions.DNSRuleAction = action
+	}
+}
+
+// restrictDNSRuleInbound limits a DNS rule to queries from the inbounds.
+// Rules with their own inbound condition are combined with the inbounds.
+func restrictDNSRuleInbound(rule option.DNSRule, inbounds []string) option.DNSRule {
+	if rule.Type == C.RuleTypeDefault && len(rule.DefaultOptions.Inbound) == 0 && !rule.DefaultOptions.Invert {
+		rule.DefaultOptions.Inbound = inbounds
+		return rule
+	}
+	return option.DNSRule{
+		Type: C.RuleTypeLogical,
+		LogicalOptions: option.LogicalDNSRule{
+			RawLogicalDNSRule: option.RawLogicalDNSRule{
+				Mode: C.LogicalTypeAnd,
+				Rules: []option.DNSRule{
+					{
+						Type: C.RuleTypeDefault,
+						DefaultOptions: option.DefaultDNSRule{
+							RawDefaultDNSRule: option.RawDefaultDNSRule{Inbound: inbounds},
+						},
+					},
+					rule,
+				},
+			},
+		},
+	}
+}
+
 func resolveRuleOutbound(selection string) string {
 	switch selection {
 	case "bypass":
@@ -548,41 +740,10 @@ func resolveRuleOutbound(selection string) string {
 }
 
 func setRoutingOptions(options *option.Options, opt *HiddifyOptions) {
-	dnsRules := []option.DefaultDNSRule{}
+	dnsRules := []option.DNSRule{}
 	routeRules := []option.Rule{}
 	rulesets := []option.RuleSet{}
 
-	rulesets = append(
-		rulesets,
-		option.RuleSet{
-			Type:   C.RuleSetTypeRemote,
-			Tag:    "geosite-geolocation-!cn",
-			Format: C.RuleSetFormatBinary,
-			RemoteOptions: option.RemoteRuleSet{
-				URL:            "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-geolocation-!cn.srs",
-				DownloadDetour: OutboundMainProxyTag,
-			},
-		},
-		option.RuleSet{
-			Type:   C.RuleSetTypeRemote,
-			Tag:    "geosite-cn",
-			Format: C.RuleSetFormatBinary,
-			RemoteOptions: option.RemoteRuleSet{
-				URL:            "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-cn.srs",
-				DownloadDetour: OutboundMainProxyTag,
-			},
-		},
-		option.RuleSet{
-			Type:   C.RuleSetTypeRemote,
-			Tag:    "geoip-cn",
-			Format: C.RuleSetFormatBinary,
-			RemoteOptions: option.RemoteRuleSet{
-				URL:            "https://raw.githubusercontent.com/Loyalsoldier/geoip/release/srs/cn.srs",
-				DownloadDetour: OutboundMainProxyTag,
-			},
-		},
-	)
-
 	if opt.EnableTun && runtime.GOOS == "android" {
 		routeRules = append(
 			routeRules,
@@ -649,63 +810,36 @@ func setRoutingOptions(options *option.Options, opt *HiddifyOptions) {
 		},
 	})
 
-	routeRules = append(routeRules, option.Rule{
-		Type: C.RuleTypeDefault,
-		DefaultOptions: option.DefaultRule{
-			RawDefaultRule: option.RawDefaultRule{
-				IPIsPrivate: true,
-			},
-			RuleAction: routeActionForOutbound(OutboundDirectTag),
-		},
-	})
+	// After the DNS rules, so the resolver queries of bypassed apps are
+	// still answered by the DNS of the core.
+	if opt.EnableTun && !opt.EnableTunService {
+		routeRules = append(routeRules, PerAppProxyRules(opt.PerAppProxy, InboundTUNTag, OutboundBypassTag)...)
+	}
 
 	routeRules = append(routeRules, option.Rule{
 		Type: C.RuleTypeDefault,
 		DefaultOptions: option.DefaultRule{
 			RawDefaultRule: option.RawDefaultRule{
-				ClashMode: "关闭代理",
+				IPIsPrivate: true,
 			},
 			RuleAction: routeActionForOutbound(OutboundDirectTag),
 		},
 	})
 
-	routeRules = append(routeRules, option.Rule{
-		Type: C.RuleTypeDefault,
-		DefaultOptions: option.DefaultRule{
-			RawDefaultRule: option.RawDefaultRule{
-				ClashMode: "全局代理",
-			},
-			RuleAction: routeActionForOutbound(OutboundMainProxyTag),
-		},
-	})
-
-	routeRules = append(routeRules, option.Rule{
-		Type: C.RuleTypeDefault,
-		DefaultOptions: option.DefaultRule{
-			RawDefaultRule: option.RawDefaultRule{
-				RuleSet: []string{
-					"geosite-cn",
-					"geoip-cn",
+	for _, mode := range opt.ClashModes.Modes {
+		if mode.Name == "" || mode.Outbound == "" {
+			continue
+		}
+		routeRules = append(routeRules, option.Rule{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultRule{
+				RawDefaultRule: option.RawDefaultRule{
+					ClashMode: mode.Name,
 				},
+				RuleAction: routeActionForOutbound(resolveRuleOutbound(mode.Outbound)),
 			},
-			RuleAction: routeActionForOutbound(OutboundDirectTag),
-		},
-	})
-
-	// {
-	// 	Type: C.RuleTypeDefault,
-	// 	DefaultOptions: option.DefaultRule{
-	// 		ClashMode: "Direct",
-	// 		Outbound:  OutboundDirectTag,
-	// 	},
-	// },
-	// {
-	// 	Type: C.RuleTypeDefault,
-	// 	DefaultOptions: option.DefaultRule{
-	// 		ClashMode: "Global",
-	// 		Outbound:  OutboundMainProxyTag,
-	// 	},
-	// },	}
+		})
+	}
 
 	if opt.BypassLAN {
 		routeRules = append(
@@ -722,46 +856,45 @@ func setRoutingOptions(options *option.Options, opt *HiddifyOptions) {
 		)
 	}
 
-	dnsRules = append(dnsRules, option.DefaultDNSRule{
-		RawDefaultDNSRule: option.RawDefaultDNSRule{
-			RuleSet: []string{
-				"geosite-cn",
-				"geoip-cn",
-			},
-		},
-		DNSRuleAction: dnsRouteActionForServer(DNSDirectTag),
-	})
-
-	for _, rule := range opt.Rules {
-		routeRule := rule.MakeRule()
+	userRuleSetTags := make(map[string]string)
+	ruleSetTag := func(rule *Rule) string {
+		ruleSet, ok := rule.MakeRuleSet(fmt.Sprintf("%s-%d", UserRuleSetTagPrefix, len(userRuleSetTags)))
+		if !ok {
+			return ""
+		}
+		tag, ok := userRuleSetTags[rule.RuleSetUrl]
+		if !ok {
+			tag = ruleSet.Tag
+			userRuleSetTags[rule.RuleSetUrl] = tag
+			rulesets = append(rulesets, ruleSet)
+		}
+		return tag
+	}
+	for _, rule := range sortRules(opt.Rules) {
 		targetOutbound := resolveRuleOutbound(rule.Outbound)
-		routeRule.RuleAction = routeActionForOutbound(targetOutbound)
-
-		if routeRule.IsValid() {
-			routeRules = append(
-				routeRules,
-				option.Rule{
-					Type:           C.RuleTypeDefault,
-					DefaultOptions: routeRule,
-				},
-			)
+		if routeRule := rule.MakeRule(ruleSetTag); routeRule.IsValid() {
+			setRuleAction(&routeRule, routeActionForOutbound(targetOutbound))
+			routeRules = append(routeRules, routeRule)
 		}
 
-		dnsRule := rule.MakeDNSRule()
+		dnsRule := rule.MakeDNSRule(ruleSetTag)
+		if !dnsRule.IsValid() {
+			continue
+		}
 		switch targetOutbound {
 		case OutboundBypassTag:
-			dnsRule.DNSRuleAction = dnsRouteActionForServer(DNSDirectTag)
+			setDNSRuleAction(&dnsRule, dnsRouteActionForServer(DNSDirectTag))
 		case OutboundBlockTag:
-			dnsRule.DNSRuleAction = dnsRouteActionForServer(DNSBlockTag)
-			dnsRule.DNSRuleAction.RouteOptions.DisableCache = true
+			action := dnsRouteActionForServer(DNSBlockTag)
+			action.RouteOptions.DisableCache = true
+			setDNSRuleAction(&dnsRule, action)
 		default:
 			if opt.EnableFakeDNS {
-				fakeDnsRule := dnsRule
-				fakeDnsRule.DNSRuleAction = dnsRouteActionForServer(DNSFakeTag)
-				fakeDnsRule.RawDefaultDNSRule.Inbound = []string{InboundTUNTag, InboundMixedTag}
+				fakeDnsRule := restrictDNSRuleInbound(dnsRule, opt.FakeIP.inbounds())
+				setDNSRuleAction(&fakeDnsRule, dnsRouteActionForServer(DNSFakeTag))
 				dnsRules = append(dnsRules, fakeDnsRule)
 			}
-			dnsRule.DNSRuleAction = dnsRouteActionForServer(DNSRemoteTag)
+			setDNSRuleAction(&dnsRule, dnsRouteActionForServer(DNSRemoteTag))
 		}
 		dnsRules = append(dnsRules, dnsRule)
 	}
@@ -776,7 +909,7 @@ func setRoutingOptions(options *option.Options, opt *HiddifyOptions) {
 		}
 		dnsRule.DNSRuleAction = dnsRouteActionForServer(DNSRemoteTag)
 		dnsRule.DNSRuleAction.RouteOptions.RewriteTTL = &dnsCPttl
-		dnsRules = append(dnsRules, dnsRule)
+		dnsRules = append(dnsRules, option.DNSRule{Type: C.RuleTypeDefault, DefaultOptions: dnsRule})
 	}
 
 	if opt.BlockAds {
@@ -864,70 +997,52 @@ func setRoutingOptions(options *option.Options, opt *HiddifyOptions) {
 			},
 		}
 		dnsRule.DNSRuleAction = dnsRouteActionForServer(DNSBlockTag)
-		dnsRules = append(dnsRules, dnsRule)
+		dnsRules = append(dnsRules, option.DNSRule{Type: C.RuleTypeDefault, DefaultOptions: dnsRule})
 
 	}
-	if opt.Region != "other" && opt.Region != "cn" {
+	if regionSets := regionRuleSets(opt.Region); regionSets != nil {
+		regionSuffix := "." + normalizeRegion(opt.Region)
+		regionTags := []string{regionGeoIPTag(opt.Region), regionGeositeTag(opt.Region)}
 		dnsRule := option.DefaultDNSRule{
 			RawDefaultDNSRule: option.RawDefaultDNSRule{
-				DomainSuffix: []string{"." + opt.Region},
+				DomainSuffix: []string{regionSuffix},
 			},
 		}
 		dnsRule.DNSRuleAction = dnsRouteActionForServer(DNSDirectTag)
-		dnsRules = append(dnsRules, dnsRule)
+		dnsRules = append(dnsRules, option.DNSRule{Type: C.RuleTypeDefault, DefaultOptions: dnsRule})
 		routeRules = append(routeRules, option.Rule{
 			Type: C.RuleTypeDefault,
 			DefaultOptions: option.DefaultRule{
 				RawDefaultRule: option.RawDefaultRule{
-					DomainSuffix: []string{"." + opt.Region},
+					DomainSuffix: []string{regionSuffix},
 				},
 				RuleAction: routeActionForOutbound(OutboundDirectTag),
 			},
 		})
 		directRule := option.DefaultDNSRule{
 			RawDefaultDNSRule: option.RawDefaultDNSRule{
-				RuleSet: []string{
-					"geoip-" + opt.Region,
-					"geosite-" + opt.Region,
-				},
+				RuleSet: regionTags,
 			},
 		}
 		directRule.DNSRuleAction = dnsRouteActionForServer(DNSDirectTag)
-		dnsRules = append(dnsRules, directRule)
+		dnsRules = append(dnsRules, option.DNSRule{Type: C.RuleTypeDefault, DefaultOptions: directRule})
 
-		rulesets = append(rulesets, option.RuleSet{
-			Type:   C.RuleSetTypeRemote,
-			Tag:    "geoip-" + opt.Region,
-			Format: C.RuleSetFormatBinary,
-			RemoteOptions: option.RemoteRuleSet{
-				URL:            "https://raw.githubusercontent.com/hiddify/hiddify-geo/rule-set/country/geoip-" + opt.Region + ".srs",
-				UpdateInterval: badoption.Duration(5 * time.Hour * 24),
-			},
-		})
-		rulesets = append(rulesets, option.RuleSet{
-			Type:   C.RuleSetTypeRemote,
-			Tag:    "geosite-" + opt.Region,
-			Format: C.RuleSetFormatBinary,
-			RemoteOptions: option.RemoteRuleSet{
-				URL:            "https://raw.githubusercontent.com/hiddify/hiddify-geo/rule-set/country/geosite-" + opt.Region + ".srs",
-				UpdateInterval: badoption.Duration(5 * time.Hour * 24),
-			},
-		})
+		rulesets = append(rulesets, regionSets...)
 
 		routeRules = append(routeRules, option.Rule{
 			Type: C.RuleTypeDefault,
 			DefaultOptions: option.DefaultRule{
 				RawDefaultRule: option.RawDefaultRule{
-					RuleSet: []string{
-						"geoip-" + opt.Region,
-						"geosite-" + opt.Region,
-					},
+					RuleSet: regionTags,
 				},
 				RuleAction: routeActionForOutbound(OutboundDirectTag),
 			},
 		})
 
 	}
+	if opt.EnableTun && !opt.EnableTunService {
+		rulesets = append(rulesets, tunExcludeRuleSets(opt)...)
+	}
 	options.Route = &option.RouteOptions{
 		Rules:               routeRules,
 		Final:               OutboundMainProxyTag,
@@ -944,13 +1059,7 @@ func setRoutingOptions(options *option.Options, opt *HiddifyOptions) {
 	if opt.EnableDNSRouting {
 		for _, dnsRule := range dnsRules {
 			if dnsRule.IsValid() {
-				options.DNS.Rules = append(
-					options.DNS.Rules,
-					option.DNSRule{
-						Type:           C.RuleTypeDefault,
-						DefaultOptions: dnsRule,
-					},
-				)
+				options.DNS.Rules = append(options.DNS.Rules, dnsRule)
 			}
 		}
 	}
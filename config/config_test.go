@@ -1,11 +1,20 @@
 package config
 
 import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
+	dns "github.com/sagernet/sing-dns"
 
+	json "github.com/sagernet/sing/common/json"
 	badoption "github.com/sagernet/sing/common/json/badoption"
 )
 
@@ -72,6 +81,584 @@ func TestBuildConfigAddsDirectFragmentDialerOptions(t *testing.T) {
 	}
 }
 
+func TestBuildConfigAddsUserRuleSets(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.Rules = []Rule{
+		{RuleSetUrl: "https://example.com/ads.srs", RuleSetUpdateInterval: DurationInSeconds(3600), RuleSetDownloadDetour: "bypass", Outbound: "block"},
+		{RuleSetUrl: "file:///etc/hiddify/direct.json", Outbound: "bypass"},
+		{RuleSetUrl: "https://example.com/ads.srs", Domains: "domain:example.org", Outbound: "block"},
+	}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	remote := findRuleSet(t, options, UserRuleSetTagPrefix+"-0")
+	if remote.Type != C.RuleSetTypeRemote || remote.Format != C.RuleSetFormatBinary || remote.RemoteOptions.URL != "https://example.com/ads.srs" {
+		t.Fatalf("unexpected remote rule-set: %+v", remote)
+	}
+	if remote.RemoteOptions.DownloadDetour != OutboundBypassTag || remote.RemoteOptions.UpdateInterval != badoption.Duration(time.Hour) {
+		t.Fatalf("remote rule-set options not applied: %+v", remote.RemoteOptions)
+	}
+	local := findRuleSet(t, options, UserRuleSetTagPrefix+"-1")
+	if local.Type != C.RuleSetTypeLocal || local.Format != C.RuleSetFormatSource || local.LocalOptions.Path != filepath.FromSlash("/etc/hiddify/direct.json") {
+		t.Fatalf("unexpected local rule-set: %+v", local)
+	}
+	if count := countUserRuleSets(options); count != 2 {
+		t.Fatalf("duplicate rule-set url not shared: %d user rule-sets", count)
+	}
+
+	var routeRefs, dnsRefs int
+	for _, rule := range options.Route.Rules {
+		if containsString(rule.DefaultOptions.RuleSet, UserRuleSetTagPrefix+"-0") {
+			routeRefs++
+		}
+	}
+	for _, rule := range options.DNS.Rules {
+		if containsString(rule.DefaultOptions.RuleSet, UserRuleSetTagPrefix+"-0") {
+			dnsRefs++
+		}
+	}
+	if routeRefs != 2 || dnsRefs != 2 {
+		t.Fatalf("rule-set referenced by %d route and %d dns rules, want 2 each", routeRefs, dnsRefs)
+	}
+}
+
+func TestBuildConfigAddsTProxyAndRedirectInbounds(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("tproxy is linux only")
+	}
+	opt := DefaultHiddifyOptions()
+	opt.TProxyPort = 12335
+	opt.RedirectPort = 12336
+	opt.AllowConnectionFromLAN = true
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	var tproxy *option.TProxyInboundOptions
+	var redirect *option.RedirectInboundOptions
+	for _, inbound := range options.Inbounds {
+		switch inboundOptions := inbound.Options.(type) {
+		case option.TProxyInboundOptions:
+			tproxy = &inboundOptions
+		case option.RedirectInboundOptions:
+			redirect = &inboundOptions
+		}
+	}
+	if tproxy == nil || tproxy.ListenPort != 12335 || tproxy.Listen.Build(netip.Addr{}).String() != "0.0.0.0" || !tproxy.SniffEnabled {
+		t.Fatalf("unexpected tproxy inbound: %+v", tproxy)
+	}
+	if redirect == nil || redirect.ListenPort != 12336 {
+		t.Fatalf("unexpected redirect inbound: %+v", redirect)
+	}
+
+	opt.TProxyPort = 0
+	opt.RedirectPort = 0
+	options, err = BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+	for _, inbound := range options.Inbounds {
+		if inbound.Type == C.TypeTProxy || inbound.Type == C.TypeRedirect {
+			t.Fatalf("inbound %s added with port 0", inbound.Type)
+		}
+	}
+}
+
+func TestBuildConfigAppliesTLSTricks(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.TLSTricks = TLSTricks{
+		EnableFragment: true,
+		RecordFragment: true,
+		MixedSNICase:   true,
+	}
+	tls := func(serverName string) option.OutboundTLSOptionsContainer {
+		return option.OutboundTLSOptionsContainer{TLS: &option.OutboundTLSOptions{Enabled: true, ServerName: serverName}}
+	}
+	server := option.ServerOptions{Server: "203.0.113.1", ServerPort: 443}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{
+			{Type: C.TypeVLESS, Tag: "vless", Options: &option.VLESSOutboundOptions{ServerOptions: server, UUID: "bf000d23-0752-40b4-affe-68f7707a9661", OutboundTLSOptionsContainer: tls("front.example.com")}},
+			{Type: C.TypeVMess, Tag: "vmess", Options: &option.VMessOutboundOptions{ServerOptions: option.ServerOptions{Server: "vmess.example.com", ServerPort: 443}, UUID: "bf000d23-0752-40b4-affe-68f7707a9661", OutboundTLSOptionsContainer: tls("")}},
+			{Type: C.TypeTrojan, Tag: "trojan", Options: &option.TrojanOutboundOptions{ServerOptions: server, Password: "secret", OutboundTLSOptionsContainer: tls("front.example.com")}},
+			{Type: C.TypeHTTP, Tag: "http", Options: &option.HTTPOutboundOptions{ServerOptions: server, OutboundTLSOptionsContainer: tls("front.example.com")}},
+			{Type: C.TypeHysteria2, Tag: "hysteria2", Options: &option.Hysteria2OutboundOptions{ServerOptions: server, Password: "secret", OutboundTLSOptionsContainer: tls("front.example.com")}},
+			{Type: C.TypeTUIC, Tag: "tuic", Options: &option.TUICOutboundOptions{ServerOptions: server, UUID: "bf000d23-0752-40b4-affe-68f7707a9661", OutboundTLSOptionsContainer: tls("front.example.com")}},
+			{Type: C.TypeShadowsocks, Tag: "ss", Options: &option.ShadowsocksOutboundOptions{ServerOptions: server, Method: "aes-256-gcm", Password: "secret", Plugin: "v2ray-plugin", PluginOptions: "tls;host=front.example.com;path=/ws"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	for _, tag := range []string{"vless", "vmess", "trojan", "http", "hysteria2", "tuic"} {
+		outbound := findOutbound(t, options, tag)
+		tlsOptions := outbound.Options.(option.OutboundTLSOptionsWrapper).TakeOutboundTLSOptions()
+		stream := tag != "hysteria2" && tag != "tuic"
+		if tlsOptions.Fragment != stream || tlsOptions.RecordFragment != stream {
+			t.Fatalf("%s: fragment = %v, record fragment = %v, want %v", tag, tlsOptions.Fragment, tlsOptions.RecordFragment, stream)
+		}
+		if tlsOptions.UTLS != nil {
+			t.Fatalf("%s: fingerprint changed: %+v", tag, tlsOptions.UTLS)
+		}
+		expectedName := "front.example.com"
+		if tag == "vmess" {
+			expectedName = "vmess.example.com"
+		}
+		if !strings.EqualFold(tlsOptions.ServerName, expectedName) || tlsOptions.ServerName == expectedName {
+			t.Fatalf("%s: server name = %q, want mixed case of %q", tag, tlsOptions.ServerName, expectedName)
+		}
+	}
+
+	ss := findOutbound(t, options, "ss").Options.(*option.ShadowsocksOutboundOptions)
+	if ss.PluginOptions != "tls;host="+mixedCase("front.example.com")+";path=/ws" {
+		t.Fatalf("plugin options = %q", ss.PluginOptions)
+	}
+}
+
+func TestBuildConfigRejectsUnsupportedTLSTricks(t *testing.T) {
+	for _, test := range []struct {
+		tricks TLSTricks
+		want   string
+	}{
+		{TLSTricks{EnableFragment: true, FragmentSize: "10-100"}, "fragment-size"},
+		{TLSTricks{EnableFragment: true, FragmentSleep: "50-200"}, "fragment-sleep"},
+		{TLSTricks{EnablePadding: true}, "padding"},
+		{TLSTricks{EnablePadding: true, PaddingSize: "1200-1500"}, "padding"},
+	} {
+		opt := DefaultHiddifyOptions()
+		opt.TLSTricks = test.tricks
+		_, err := BuildConfig(*opt, option.Options{
+			Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+		})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("%+v: BuildConfig error = %v, want %s error", test.tricks, err, test.want)
+		}
+	}
+
+	// The sizes of a disabled trick are left alone.
+	opt := DefaultHiddifyOptions()
+	opt.TLSTricks = TLSTricks{FragmentSize: "10-100", FragmentSleep: "50-200", PaddingSize: "1200-1500"}
+	if _, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	}); err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+}
+
+func TestMixedCaseIsStable(t *testing.T) {
+	mixed := mixedCase("www.example.com")
+	if mixed != mixedCase("www.example.com") {
+		t.Fatal("mixed case is not deterministic")
+	}
+	if !strings.EqualFold(mixed, "www.example.com") {
+		t.Fatalf("mixed case changed the domain: %q", mixed)
+	}
+}
+
+func TestBuildConfigAppliesMuxOptions(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.Mux = MuxOptions{
+		Enable:         true,
+		Padding:        true,
+		Protocol:       "smux",
+		MinStreams:     4,
+		MaxConnections: 2,
+		Brutal:         MuxBrutalOptions{Enable: true, UpMbps: 50, DownMbps: 100},
+	}
+	server := option.ServerOptions{Server: "203.0.113.1", ServerPort: 443}
+	uuid := "bf000d23-0752-40b4-affe-68f7707a9661"
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{
+			{Type: C.TypeVLESS, Tag: "vless", Options: &option.VLESSOutboundOptions{ServerOptions: server, UUID: uuid}},
+			{Type: C.TypeVLESS, Tag: "vless-vision", Options: &option.VLESSOutboundOptions{ServerOptions: server, UUID: uuid, Flow: "xtls-rprx-vision"}},
+			{Type: C.TypeVMess, Tag: "vmess-grpc", Options: &option.VMessOutboundOptions{ServerOptions: server, UUID: uuid, Transport: &option.V2RayTransportOptions{Type: C.V2RayTransportTypeGRPC}}},
+			{Type: C.TypeTrojan, Tag: "trojan-ws", Options: &option.TrojanOutboundOptions{ServerOptions: server, Password: "secret", Transport: &option.V2RayTransportOptions{Type: C.V2RayTransportTypeWebsocket}}},
+			{Type: C.TypeShadowsocks, Tag: "ss", Options: &option.ShadowsocksOutboundOptions{ServerOptions: server, Method: "aes-256-gcm", Password: "secret"}},
+			{Type: C.TypeShadowsocks, Tag: "ss-uot", Options: &option.ShadowsocksOutboundOptions{ServerOptions: server, Method: "aes-256-gcm", Password: "secret", UDPOverTCP: &option.UDPOverTCPOptions{Enabled: true}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	multiplex := func(tag string) *option.OutboundMultiplexOptions {
+		switch opts := findOutbound(t, options, tag).Options.(type) {
+		case *option.VLESSOutboundOptions:
+			return opts.Multiplex
+		case *option.VMessOutboundOptions:
+			return opts.Multiplex
+		case *option.TrojanOutboundOptions:
+			return opts.Multiplex
+		case *option.ShadowsocksOutboundOptions:
+			return opts.Multiplex
+		}
+		t.Fatalf("%s: unexpected options type", tag)
+		return nil
+	}
+	for _, tag := range []string{"vless", "trojan-ws", "ss"} {
+		mux := multiplex(tag)
+		if mux == nil || !mux.Enabled || mux.Protocol != "smux" || mux.MinStreams != 4 || mux.MaxConnections != 2 {
+			t.Fatalf("%s: multiplex = %+v", tag, mux)
+		}
+		if mux.Brutal == nil || !mux.Brutal.Enabled || mux.Brutal.UpMbps != 50 || mux.Brutal.DownMbps != 100 {
+			t.Fatalf("%s: brutal = %+v", tag, mux.Brutal)
+		}
+	}
+	for _, tag := range []string{"vless-vision", "vmess-grpc", "ss-uot"} {
+		if mux := multiplex(tag); mux != nil {
+			t.Fatalf("%s: multiplex applied to incompatible outbound: %+v", tag, mux)
+		}
+	}
+}
+
+func TestBuildConfigRejectsInvalidMuxOptions(t *testing.T) {
+	for _, mux := range []MuxOptions{
+		{Enable: true, MaxStreams: 8, MinStreams: 4},
+		{Enable: true, MaxStreams: 8, MaxConnections: 2},
+		{Enable: true, MaxStreams: 8, Brutal: MuxBrutalOptions{Enable: true, DownMbps: 100}},
+		{Enable: true, MaxStreams: 8, Brutal: MuxBrutalOptions{Enable: true, UpMbps: 50}},
+	} {
+		opt := DefaultHiddifyOptions()
+		opt.Mux = mux
+		if _, err := BuildConfig(*opt, option.Options{
+			Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+		}); err == nil {
+			t.Fatalf("mux %+v accepted", mux)
+		}
+	}
+
+	opt := DefaultHiddifyOptions()
+	opt.Mux.MinStreams = 4
+	if _, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	}); err != nil {
+		t.Fatalf("disabled mux rejected: %v", err)
+	}
+}
+
+func TestBuildConfigKeepsOutboundsOfOwnRoute(t *testing.T) {
+	input, err := UnmarshalOptions([]byte(`{
+		"outbounds": [
+			{"type": "selector", "tag": "My Group", "outbounds": ["proxy-a", "proxy-b"]},
+			{"type": "shadowsocks", "tag": "proxy-a", "server": "203.0.113.1", "server_port": 443, "method": "aes-256-gcm", "password": "secret"},
+			{"type": "shadowsocks", "tag": "proxy-b", "server": "203.0.113.2", "server_port": 443, "method": "aes-256-gcm", "password": "secret"},
+			{"type": "direct", "tag": "my-direct"},
+			{"type": "block", "tag": "block"}
+		],
+		"route": {
+			"rules": [
+				{"domain_suffix": ["lan.example"], "outbound": "my-direct"},
+				{"domain_suffix": ["ads.example"], "outbound": "block"}
+			],
+			"final": "My Group"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("UnmarshalOptions failed: %v", err)
+	}
+	opt := DefaultHiddifyOptions()
+	opt.Mux = MuxOptions{Enable: true, Protocol: "smux", MaxStreams: 8}
+	options, err := BuildConfig(*opt, *input)
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	tags := make(map[string]int)
+	for _, outbound := range options.Outbounds {
+		tags[outbound.Tag]++
+	}
+	for tag, count := range tags {
+		if count != 1 {
+			t.Fatalf("outbound %s added %d times", tag, count)
+		}
+	}
+	references := []string{options.Route.Final}
+	for _, rule := range options.Route.Rules {
+		references = append(references, rule.DefaultOptions.RuleAction.RouteOptions.Outbound)
+	}
+	for _, tag := range references {
+		if tags[tag] == 0 {
+			t.Fatalf("route refers to missing outbound %s", tag)
+		}
+	}
+	if group := findOutbound(t, options, "My Group"); group.Type != C.TypeSelector {
+		t.Fatalf("own group = %s", group.Type)
+	}
+	if findOutbound(t, options, "block").Type != C.TypeBlock || tags[OutboundSelectTag] != 1 || tags[OutboundDirectTag] != 1 {
+		t.Fatalf("outbounds = %v", tags)
+	}
+	proxy := findOutbound(t, options, "proxy-a").Options.(*option.ShadowsocksOutboundOptions)
+	if proxy.Multiplex == nil || !proxy.Multiplex.Enabled {
+		t.Fatalf("proxy of own route not patched: %+v", proxy.Multiplex)
+	}
+}
+
+func TestBuildConfigAddsProxyGroups(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.Groups = []ProxyGroup{
+		{Name: "Streaming", Type: ProxyGroupFallback, Countries: []string{"us"}},
+		{Name: "Gaming", Type: ProxyGroupSelector, Filter: "(?i)game", Outbounds: []string{"bypass"}},
+		{Name: "Shadowsocks", Type: ProxyGroupURLTest, Protocols: []string{C.TypeShadowsocks}, ExcludeFilter: "game"},
+		{Name: "Empty", Filter: "nothing-matches"},
+	}
+	opt.Rules = []Rule{{Domains: "domain:netflix.com", Outbound: "Streaming"}}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{
+			minimalShadowsocksOutbound("🇺🇸 New York"),
+			minimalShadowsocksOutbound("US-2"),
+			minimalShadowsocksOutbound("DE game"),
+			{Type: C.TypeTrojan, Tag: "trojan-game", Options: &option.TrojanOutboundOptions{ServerOptions: option.ServerOptions{Server: "203.0.113.2", ServerPort: 443}, Password: "secret"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	streaming := findOutbound(t, options, "Streaming")
+	streamingOptions := streaming.Options.(option.URLTestOutboundOptions)
+	if streaming.Type != C.TypeURLTest || streamingOptions.Tolerance != fallbackTolerance {
+		t.Fatalf("fallback group = %s %+v", streaming.Type, streamingOptions)
+	}
+	if len(streamingOptions.Outbounds) != 2 || streamingOptions.Outbounds[0] != "🇺🇸 New York" || streamingOptions.Outbounds[1] != "US-2" {
+		t.Fatalf("country filter members = %v", streamingOptions.Outbounds)
+	}
+
+	gaming := findOutbound(t, options, "Gaming")
+	gamingOptions := gaming.Options.(option.SelectorOutboundOptions)
+	if gaming.Type != C.TypeSelector || len(gamingOptions.Outbounds) != 3 || gamingOptions.Outbounds[2] != OutboundBypassTag {
+		t.Fatalf("selector group = %s %v", gaming.Type, gamingOptions.Outbounds)
+	}
+
+	shadowsocks := findOutbound(t, options, "Shadowsocks").Options.(option.URLTestOutboundOptions)
+	if len(shadowsocks.Outbounds) != 2 || containsString(shadowsocks.Outbounds, "DE game") {
+		t.Fatalf("protocol filter members = %v", shadowsocks.Outbounds)
+	}
+
+	empty := findOutbound(t, options, "Empty").Options.(option.URLTestOutboundOptions)
+	if len(empty.Outbounds) != 1 || empty.Outbounds[0] != OutboundMainProxyTag {
+		t.Fatalf("empty group members = %v", empty.Outbounds)
+	}
+
+	found := false
+	for _, rule := range options.Route.Rules {
+		if rule.DefaultOptions.RuleAction.RouteOptions.Outbound == "Streaming" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("rule does not route to the group")
+	}
+}
+
+func TestBuildConfigRejectsInvalidProxyGroups(t *testing.T) {
+	for _, group := range []ProxyGroup{
+		{Name: OutboundSelectTag},
+		{Name: "bad-type", Type: "random"},
+		{Name: "load-balance", Type: "load-balance"},
+		{Name: "bad-filter", Filter: "("},
+	} {
+		opt := DefaultHiddifyOptions()
+		opt.Groups = []ProxyGroup{group}
+		if _, err := BuildConfig(*opt, option.Options{
+			Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+		}); err == nil {
+			t.Fatalf("group %+v accepted", group)
+		}
+	}
+
+	opt := DefaultHiddifyOptions()
+	opt.Groups = []ProxyGroup{{Name: "balanced", Type: "load-balance"}}
+	_, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "type load-balance is not supported") {
+		t.Fatalf("load-balance error = %v", err)
+	}
+}
+
+func TestBuildConfigAppliesProxyChains(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.Chains = []ProxyChain{
+		{Name: "relay", Filter: "^exit", Detour: "relay"},
+		{Name: "front", Filter: "^fallback", Link: "trojan://secret@front.example.com:443#front"},
+	}
+	exit := func(tag string, server string) option.Outbound {
+		return option.Outbound{Type: C.TypeTrojan, Tag: tag, Options: &option.TrojanOutboundOptions{
+			ServerOptions: option.ServerOptions{Server: server, ServerPort: 443},
+			Password:      "secret",
+		}}
+	}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{
+			exit("relay", "relay.example.com"),
+			exit("exit-a", "exit-a.example.com"),
+			exit("fallback-b", "exit-b.example.com"),
+			exit("plain", "plain.example.com"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	detours := map[string]string{
+		"relay":      "",
+		"exit-a":     "relay",
+		"fallback-b": OutboundChainTagPrefix + "-front",
+		"plain":      "",
+	}
+	for tag, expected := range detours {
+		if detour := outboundDetour(findOutbound(t, options, tag)); detour != expected {
+			t.Fatalf("%s: detour = %q, want %q", tag, detour, expected)
+		}
+	}
+	front := findOutbound(t, options, OutboundChainTagPrefix+"-front")
+	if front.Type != C.TypeTrojan || outboundDetour(front) != "" {
+		t.Fatalf("front proxy = %s detour %q", front.Type, outboundDetour(front))
+	}
+	selector := findOutbound(t, options, OutboundSelectTag).Options.(option.SelectorOutboundOptions)
+	if containsString(selector.Outbounds, front.Tag) {
+		t.Fatalf("front proxy listed in the selector: %v", selector.Outbounds)
+	}
+
+	var directDomains []string
+	for _, rule := range options.DNS.Rules {
+		if rule.DefaultOptions.DNSRuleAction.RouteOptions.Server == DNSDirectTag {
+			directDomains = append(directDomains, rule.DefaultOptions.Domain...)
+		}
+	}
+	for _, domain := range []string{"relay.example.com", "front.example.com", "plain.example.com"} {
+		if !containsString(directDomains, domain) {
+			t.Fatalf("%s not resolved by direct dns: %v", domain, directDomains)
+		}
+	}
+	for _, domain := range []string{"exit-a.example.com", "exit-b.example.com"} {
+		if containsString(directDomains, domain) {
+			t.Fatalf("chained server %s resolved by direct dns", domain)
+		}
+	}
+}
+
+func TestBuildConfigRejectsUnknownChainDetour(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.Chains = []ProxyChain{{Name: "relay", Detour: "missing"}}
+	_, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("BuildConfig error = %v, want missing detour", err)
+	}
+}
+
+func TestBuildConfigUsesClashModes(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.ClashModes = ClashModeOptions{
+		DefaultMode: "Global",
+		Modes: []ClashMode{
+			{Name: "Direct", Outbound: OutboundDirectTag},
+			{Name: "Global", Outbound: "proxy"},
+			{Name: "Block", Outbound: "block"},
+		},
+	}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	modes := make(map[string]string)
+	for _, rule := range options.Route.Rules {
+		if mode := rule.DefaultOptions.ClashMode; mode != "" {
+			modes[mode] = rule.DefaultOptions.RuleAction.RouteOptions.Outbound
+		}
+	}
+	expected := map[string]string{
+		"Direct": OutboundDirectTag,
+		"Global": OutboundMainProxyTag,
+		"Block":  OutboundBlockTag,
+	}
+	if len(modes) != len(expected) {
+		t.Fatalf("clash mode rules = %v, want %v", modes, expected)
+	}
+	for mode, outbound := range expected {
+		if modes[mode] != outbound {
+			t.Fatalf("clash mode %s routes to %q, want %q", mode, modes[mode], outbound)
+		}
+	}
+	if options.Experimental == nil || options.Experimental.ClashAPI == nil || options.Experimental.ClashAPI.DefaultMode != "Global" {
+		t.Fatalf("default mode not set: %+v", options.Experimental)
+	}
+}
+
+func TestBuildConfigDerivesRegionRuleSets(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.Region = "other"
+	opt.EnableTun = true
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+	for _, ruleSet := range options.Route.RuleSet {
+		if strings.HasPrefix(ruleSet.Tag, "geoip-") || strings.HasPrefix(ruleSet.Tag, "geosite-") {
+			t.Fatalf("region other downloads rule-set %s", ruleSet.Tag)
+		}
+	}
+	if tun := findTunOptions(t, options); len(tun.RouteExcludeAddressSet) != 0 {
+		t.Fatalf("region other excludes %v from tun", tun.RouteExcludeAddressSet)
+	}
+
+	opt.Region = "ir"
+	opt.TUNExcludeAddress = []string{"198.51.100.0/24", "203.0.113.9"}
+	opt.TUNExcludeRuleSets = []string{"https://example.com/lan.srs"}
+	options, err = BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+	findRuleSet(t, options, "geoip-ir")
+	findRuleSet(t, options, "geosite-ir")
+	userSet := findRuleSet(t, options, TUNExcludeRuleSetTagPrefix+"-0")
+	if userSet.RemoteOptions.URL != "https://example.com/lan.srs" {
+		t.Fatalf("tun exclude rule-set url = %s", userSet.RemoteOptions.URL)
+	}
+	for _, ruleSet := range options.Route.RuleSet {
+		if strings.HasSuffix(ruleSet.Tag, "cn") {
+			t.Fatalf("region ir downloads rule-set %s", ruleSet.Tag)
+		}
+	}
+	tun := findTunOptions(t, options)
+	if len(tun.RouteExcludeAddressSet) != 2 || tun.RouteExcludeAddressSet[0] != "geoip-ir" || tun.RouteExcludeAddressSet[1] != userSet.Tag {
+		t.Fatalf("tun exclude sets = %v", tun.RouteExcludeAddressSet)
+	}
+	expected := []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24"), netip.MustParsePrefix("203.0.113.9/32")}
+	if len(tun.RouteExcludeAddress) != len(expected) || tun.RouteExcludeAddress[0] != expected[0] || tun.RouteExcludeAddress[1] != expected[1] {
+		t.Fatalf("tun exclude addresses = %v", tun.RouteExcludeAddress)
+	}
+}
+
+func findTunOptions(t *testing.T, options *option.Options) option.TunInboundOptions {
+	t.Helper()
+	for _, inbound := range options.Inbounds {
+		if inbound.Type == C.TypeTun {
+			return inbound.Options.(option.TunInboundOptions)
+		}
+	}
+	t.Fatal("tun inbound not found")
+	return option.TunInboundOptions{}
+}
+
 func minimalShadowsocksOutbound(tag string) option.Outbound {
 	return option.Outbound{
 		Tag:  tag,
@@ -98,6 +685,27 @@ func findOutbound(t *testing.T, options *option.Options, tag string) option.Outb
 	return option.Outbound{}
 }
 
+func findRuleSet(t *testing.T, options *option.Options, tag string) option.RuleSet {
+	t.Helper()
+	for _, ruleSet := range options.Route.RuleSet {
+		if ruleSet.Tag == tag {
+			return ruleSet
+		}
+	}
+	t.Fatalf("rule-set %s not found", tag)
+	return option.RuleSet{}
+}
+
+func countUserRuleSets(options *option.Options) int {
+	count := 0
+	for _, ruleSet := range options.Route.RuleSet {
+		if strings.HasPrefix(ruleSet.Tag, UserRuleSetTagPrefix) {
+			count++
+		}
+	}
+	return count
+}
+
 func containsString(items []string, target string) bool {
 	for _, item := range items {
 		if item == target {
@@ -106,3 +714,620 @@ func containsString(items []string, target string) bool {
 	}
 	return false
 }
+
+func TestBuildConfigAppliesPerAppProxy(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.EnableTun = true
+	opt.PerAppProxy = PerAppProxyOptions{
+		Mode:         PerAppProxyModeExclude,
+		PackageNames: []string{"org.example.bank"},
+		ProcessNames: []string{"bank.exe"},
+		ProcessPaths: []string{"/opt/bank/bank"},
+	}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+	tun := findTunOptions(t, options)
+	if len(tun.IncludePackage) != 0 || len(tun.ExcludePackage) != 1 || tun.ExcludePackage[0] != "org.example.bank" {
+		t.Fatalf("tun packages = %v / %v", tun.IncludePackage, tun.ExcludePackage)
+	}
+	var names, paths bool
+	for _, rule := range options.Route.Rules {
+		raw := rule.DefaultOptions.RawDefaultRule
+		if len(raw.ProcessName) == 0 && len(raw.ProcessPath) == 0 {
+			continue
+		}
+		if rule.DefaultOptions.RouteOptions.Outbound != OutboundBypassTag || len(raw.Inbound) != 1 || raw.Inbound[0] != InboundTUNTag {
+			t.Fatalf("unexpected process rule: %+v", rule.DefaultOptions)
+		}
+		names = names || containsString(raw.ProcessName, "bank.exe")
+		paths = paths || containsString(raw.ProcessPath, "/opt/bank/bank")
+	}
+	if !names || !paths {
+		t.Fatalf("excluded processes not bypassed")
+	}
+
+	opt.PerAppProxy.Mode = PerAppProxyModeInclude
+	options, err = BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+	tun = findTunOptions(t, options)
+	if len(tun.ExcludePackage) != 0 || len(tun.IncludePackage) != 1 || tun.IncludePackage[0] != "org.example.bank" {
+		t.Fatalf("tun packages = %v / %v", tun.IncludePackage, tun.ExcludePackage)
+	}
+	var logical *option.LogicalRule
+	for i, rule := range options.Route.Rules {
+		if rule.Type == C.RuleTypeLogical {
+			logical = &options.Route.Rules[i].LogicalOptions
+		}
+	}
+	if logical == nil || logical.Mode != C.LogicalTypeAnd || logical.RouteOptions.Outbound != OutboundBypassTag || len(logical.Rules) != 3 {
+		t.Fatalf("unexpected include rule: %+v", logical)
+	}
+	for _, rule := range logical.Rules[1:] {
+		if !rule.DefaultOptions.Invert {
+			t.Fatalf("included processes are not inverted: %+v", rule.DefaultOptions)
+		}
+	}
+
+	opt.PerAppProxy.Mode = "only"
+	if _, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	}); err == nil {
+		t.Fatal("unknown per-app proxy mode accepted")
+	}
+}
+
+func TestBuildConfigOrdersPerAppRulesAfterDNS(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.EnableTun = true
+	opt.PerAppProxy = PerAppProxyOptions{
+		Mode:         PerAppProxyModeInclude,
+		ProcessNames: []string{"browser"},
+	}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+	hijack, perApp := -1, -1
+	for i, rule := range options.Route.Rules {
+		switch {
+		case rule.Type == C.RuleTypeDefault && rule.DefaultOptions.Action == C.RuleActionTypeHijackDNS:
+			hijack = i
+		case rule.Type == C.RuleTypeLogical && rule.LogicalOptions.RouteOptions.Outbound == OutboundBypassTag:
+			perApp = i
+		}
+	}
+	if hijack < 0 || perApp < hijack {
+		t.Fatalf("per-app rule at %d comes before dns hijack at %d", perApp, hijack)
+	}
+
+	explanation, err := ExplainRoute(options, RouteQuery{
+		IP:          "192.0.2.53",
+		Port:        53,
+		Network:     "udp",
+		Protocol:    "dns",
+		ProcessName: "resolver",
+		Inbound:     InboundTUNTag,
+	})
+	if err != nil {
+		t.Fatalf("ExplainRoute failed: %v", err)
+	}
+	if explanation.Outbound == OutboundBypassTag {
+		t.Fatalf("dns of an unlisted process bypassed: %+v", explanation)
+	}
+}
+
+func TestBuildConfigBuildsLogicalRules(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.EnableDNSRouting = true
+	opt.Rules = []Rule{
+		{IP: "198.51.100.0/24", Outbound: "proxy"},
+		{
+			Mode:     C.LogicalTypeAnd,
+			Inbound:  InboundMixedTag,
+			Priority: 10,
+			Outbound: "bypass",
+			Rules: []Rule{
+				{Mode: C.LogicalTypeOr, Rules: []Rule{
+					{Domains: "domain:example.com"},
+					{ProcessName: "game.exe, chat.exe"},
+				}},
+				{SourceIP: "192.168.1.0/24", SourcePort: "1000:2000,3000", Invert: true},
+				{WifiSSID: "office"},
+			},
+		},
+	}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	var logicalIndex, ipIndex int
+	var logical option.LogicalRule
+	for i, rule := range options.Route.Rules {
+		if rule.Type == C.RuleTypeLogical {
+			logicalIndex, logical = i, rule.LogicalOptions
+		} else if containsString(rule.DefaultOptions.IPCIDR, "198.51.100.0/24") {
+			ipIndex = i
+		}
+	}
+	if logicalIndex == 0 || ipIndex == 0 || logicalIndex > ipIndex {
+		t.Fatalf("rules not ordered by priority: logical %d, ip %d", logicalIndex, ipIndex)
+	}
+	if logical.Mode != C.LogicalTypeAnd || logical.RouteOptions.Outbound != OutboundBypassTag || len(logical.Rules) != 4 {
+		t.Fatalf("unexpected logical rule: %+v", logical)
+	}
+	if inbound := logical.Rules[0].DefaultOptions.Inbound; len(inbound) != 1 || inbound[0] != InboundMixedTag {
+		t.Fatalf("own conditions not kept: %+v", logical.Rules[0])
+	}
+	or := logical.Rules[1]
+	if or.Type != C.RuleTypeLogical || or.LogicalOptions.Mode != C.LogicalTypeOr || len(or.LogicalOptions.Rules) != 2 {
+		t.Fatalf("unexpected nested rule: %+v", or)
+	}
+	if names := or.LogicalOptions.Rules[1].DefaultOptions.ProcessName; len(names) != 2 || names[1] != "chat.exe" {
+		t.Fatalf("process names = %v", names)
+	}
+	source := logical.Rules[2].DefaultOptions
+	if !source.Invert || !containsString(source.SourceIPCIDR, "192.168.1.0/24") || len(source.SourcePort) != 1 || len(source.SourcePortRange) != 1 {
+		t.Fatalf("unexpected source rule: %+v", source)
+	}
+	if ssid := logical.Rules[3].DefaultOptions.WIFISSID; len(ssid) != 1 || ssid[0] != "office" {
+		t.Fatalf("wifi ssid = %v", ssid)
+	}
+
+	var logicalDNS int
+	for _, rule := range options.DNS.Rules {
+		if rule.Type == C.RuleTypeLogical {
+			logicalDNS++
+			if rule.LogicalOptions.RouteOptions.Server != DNSDirectTag {
+				t.Fatalf("logical dns rule uses %s", rule.LogicalOptions.RouteOptions.Server)
+			}
+		} else if !rule.DefaultOptions.IsValid() || rule.DefaultOptions.RouteOptions.Server == DNSRemoteTag && len(rule.DefaultOptions.Domain) == 0 {
+			t.Fatalf("ip rule emitted dns rule: %+v", rule.DefaultOptions)
+		}
+	}
+	if logicalDNS != 1 {
+		t.Fatalf("got %d logical dns rules, want 1", logicalDNS)
+	}
+
+	opt.Rules = []Rule{{Mode: "xor", Rules: []Rule{{Domains: "domain:example.com"}}}}
+	if _, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	}); err == nil {
+		t.Fatal("unknown rule mode accepted")
+	}
+}
+
+func TestExplainRoute(t *testing.T) {
+	ruleSetPath := filepath.Join(t.TempDir(), "games.json")
+	if err := os.WriteFile(ruleSetPath, []byte(`{"version":3,"rules":[{"domain_suffix":["game.example"]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opt := DefaultHiddifyOptions()
+	opt.EnableDNSRouting = true
+	opt.Rules = []Rule{
+		{Domains: "domain:blocked.example", Outbound: "block"},
+		{RuleSetUrl: ruleSetPath, Outbound: "bypass"},
+		{Mode: C.LogicalTypeAnd, Rules: []Rule{{ProcessName: "chat.exe"}, {Port: "443"}}, Outbound: "bypass"},
+		{RuleSetUrl: "https://example.com/remote.srs", Outbound: "block"},
+	}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	explain := func(query RouteQuery) *RouteExplanation {
+		t.Helper()
+		explanation, err := ExplainRoute(options, query)
+		if err != nil {
+			t.Fatalf("ExplainRoute(%+v) failed: %v", query, err)
+		}
+		return explanation
+	}
+
+	blocked := explain(RouteQuery{Domain: "www.blocked.example", Port: 443})
+	if blocked.RuleIndex < 0 || blocked.Outbound != OutboundBlockTag || blocked.DNSServer != DNSBlockTag {
+		t.Fatalf("unexpected explanation for blocked domain: %+v", blocked)
+	}
+	game := explain(RouteQuery{Domain: "play.game.example", Port: 443})
+	if game.RuleIndex <= blocked.RuleIndex || game.Outbound != OutboundBypassTag || game.DNSServer != DNSDirectTag {
+		t.Fatalf("unexpected explanation for rule-set domain: %+v", game)
+	}
+	chat := explain(RouteQuery{IP: "203.0.113.7", Port: 443, ProcessName: "chat.exe"})
+	if chat.RuleIndex <= game.RuleIndex || chat.Outbound != OutboundBypassTag || chat.DNSRuleIndex != -1 {
+		t.Fatalf("unexpected explanation for process: %+v", chat)
+	}
+	other := explain(RouteQuery{Domain: "example.org", Port: 80})
+	if other.RuleIndex != -1 || other.Outbound != OutboundMainProxyTag || other.DNSServer != DNSRemoteTag {
+		t.Fatalf("unexpected explanation for unmatched domain: %+v", other)
+	}
+	if !slices.ContainsFunc(other.Notes, func(note string) bool {
+		return strings.Contains(note, "remote.srs") || strings.Contains(note, UserRuleSetTagPrefix+"-1")
+	}) {
+		t.Fatalf("unavailable remote rule-set not reported: %v", other.Notes)
+	}
+	dns := explain(RouteQuery{IP: "198.51.100.1", Port: 53, Network: "udp"})
+	if dns.Outbound != OutboundDNSTag {
+		t.Fatalf("unexpected explanation for dns: %+v", dns)
+	}
+
+	if _, err := ExplainRoute(options, RouteQuery{IP: "not-an-ip"}); err == nil {
+		t.Fatal("invalid ip accepted")
+	}
+}
+
+func TestBuildConfigAppliesDNSOverrides(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.EnableDNSRouting = false
+	opt.Hosts = map[string][]string{
+		"Router.Example": {"192.168.1.1"},
+		"*.lab.example":  {"10.0.0.2", "fd00::2"},
+	}
+	opt.DomainResolvers = map[string]string{
+		"corp.example":   "10.0.0.53",
+		"*.corp.example": "10.0.0.53",
+		"isp.example":    "direct",
+	}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	content, err := json.MarshalContext(OptionsContext(), options)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	options, err = UnmarshalOptions(content)
+	if err != nil {
+		t.Fatalf("built config does not parse: %v", err)
+	}
+	if options.DNS.Servers[0].Tag != DNSHostsTag || options.DNS.Servers[1].Tag != DNSOverrideTagPrefix+"-0" || options.DNS.Servers[2].Tag != DNSRemoteTag {
+		t.Fatalf("unexpected dns servers: %+v", options.DNS.Servers)
+	}
+	if len(options.DNS.Rules) != 6 {
+		t.Fatalf("expected 6 override rules without dns routing, got %d", len(options.DNS.Rules))
+	}
+
+	explain := func(domain string) *RouteExplanation {
+		t.Helper()
+		explanation, err := ExplainRoute(options, RouteQuery{Domain: domain})
+		if err != nil {
+			t.Fatalf("ExplainRoute(%s) failed: %v", domain, err)
+		}
+		return explanation
+	}
+	if server := explain("router.example").DNSServer; server != DNSHostsTag {
+		t.Fatalf("exact host not pinned, got %s", server)
+	}
+	if server := explain("db.lab.example").DNSServer; !strings.Contains(server, "10.0.0.2") {
+		t.Fatalf("wildcard host not answered, got %s", server)
+	}
+	if server := explain("lab.example").DNSServer; server != DNSRemoteTag {
+		t.Fatalf("wildcard host matched the apex, got %s", server)
+	}
+	for _, domain := range []string{"corp.example", "mail.corp.example"} {
+		if server := explain(domain).DNSServer; server != DNSOverrideTagPrefix+"-0" {
+			t.Fatalf("resolver override not applied to %s, got %s", domain, server)
+		}
+	}
+	if server := explain("isp.example").DNSServer; server != DNSDirectTag {
+		t.Fatalf("direct resolver override not applied, got %s", server)
+	}
+
+	opt.Hosts = map[string][]string{"bad.example": {"not-an-ip"}}
+	if _, err := BuildConfig(*opt, option.Options{}); err == nil {
+		t.Fatalf("invalid host address accepted")
+	}
+}
+
+func TestBuildConfigBuildsTypedDNSServers(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.RemoteDnsServers = []DNSServer{
+		{Address: "https://dns.google/dns-query", ClientSubnet: "203.0.113.0/24"},
+		{Address: "tls://1.1.1.1:853", Detour: "bypass"},
+	}
+	opt.DirectDnsServers = []DNSServer{{Address: "tcp://dns.example", Bootstrap: "9.9.9.9"}}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	servers := make(map[string]option.DNSServerOptions)
+	for _, server := range options.DNS.Servers {
+		if server.Type == C.DNSTypeLegacy {
+			t.Fatalf("legacy dns server %s built", server.Tag)
+		}
+		servers[server.Tag] = server
+	}
+	remote, ok := servers[DNSRemoteTag].Options.(*option.RemoteHTTPSDNSServerOptions)
+	if !ok || servers[DNSRemoteTag].Type != C.DNSTypeHTTPS || remote.Server != "dns.google" || remote.Path != "" {
+		t.Fatalf("unexpected remote server: %+v", servers[DNSRemoteTag])
+	}
+	if remote.Detour != OutboundMainProxyTag || remote.DomainResolver == nil || remote.DomainResolver.Server != DNSDirectTag {
+		t.Fatalf("unexpected remote dialer: %+v", remote.DialerOptions)
+	}
+	fallback, ok := servers[DNSRemoteTag+"-1"].Options.(*option.RemoteTLSDNSServerOptions)
+	if !ok || fallback.Server != "1.1.1.1" || fallback.ServerPort != 853 || fallback.Detour != "" {
+		t.Fatalf("unexpected fallback server: %+v", servers[DNSRemoteTag+"-1"])
+	}
+	direct, ok := servers[DNSDirectTag].Options.(*option.RemoteDNSServerOptions)
+	if !ok || servers[DNSDirectTag].Type != C.DNSTypeTCP || direct.DomainResolver == nil || direct.DomainResolver.Server != DNSDirectTag+"-bootstrap" {
+		t.Fatalf("unexpected direct server: %+v", servers[DNSDirectTag])
+	}
+	if _, ok := servers[DNSDirectTag+"-bootstrap"]; !ok {
+		t.Fatalf("bootstrap server missing")
+	}
+
+	if options.DNS.ClientSubnet == nil || options.DNS.ClientSubnet.Build(netip.Prefix{}).String() != "203.0.113.0/24" {
+		t.Fatalf("client subnet of the final server not applied: %+v", options.DNS.ClientSubnet)
+	}
+	rules := options.DNS.Rules
+	last := rules[len(rules)-1].DefaultOptions
+	previous := rules[len(rules)-2].DefaultOptions
+	if !previous.IPAcceptAny || previous.RouteOptions.Server != DNSRemoteTag || previous.RouteOptions.ClientSubnet == nil {
+		t.Fatalf("unexpected first final fallback rule: %+v", previous)
+	}
+	if len(last.QueryType) == 0 || last.RouteOptions.Server != DNSRemoteTag+"-1" || last.RouteOptions.ClientSubnet != nil {
+		t.Fatalf("unexpected last final fallback rule: %+v", last)
+	}
+	if !slices.ContainsFunc(rules, func(rule option.DNSRule) bool {
+		return containsString(rule.DefaultOptions.Domain, "dns.google")
+	}) {
+		t.Fatalf("remote server domain not resolved directly")
+	}
+
+	opt.DNSServerPolicy = DNSServerPolicyEmptyAnswer
+	if _, err := BuildConfig(*opt, option.Options{}); err != nil {
+		t.Fatalf("empty-answer policy rejected: %v", err)
+	}
+	for _, policy := range []string{DNSServerPolicyRace, "fallback"} {
+		opt.DNSServerPolicy = policy
+		if _, err := BuildConfig(*opt, option.Options{}); err == nil {
+			t.Fatalf("%s policy accepted", policy)
+		}
+	}
+	opt.DNSServerPolicy = ""
+	opt.RemoteDnsServers = []DNSServer{{Address: "ftp://dns.example"}}
+	if _, err := BuildConfig(*opt, option.Options{}); err == nil {
+		t.Fatalf("unsupported scheme accepted")
+	}
+}
+
+func TestBuildConfigAppliesFakeIPOptions(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.EnableFakeDNS = true
+	opt.EnableClashApi = false
+	opt.FakeIP = FakeIPOptions{
+		Inet4Range:     "100.64.0.0/10",
+		Inet6Range:     "fd00:64::/48",
+		Inbounds:       []string{InboundTUNTag, InboundDNSTag},
+		ProxyRulesOnly: true,
+		ExcludeDomains: "domain:corp.example, full:printer.example",
+		Persist:        true,
+	}
+	opt.Rules = []Rule{{Domains: "domain:proxied.example", Outbound: "proxy"}}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	var fake option.FakeIPDNSServerOptions
+	for _, server := range options.DNS.Servers {
+		if server.Tag == DNSFakeTag {
+			fake = server.Options.(option.FakeIPDNSServerOptions)
+		}
+	}
+	if fake.Inet4Range == nil || fake.Inet4Range.Build(netip.Prefix{}).String() != "100.64.0.0/10" || fake.Inet6Range.Build(netip.Prefix{}).String() != "fd00:64::/48" {
+		t.Fatalf("unexpected fake ip ranges: %+v", fake)
+	}
+	if cache := options.Experimental.CacheFile; cache == nil || !cache.Enabled || !cache.StoreFakeIP {
+		t.Fatalf("fake ip mappings not persisted: %+v", cache)
+	}
+
+	explain := func(query RouteQuery) string {
+		t.Helper()
+		explanation, err := ExplainRoute(options, query)
+		if err != nil {
+			t.Fatalf("ExplainRoute(%+v) failed: %v", query, err)
+		}
+		return explanation.DNSServer
+	}
+	for _, query := range []RouteQuery{
+		{Domain: "www.proxied.example", Inbound: InboundDNSTag},
+		{Domain: "www.proxied.example", Inbound: InboundTUNTag},
+	} {
+		if server := explain(query); server != DNSFakeTag {
+			t.Fatalf("%+v not answered by fake dns: %s", query, server)
+		}
+	}
+	if server := explain(RouteQuery{Domain: "www.proxied.example", Inbound: InboundTProxyTag}); server == DNSFakeTag {
+		t.Fatalf("fake dns used for an inbound not listed")
+	}
+	if server := explain(RouteQuery{Domain: "other.example", Inbound: InboundTUNTag}); server == DNSFakeTag {
+		t.Fatalf("fake dns used outside the proxy rules")
+	}
+	for _, domain := range []string{"printer.example", "mail.corp.example", "pool.ntp.org", "nas.lan", "connectivitycheck.gstatic.com"} {
+		if server := explain(RouteQuery{Domain: domain, Inbound: InboundTUNTag}); server != DNSLocalTag {
+			t.Fatalf("excluded domain %s resolved by %s", domain, server)
+		}
+	}
+
+	opt.EnableDNSRouting = false
+	if _, err := BuildConfig(*opt, option.Options{}); err == nil {
+		t.Fatalf("proxy-rules-only accepted without dns routing")
+	}
+	opt.EnableDNSRouting = true
+	if _, err := BuildConfig(*opt, option.Options{Route: &option.RouteOptions{}}); err == nil {
+		t.Fatalf("proxy-rules-only accepted with the own route of the profile")
+	}
+
+	opt.FakeIP = FakeIPOptions{Inet4Range: "fc00::/18"}
+	if _, err := BuildConfig(*opt, option.Options{}); err == nil {
+		t.Fatalf("ipv6 range accepted for ipv4")
+	}
+}
+
+func TestBuildConfigAddsLANSharingInbounds(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.AllowConnectionFromLAN = true
+	opt.LANSharing = LANSharingOptions{
+		Users:               []LANSharingUser{{Username: "guest", Password: "secret"}},
+		SocksPort:           12340,
+		HTTPPort:            12341,
+		ShadowsocksPort:     12342,
+		ShadowsocksPassword: "AAECAwQFBgcICQoLDA0ODw==",
+	}
+	options, err := BuildConfig(*opt, option.Options{
+		Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+	})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+
+	inbounds := make(map[string]option.Inbound)
+	for _, inbound := range options.Inbounds {
+		inbounds[inbound.Tag] = inbound
+	}
+	mixed := inbounds[InboundMixedTag].Options.(option.HTTPMixedInboundOptions)
+	if len(mixed.Users) != 1 || mixed.Users[0].Username != "guest" || mixed.Listen.Build(netip.Addr{}).String() != "0.0.0.0" {
+		t.Fatalf("unexpected mixed inbound: %+v", mixed)
+	}
+	socks, ok := inbounds[InboundSocksTag].Options.(option.SocksInboundOptions)
+	if !ok || socks.ListenPort != 12340 || len(socks.Users) != 1 || socks.Listen.Build(netip.Addr{}).String() != "0.0.0.0" {
+		t.Fatalf("unexpected socks inbound: %+v", inbounds[InboundSocksTag])
+	}
+	http, ok := inbounds[InboundHTTPTag].Options.(option.HTTPMixedInboundOptions)
+	if !ok || inbounds[InboundHTTPTag].Type != C.TypeHTTP || http.ListenPort != 12341 || len(http.Users) != 1 {
+		t.Fatalf("unexpected http inbound: %+v", inbounds[InboundHTTPTag])
+	}
+	shadowsocks, ok := inbounds[InboundShadowsocksTag].Options.(option.ShadowsocksInboundOptions)
+	if !ok || shadowsocks.ListenPort != 12342 || shadowsocks.Method != "2022-blake3-aes-128-gcm" {
+		t.Fatalf("unexpected shadowsocks inbound: %+v", inbounds[InboundShadowsocksTag])
+	}
+
+	build := func() map[string]option.Inbound {
+		t.Helper()
+		options, err := BuildConfig(*opt, option.Options{
+			Outbounds: []option.Outbound{minimalShadowsocksOutbound("proxy-a")},
+		})
+		if err != nil {
+			t.Fatalf("BuildConfig failed: %v", err)
+		}
+		inbounds := make(map[string]option.Inbound)
+		for _, inbound := range options.Inbounds {
+			inbounds[inbound.Tag] = inbound
+		}
+		return inbounds
+	}
+
+	// The system proxy cannot authenticate, the LAN gets the extra inbounds.
+	opt.SetSystemProxy = true
+	inbounds = build()
+	mixed = inbounds[InboundMixedTag].Options.(option.HTTPMixedInboundOptions)
+	if len(mixed.Users) != 0 || mixed.Listen.Build(netip.Addr{}).String() != "127.0.0.1" {
+		t.Fatalf("system proxy inbound requires authentication or is reachable from the lan: %+v", mixed)
+	}
+	if socks := inbounds[InboundSocksTag].Options.(option.SocksInboundOptions); len(socks.Users) != 1 || socks.Listen.Build(netip.Addr{}).String() != "0.0.0.0" {
+		t.Fatalf("unexpected socks inbound with system proxy: %+v", socks)
+	}
+
+	opt.SetSystemProxy = false
+	opt.AllowConnectionFromLAN = false
+	inbounds = build()
+	if mixed := inbounds[InboundMixedTag].Options.(option.HTTPMixedInboundOptions); len(mixed.Users) != 0 {
+		t.Fatalf("local mixed inbound requires authentication: %+v", mixed)
+	}
+	for _, tag := range []string{InboundSocksTag, InboundHTTPTag, InboundShadowsocksTag} {
+		if _, found := inbounds[tag]; found {
+			t.Fatalf("lan sharing inbound %s added without lan access", tag)
+		}
+	}
+	opt.AllowConnectionFromLAN = true
+
+	for _, sharing := range []LANSharingOptions{
+		{Users: []LANSharingUser{{Username: "guest"}}},
+		{SocksPort: opt.MixedPort},
+		{SocksPort: 12340, HTTPPort: 12340},
+		{ShadowsocksPort: 12342, ShadowsocksPassword: "short"},
+		{ShadowsocksPort: 12342, ShadowsocksMethod: "aes-128-gcm", ShadowsocksPassword: "AAECAwQFBgcICQoLDA0ODw=="},
+	} {
+		opt.LANSharing = sharing
+		if _, err := BuildConfig(*opt, option.Options{}); err == nil {
+			t.Fatalf("invalid lan sharing options accepted: %+v", sharing)
+		}
+	}
+}
+
+func TestBuildConfigAppliesTUNAddressing(t *testing.T) {
+	opt := DefaultHiddifyOptions()
+	opt.EnableTun = true
+	options, err := BuildConfig(*opt, option.Options{})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+	tun := findTunOptions(t, options)
+	if len(tun.Address) != 2 || tun.Address[0].String() != DefaultTUNInet4Address || !tun.EndpointIndependentNat || len(tun.RouteAddress) != 0 {
+		t.Fatalf("unexpected default tun options: %+v", tun)
+	}
+
+	// Settings saved before the option existed keep the NAT on.
+	var saved HiddifyOptions
+	if err := json.Unmarshal([]byte(`{"enable-tun": true}`), &saved); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	options, err = BuildConfig(saved, option.Options{})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+	if tun := findTunOptions(t, options); !tun.EndpointIndependentNat {
+		t.Fatalf("endpoint independent nat off without the option")
+	}
+
+	opt.TUNInterfaceName = "hiddify0"
+	opt.TUNInet4Address = "10.255.0.1/30"
+	opt.TUNInet6Address = "fd00:1::1/126"
+	opt.TUNRouteAddress = []string{"10.0.0.0/8", "192.0.2.1"}
+	opt.TUNDisableEndpointIndependentNat = true
+	opt.IPv6Mode = option.DomainStrategy(dns.DomainStrategyUseIPv6)
+	options, err = BuildConfig(*opt, option.Options{})
+	if err != nil {
+		t.Fatalf("BuildConfig failed: %v", err)
+	}
+	tun = findTunOptions(t, options)
+	if tun.InterfaceName != "hiddify0" || len(tun.Address) != 1 || tun.Address[0].String() != "fd00:1::1/126" || tun.EndpointIndependentNat {
+		t.Fatalf("unexpected tun options: %+v", tun)
+	}
+	if len(tun.RouteAddress) != 2 || tun.RouteAddress[1].String() != "192.0.2.1/32" {
+		t.Fatalf("unexpected tun route addresses: %v", tun.RouteAddress)
+	}
+
+	for _, inbound := range []InboundOptions{
+		{TUNInet4Address: "fd00:1::1/126"},
+		{TUNInet6Address: "10.255.0.1"},
+		{TUNRouteAddress: []string{"10.0.0.0/33"}},
+	} {
+		opt.InboundOptions = inbound
+		if _, err := BuildConfig(*opt, option.Options{}); err == nil {
+			t.Fatalf("invalid tun options accepted: %+v", inbound)
+		}
+	}
+}
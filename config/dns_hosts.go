@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badjson"
+	"github.com/sagernet/sing/common/json/badoption"
+
+	mDNS "github.com/miekg/dns"
+)
+
+const hostsWildcardPrefix = "*."
+
+func (d DNSOptions) validateOverrides() error {
+	for domain, addresses := range d.Hosts {
+		if err := validateOverrideDomain(domain); err != nil {
+			return fmt.Errorf("hosts: %w", err)
+		}
+		if len(addresses) == 0 {
+			return fmt.Errorf("hosts: no address for %q", domain)
+		}
+		for _, address := range addresses {
+			if _, err := netip.ParseAddr(address); err != nil {
+				return fmt.Errorf("hosts: invalid address %q for %q", address, domain)
+			}
+		}
+	}
+	for domain, resolver := range d.DomainResolvers {
+		if err := validateOverrideDomain(domain); err != nil {
+			return fmt.Errorf("domain resolvers: %w", err)
+		}
+		resolver = strings.TrimSpace(resolver)
+		if resolver == "" {
+			return fmt.Errorf("domain resolvers: no resolver for %q", domain)
+		}
+		if overrideResolverTag(resolver) == "" {
+			if _, err := (DNSServer{Address: resolver}).build(domain, "", ""); err != nil {
+				return fmt.Errorf("domain resolvers: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateOverrideDomain(domain string) error {
+	name := strings.TrimPrefix(domain, hostsWildcardPrefix)
+	if name == "" || strings.ContainsAny(name, "*/: ") {
+		return fmt.Errorf("invalid domain %q", domain)
+	}
+	return nil
+}
+
+// setDNSOverrides puts the hosts and domain resolvers ahead of every other
+// DNS rule. They are applied whether DNS routing is enabled or not.
+func setDNSOverrides(options *option.Options, opt *HiddifyOptions) {
+	if options.DNS == nil || len(opt.Hosts) == 0 && len(opt.DomainResolvers) == 0 {
+		return
+	}
+	var servers []option.DNSServerOptions
+	var rules []option.DNSRule
+
+	predefined := new(badjson.TypedMap[string, badoption.Listable[netip.Addr]])
+	var exactHosts []string
+	for _, domain := range sortedKeys(opt.Hosts) {
+		addresses := parseHostAddresses(opt.Hosts[domain])
+		if name, ok := strings.CutPrefix(domain, hostsWildcardPrefix); ok {
+			rules = append(rules, wildcardHostRules(name, addresses)...)
+			continue
+		}
+		predefined.Put(domain, addresses)
+		exactHosts = append(exactHosts, strings.ToLower(domain))
+	}
+	if len(exactHosts) > 0 {
+		servers = append(servers, option.DNSServerOptions{
+			Tag:     DNSHostsTag,
+			Type:    C.DNSTypeHosts,
+			Options: &option.HostsDNSServerOptions{Predefined: predefined},
+		})
+		rules = append(rules, makeOverrideDNSRule(option.RawDefaultDNSRule{
+			Domain:    exactHosts,
+			QueryType: addressQueryTypes(mDNS.TypeA, mDNS.TypeAAAA),
+		}, dnsRouteActionForServer(DNSHostsTag)))
+	}
+
+	resolverTags := make(map[string]string)
+	for _, domain := range sortedKeys(opt.DomainResolvers) {
+		resolver := strings.TrimSpace(opt.DomainResolvers[domain])
+		tag := overrideResolverTag(resolver)
+		if tag == "" {
+			tag = resolverTags[resolver]
+		}
+		if tag == "" {
+			tag = fmt.Sprintf("%s-%d", DNSOverrideTagPrefix, len(resolverTags))
+			resolverTags[resolver] = tag
+			built, err := DNSServer{Address: resolver}.build(tag, OutboundMainProxyTag, DNSDirectTag)
+			if err != nil {
+				fmt.Printf("failed to build DNS server %s (%s): %v\n", tag, resolver, err)
+				continue
+			}
+			servers = append(servers, built...)
+		}
+		rules = append(rules, makeOverrideDNSRule(overrideDomainCondition(domain), dnsRouteActionForServer(tag)))
+	}
+
+	// The DNS options may be the ones of the input config.
+	dns := *options.DNS
+	dns.Servers = append(servers, dns.Servers...)
+	dns.Rules = append(rules, dns.Rules...)
+	options.DNS = &dns
+}
+
+// overrideResolverTag returns the tag of the built-in server named by
+// resolver, if any.
+func overrideResolverTag(resolver string) string {
+	switch strings.ToLower(resolver) {
+	case "remote":
+		return DNSRemoteTag
+	case "direct":
+		return DNSDirectTag
+	case "local":
+		return DNSLocalTag
+	}
+	return ""
+}
+
+func overrideDomainCondition(domain string) option.RawDefaultDNSRule {
+	if name, ok := strings.CutPrefix(domain, hostsWildcardPrefix); ok {
+		// A leading dot limits the suffix to the subdomains.
+		return option.RawDefaultDNSRule{DomainSuffix: []string{"." + strings.ToLower(name)}}
+	}
+	return option.RawDefaultDNSRule{Domain: []string{strings.ToLower(domain)}}
+}
+
+// wildcardHostRules answers the address queries of the subdomains of name.
+// Queries for a family without addresses get an empty answer rather than
+// falling through to another server.
+func wildcardHostRules(name string, addresses []netip.Addr) []option.DNSRule {
+	var inet4, inet6 badoption.Listable[option.DNSRecordOptions]
+	owner := mDNS.Fqdn(hostsWildcardPrefix + strings.ToLower(name))
+	for _, address := range addresses {
+		header := mDNS.RR_Header{Name: owner, Class: mDNS.ClassINET, Ttl: C.DefaultDNSTTL}
+		if address.Is4() {
+			header.Rrtype = mDNS.TypeA
+			inet4 = append(inet4, option.DNSRecordOptions{RR: &mDNS.A{Hdr: header, A: address.AsSlice()}})
+		} else {
+			header.Rrtype = mDNS.TypeAAAA
+			inet6 = append(inet6, option.DNSRecordOptions{RR: &mDNS.AAAA{Hdr: header, AAAA: address.AsSlice()}})
+		}
+	}
+	inet4Condition := overrideDomainCondition(hostsWildcardPrefix + name)
+	inet4Condition.QueryType = addressQueryTypes(mDNS.TypeA)
+	inet6Condition := overrideDomainCondition(hostsWildcardPrefix + name)
+	inet6Condition.QueryType = addressQueryTypes(mDNS.TypeAAAA)
+	return []option.DNSRule{
+		makeOverrideDNSRule(inet4Condition, predefinedDNSAction(inet4)),
+		makeOverrideDNSRule(inet6Condition, predefinedDNSAction(inet6)),
+	}
+}
+
+func predefinedDNSAction(answer badoption.Listable[option.DNSRecordOptions]) option.DNSRuleAction {
+	return option.DNSRuleAction{
+		Action:            C.RuleActionTypePredefined,
+		PredefinedOptions: option.DNSRouteActionPredefined{Answer: answer},
+	}
+}
+
+func makeOverrideDNSRule(condition option.RawDefaultDNSRule, action option.DNSRuleAction) option.DNSRule {
+	return option.DNSRule{
+		Type: C.RuleTypeDefault,
+		DefaultOptions: option.DefaultDNSRule{
+			RawDefaultDNSRule: condition,
+			DNSRuleAction:     action,
+		},
+	}
+}
+
+func addressQueryTypes(types ...uint16) badoption.Listable[option.DNSQueryType] {
+	queryTypes := make(badoption.Listable[option.DNSQueryType], 0, len(types))
+	for _, queryType := range types {
+		queryTypes = append(queryTypes, option.DNSQueryType(queryType))
+	}
+	return queryTypes
+}
+
+func parseHostAddresses(values []string) []netip.Addr {
+	addresses := make([]netip.Addr, 0, len(values))
+	for _, value := range values {
+		if address, err := netip.ParseAddr(value); err == nil {
+			addresses = append(addresses, address.Unmap())
+		}
+	}
+	return addresses
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,363 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+	M "github.com/sagernet/sing/common/metadata"
+
+	mDNS "github.com/miekg/dns"
+)
+
+const (
+	// DNSServerPolicyEmptyAnswer moves on to the next server of a role when a
+	// server answers an address query without addresses. sing-box only moves
+	// on from rejected answers, so queries that fail or time out are not
+	// retried and unreachable servers are not skipped.
+	DNSServerPolicyEmptyAnswer = "empty-answer"
+	// DNSServerPolicyRace would query every server at once. sing-box 1.12
+	// has no way to express it, so it is rejected.
+	DNSServerPolicyRace = "race"
+)
+
+// DNSServer describes a resolver. Address is an ip, an ip:port, "local" or
+// a URL with the udp, tcp, tls, https, h3, quic or dhcp scheme.
+type DNSServer struct {
+	Address string `json:"address"`
+	// Bootstrap resolves the host of Address when it is a domain, given as
+	// local, direct, remote or an ip. It defaults to the direct resolver for
+	// remote servers and to the local one for direct servers.
+	Bootstrap string `json:"bootstrap"`
+	// Detour is the outbound the queries go through, accepting the values of
+	// Rule.Outbound. Remote servers use the proxy and direct servers no
+	// outbound by default.
+	Detour string `json:"detour"`
+	// ClientSubnet is sent as EDNS client subnet, as a prefix or an ip.
+	ClientSubnet string `json:"client-subnet"`
+}
+
+// dnsServerRole is the resolved list of servers behind a role tag.
+type dnsServerRole struct {
+	tag              string
+	servers          []DNSServer
+	strategy         option.DomainStrategy
+	defaultDetour    string
+	defaultBootstrap string
+}
+
+func (r dnsServerRole) serverTag(index int) string {
+	if index == 0 {
+		return r.tag
+	}
+	return fmt.Sprintf("%s-%d", r.tag, index)
+}
+
+// dnsServerRoles returns the remote and direct roles, falling back to the
+// single address options when no server list is given.
+func dnsServerRoles(opt *HiddifyOptions) []dnsServerRole {
+	remote := opt.RemoteDnsServers
+	if len(remote) == 0 {
+		remote = []DNSServer{{Address: opt.RemoteDnsAddress}}
+	}
+	direct := opt.DirectDnsServers
+	if len(direct) == 0 {
+		direct = []DNSServer{{Address: opt.DirectDnsAddress}}
+	}
+	return []dnsServerRole{
+		{
+			tag:              DNSRemoteTag,
+			servers:          remote,
+			strategy:         opt.RemoteDnsDomainStrategy,
+			defaultDetour:    OutboundMainProxyTag,
+			defaultBootstrap: DNSDirectTag,
+		},
+		{
+			tag:              DNSDirectTag,
+			servers:          direct,
+			strategy:         opt.DirectDnsDomainStrategy,
+			defaultBootstrap: DNSLocalTag,
+		},
+	}
+}
+
+func (d DNSOptions) validateServers() error {
+	switch d.DNSServerPolicy {
+	case "", DNSServerPolicyEmptyAnswer:
+	case DNSServerPolicyRace:
+		return fmt.Errorf("dns server policy %q is not supported by sing-box", d.DNSServerPolicy)
+	default:
+		return fmt.Errorf("unknown dns server policy %q", d.DNSServerPolicy)
+	}
+	for _, servers := range [][]DNSServer{d.RemoteDnsServers, d.DirectDnsServers} {
+		for _, server := range servers {
+			if _, err := server.build("", "", ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// build converts the server to typed sing-box servers: the server itself
+// with the tag, then its bootstrap server when it is given as an address.
+func (s DNSServer) build(tag string, defaultDetour string, defaultBootstrap string) ([]option.DNSServerOptions, error) {
+	address := strings.TrimSpace(s.Address)
+	if address == "" {
+		return nil, fmt.Errorf("dns server %s: missing address", tag)
+	}
+	if address == C.DNSTypeLocal {
+		return []option.DNSServerOptions{{Tag: tag, Type: C.DNSTypeLocal, Options: &option.LocalDNSServerOptions{}}}, nil
+	}
+	serverType := C.DNSTypeUDP
+	host := address
+	var serverURL *url.URL
+	if strings.Contains(address, "://") {
+		var err error
+		serverURL, err = url.Parse(address)
+		if err != nil {
+			return nil, fmt.Errorf("dns server %s: %w", tag, err)
+		}
+		serverType, host = serverURL.Scheme, serverURL.Host
+	}
+	if serverType == C.DNSTypeDHCP {
+		dhcpOptions := &option.DHCPDNSServerOptions{}
+		if host != "" && host != "auto" {
+			dhcpOptions.Interface = host
+		}
+		return []option.DNSServerOptions{{Tag: tag, Type: C.DNSTypeDHCP, Options: dhcpOptions}}, nil
+	}
+
+	serverAddr := M.ParseSocksaddr(host)
+	if !serverAddr.IsValid() {
+		return nil, fmt.Errorf("dns server %s: invalid address %q", tag, address)
+	}
+	remoteOptions := option.RemoteDNSServerOptions{
+		DNSServerAddressOptions: option.DNSServerAddressOptions{
+			Server:     serverAddr.AddrString(),
+			ServerPort: serverAddr.Port,
+		},
+	}
+	if detour := s.Detour; detour != "" {
+		remoteOptions.Detour = resolveRuleOutbound(detour)
+	} else {
+		remoteOptions.Detour = defaultDetour
+	}
+	switch remoteOptions.Detour {
+	case OutboundDirectTag, OutboundBypassTag:
+		// sing-box rejects detours to plain direct outbounds.
+		remoteOptions.Detour = ""
+	}
+
+	var servers []option.DNSServerOptions
+	if serverAddr.IsFqdn() {
+		bootstrap := strings.TrimSpace(s.Bootstrap)
+		switch resolverTag := overrideResolverTag(bootstrap); {
+		case resolverTag != "":
+			bootstrap = resolverTag
+		case bootstrap == "":
+			bootstrap = defaultBootstrap
+		default:
+			bootstrapAddr, err := netip.ParseAddr(bootstrap)
+			if err != nil {
+				return nil, fmt.Errorf("dns server %s: invalid bootstrap %q", tag, s.Bootstrap)
+			}
+			bootstrapTag := tag + "-bootstrap"
+			servers = append(servers, option.DNSServerOptions{
+				Tag:  bootstrapTag,
+				Type: C.DNSTypeUDP,
+				Options: &option.RemoteDNSServerOptions{
+					DNSServerAddressOptions: option.DNSServerAddressOptions{Server: bootstrapAddr.String()},
+				},
+			})
+			bootstrap = bootstrapTag
+		}
+		remoteOptions.DomainResolver = &option.DomainResolveOptions{Server: bootstrap}
+	}
+
+	var server option.DNSServerOptions
+	switch serverType {
+	case C.DNSTypeUDP, C.DNSTypeTCP:
+		server = option.DNSServerOptions{Tag: tag, Type: serverType, Options: &remoteOptions}
+	case C.DNSTypeTLS, C.DNSTypeQUIC:
+		server = option.DNSServerOptions{Tag: tag, Type: serverType, Options: &option.RemoteTLSDNSServerOptions{
+			RemoteDNSServerOptions: remoteOptions,
+		}}
+	case C.DNSTypeHTTPS, C.DNSTypeHTTP3:
+		httpsOptions := &option.RemoteHTTPSDNSServerOptions{
+			RemoteTLSDNSServerOptions: option.RemoteTLSDNSServerOptions{RemoteDNSServerOptions: remoteOptions},
+		}
+		if serverURL.Path != "" && serverURL.Path != "/dns-query" {
+			httpsOptions.Path = serverURL.Path
+		}
+		server = option.DNSServerOptions{Tag: tag, Type: serverType, Options: httpsOptions}
+	default:
+		return nil, fmt.Errorf("dns server %s: unsupported scheme %q", tag, serverType)
+	}
+	if _, err := s.clientSubnet(); err != nil {
+		return nil, fmt.Errorf("dns server %s: %w", tag, err)
+	}
+	return append([]option.DNSServerOptions{server}, servers...), nil
+}
+
+func (s DNSServer) clientSubnet() (*badoption.Prefixable, error) {
+	if s.ClientSubnet == "" {
+		return nil, nil
+	}
+	var prefix badoption.Prefixable
+	if err := prefix.UnmarshalJSON([]byte(`"` + s.ClientSubnet + `"`)); err != nil {
+		return nil, fmt.Errorf("invalid client subnet %q", s.ClientSubnet)
+	}
+	return &prefix, nil
+}
+
+// buildRoleDNSServers returns the typed servers of the roles. A server that
+// does not build is reported and left out, the first server of a role falls
+// back to the public resolver so the role tag always exists.
+func buildRoleDNSServers(roles []dnsServerRole) []option.DNSServerOptions {
+	var servers []option.DNSServerOptions
+	for _, role := range roles {
+		for i, server := range role.servers {
+			built, err := server.build(role.serverTag(i), role.defaultDetour, role.defaultBootstrap)
+			if err != nil && i == 0 {
+				fmt.Printf("failed to build DNS server %s (%s): %v\n", role.serverTag(i), server.Address, err)
+				built, err = DNSServer{Address: "1.1.1.1", Detour: role.defaultDetour}.build(role.tag, role.defaultDetour, role.defaultBootstrap)
+			}
+			if err != nil {
+				fmt.Printf("failed to build DNS server %s (%s): %v\n", role.serverTag(i), server.Address, err)
+				continue
+			}
+			servers = append(servers, built...)
+		}
+	}
+	return servers
+}
+
+// setDNSServerPolicy gives the queries sent to a role the strategy and
+// client subnet of its servers and sends the address queries a server
+// answers without addresses to the next server of the role. It runs once
+// every DNS rule is in place.
+func setDNSServerPolicy(options *option.Options, opt *HiddifyOptions) {
+	if options.DNS == nil {
+		return
+	}
+	actions := make(map[string]option.DNSRouteActionOptions)
+	fallbacks := make(map[string][]string)
+	for _, role := range dnsServerRoles(opt) {
+		var tags []string
+		for i, server := range role.servers {
+			tag := role.serverTag(i)
+			if !hasDNSServer(options.DNS, tag) {
+				continue
+			}
+			subnet, _ := server.clientSubnet()
+			actions[tag] = option.DNSRouteActionOptions{Server: tag, Strategy: role.strategy, ClientSubnet: subnet}
+			tags = append(tags, tag)
+		}
+		if len(tags) > 1 {
+			fallbacks[role.tag] = tags
+		}
+	}
+
+	var rules []option.DNSRule
+	for _, rule := range options.DNS.Rules {
+		rules = append(rules, fallbackDNSRules(&rule, fallbacks[dnsRuleServer(rule)])...)
+		rules = append(rules, rule)
+	}
+	rules = append(rules, fallbackDNSRules(nil, fallbacks[options.DNS.Final])...)
+	for i := range rules {
+		setDNSRouteOptions(&rules[i], actions)
+	}
+	options.DNS.Rules = rules
+
+	if final, ok := actions[options.DNS.Final]; ok {
+		options.DNS.Strategy = final.Strategy
+		options.DNS.ClientSubnet = final.ClientSubnet
+	}
+}
+
+// fallbackDNSRules returns the rules trying the servers in order for the
+// queries matching rule, or every query when rule is nil. All but the last
+// rule only hold when their server answers with addresses, so sing-box
+// moves on otherwise. The last one takes the remaining address queries;
+// other queries are left to rule, which uses the first server.
+func fallbackDNSRules(rule *option.DNSRule, tags []string) []option.DNSRule {
+	var rules []option.DNSRule
+	for i, tag := range tags {
+		var condition option.RawDefaultDNSRule
+		if i < len(tags)-1 {
+			condition.IPAcceptAny = true
+		} else {
+			condition.QueryType = addressQueryTypes(mDNS.TypeA, mDNS.TypeAAAA, mDNS.TypeHTTPS)
+		}
+		action := dnsRouteActionForServer(tag)
+		if rule == nil {
+			rules = append(rules, makeOverrideDNSRule(condition, action))
+			continue
+		}
+		inner := *rule
+		if inner.Type == C.RuleTypeLogical {
+			action.RouteOptions.DisableCache = inner.LogicalOptions.RouteOptions.DisableCache
+			action.RouteOptions.RewriteTTL = inner.LogicalOptions.RouteOptions.RewriteTTL
+		} else {
+			action.RouteOptions.DisableCache = inner.DefaultOptions.RouteOptions.DisableCache
+			action.RouteOptions.RewriteTTL = inner.DefaultOptions.RouteOptions.RewriteTTL
+		}
+		setDNSRuleAction(&inner, option.DNSRuleAction{})
+		fallback := option.DNSRule{
+			Type: C.RuleTypeLogical,
+			LogicalOptions: option.LogicalDNSRule{
+				RawLogicalDNSRule: option.RawLogicalDNSRule{
+					Mode:  C.LogicalTypeAnd,
+					Rules: []option.DNSRule{inner, makeOverrideDNSRule(condition, option.DNSRuleAction{})},
+				},
+				DNSRuleAction: action,
+			},
+		}
+		rules = append(rules, fallback)
+	}
+	return rules
+}
+
+func dnsRuleServer(rule option.DNSRule) string {
+	action := rule.DefaultOptions.DNSRuleAction
+	if rule.Type == C.RuleTypeLogical {
+		action = rule.LogicalOptions.DNSRuleAction
+	}
+	if action.Action != C.RuleActionTypeRoute && action.Action != "" {
+		return ""
+	}
+	return action.RouteOptions.Server
+}
+
+// setDNSRouteOptions fills the strategy and client subnet of a rule routing
+// to one of the servers, keeping the ones the rule sets itself.
+func setDNSRouteOptions(rule *option.DNSRule, actions map[string]option.DNSRouteActionOptions) {
+	routeOptions := &rule.DefaultOptions.RouteOptions
+	if rule.Type == C.RuleTypeLogical {
+		routeOptions = &rule.LogicalOptions.RouteOptions
+	}
+	defaults, ok := actions[dnsRuleServer(*rule)]
+	if !ok {
+		return
+	}
+	if routeOptions.Strategy == option.DomainStrategy(C.DomainStrategyAsIS) {
+		routeOptions.Strategy = defaults.Strategy
+	}
+	if routeOptions.ClientSubnet == nil {
+		routeOptions.ClientSubnet = defaults.ClientSubnet
+	}
+}
+
+func hasDNSServer(dns *option.DNSOptions, tag string) bool {
+	for _, server := range dns.Servers {
+		if server.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+const (
+	defaultFakeIPInet4Range = "198.18.0.0/15"
+	defaultFakeIPInet6Range = "fc00::/18"
+)
+
+// fakeIPExcludedDomains always get real addresses: local names, time
+// servers and the hosts probed to detect captive portals, which break when
+// answered with fake addresses.
+var fakeIPExcludedDomains = []string{
+	"full:localhost",
+	"domain:lan",
+	"domain:local",
+	"domain:localdomain",
+	"domain:home.arpa",
+	"domain:ntp.org",
+	"domain:time.windows.com",
+	"domain:time.apple.com",
+	"domain:time.google.com",
+	"domain:time.cloudflare.com",
+	"domain:msftconnecttest.com",
+	"domain:msftncsi.com",
+	"full:captive.apple.com",
+	"full:connectivitycheck.gstatic.com",
+	"full:connectivitycheck.android.com",
+	"full:clients3.google.com",
+	"full:detectportal.firefox.com",
+	"full:nmcheck.gnome.org",
+}
+
+// FakeIPOptions tunes the fake DNS enabled by EnableFakeDNS.
+type FakeIPOptions struct {
+	// Inet4Range and Inet6Range are the prefixes fake addresses are taken
+	// from, 198.18.0.0/15 and fc00::/18 when empty.
+	Inet4Range string `json:"inet4-range"`
+	Inet6Range string `json:"inet6-range"`
+	// Inbounds are the tags of the inbounds whose queries get fake
+	// addresses, the TUN and TProxy inbounds when empty.
+	Inbounds []string `json:"inbounds"`
+	// ProxyRulesOnly limits fake addresses to the domains of the rules
+	// routed through the proxy instead of every query. It needs
+	// EnableDNSRouting, and a profile without a route of its own, since
+	// those rules come from the routing built by Hiddify.
+	ProxyRulesOnly bool `json:"proxy-rules-only"`
+	// ExcludeDomains get real addresses, in the syntax of Rule.Domains, in
+	// addition to local names, time servers and captive portal checks.
+	ExcludeDomains string `json:"exclude-domains"`
+	// Persist keeps the fake address mappings in the cache file, so
+	// connections to addresses handed out before a restart keep working.
+	Persist bool `json:"persist"`
+}
+
+// validate checks the options, and that the rules ProxyRulesOnly relies on
+// are emitted when fake DNS is enabled, which needs DNS routing and the
+// routing of Hiddify instead of the own route of the profile.
+func (f FakeIPOptions) validate(enabled bool, dnsRouting bool, ownRoute bool) error {
+	if _, err := f.ranges(); err != nil {
+		return err
+	}
+	if !enabled || !f.ProxyRulesOnly {
+		return nil
+	}
+	if !dnsRouting {
+		return fmt.Errorf("fake ip: proxy-rules-only needs enable-dns-routing")
+	}
+	if ownRoute {
+		return fmt.Errorf("fake ip: proxy-rules-only is not supported with the own route of the profile")
+	}
+	return nil
+}
+
+func (f FakeIPOptions) ranges() ([2]*badoption.Prefix, error) {
+	var ranges [2]*badoption.Prefix
+	for i, value := range []string{f.Inet4Range, f.Inet6Range} {
+		if value == "" {
+			value = []string{defaultFakeIPInet4Range, defaultFakeIPInet6Range}[i]
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return ranges, fmt.Errorf("fake ip: invalid range %q", value)
+		}
+		if prefix.Addr().Is4() != (i == 0) {
+			return ranges, fmt.Errorf("fake ip: range %q is not ipv%d", value, []int{4, 6}[i])
+		}
+		badPrefix := badoption.Prefix(prefix.Masked())
+		ranges[i] = &badPrefix
+	}
+	return ranges, nil
+}
+
+func (f FakeIPOptions) inbounds() []string {
+	if len(f.Inbounds) > 0 {
+		return f.Inbounds
+	}
+	return []string{InboundTUNTag, InboundTProxyTag}
+}
+
+// excludeRule is the DNS rule of the domains never answered with fake
+// addresses, without action.
+func (f FakeIPOptions) excludeRule() option.DNSRule {
+	domains := append([]string(nil), fakeIPExcludedDomains...)
+	domains = append(domains, splitList(f.ExcludeDomains)...)
+	rule := Rule{Domains: strings.Join(domains, ",")}
+	return rule.MakeDNSRule(nil)
+}
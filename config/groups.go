@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	badoption "github.com/sagernet/sing/common/json/badoption"
+)
+
+const (
+	ProxyGroupSelector = "selector"
+	ProxyGroupURLTest  = "urltest"
+	ProxyGroupFallback = "fallback"
+
+	// fallbackTolerance keeps a fallback group on its current member while it
+	// is reachable. It is well below the uint16 limit since sing-box adds it
+	// to the measured delay.
+	fallbackTolerance = 30000
+)
+
+// ProxyGroup is a user defined outbound group. Members are the proxies
+// matching every filter that is set, followed by the explicit Outbounds,
+// which may also name other groups. The group name can be used as
+// Rule.Outbound.
+type ProxyGroup struct {
+	Name string `json:"name"`
+	// Type is one of selector, urltest or fallback; load-balance is
+	// rejected since sing-box has no load-balancing outbound. Fallback is an
+	// urltest group approximating a priority list: the first reachable
+	// member in order is picked and kept while it stays reachable, but the
+	// group does not move back to an earlier member once it recovers.
+	Type          string   `json:"type"`
+	Filter        string   `json:"filter"`
+	ExcludeFilter string   `json:"exclude-filter"`
+	Countries     []string `json:"countries"`
+	Protocols     []string `json:"protocols"`
+	Outbounds     []string `json:"outbounds"`
+	Tolerance     uint16   `json:"tolerance"`
+}
+
+func (g *ProxyGroup) members(proxies []option.Outbound) ([]string, error) {
+	var filter, excludeFilter *regexp.Regexp
+	var err error
+	if g.Filter != "" {
+		if filter, err = regexp.Compile(g.Filter); err != nil {
+			return nil, fmt.Errorf("group %s: invalid filter: %w", g.Name, err)
+		}
+	}
+	if g.ExcludeFilter != "" {
+		if excludeFilter, err = regexp.Compile(g.ExcludeFilter); err != nil {
+			return nil, fmt.Errorf("group %s: invalid exclude-filter: %w", g.Name, err)
+		}
+	}
+	var members []string
+	if filter != nil || len(g.Countries) > 0 || len(g.Protocols) > 0 {
+		for _, proxy := range proxies {
+			if filter != nil && !filter.MatchString(proxy.Tag) {
+				continue
+			}
+			if excludeFilter != nil && excludeFilter.MatchString(proxy.Tag) {
+				continue
+			}
+			if len(g.Protocols) > 0 && !slices.ContainsFunc(g.Protocols, func(protocol string) bool {
+				return strings.EqualFold(protocol, proxy.Type)
+			}) {
+				continue
+			}
+			if len(g.Countries) > 0 && !slices.ContainsFunc(g.Countries, func(country string) bool {
+				return tagMatchesCountry(proxy.Tag, country)
+			}) {
+				continue
+			}
+			members = append(members, proxy.Tag)
+		}
+	}
+	for _, outbound := range g.Outbounds {
+		members = append(members, resolveRuleOutbound(outbound))
+	}
+	return removeDuplicateStr(members), nil
+}
+
+// tagMatchesCountry reports whether a tag is labeled with the ISO country
+// code, either by its flag emoji or by the code as a separate word.
+func tagMatchesCountry(tag string, country string) bool {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if len(country) != 2 {
+		return false
+	}
+	flag := string([]rune{
+		rune(country[0]) - 'A' + 0x1F1E6,
+		rune(country[1]) - 'A' + 0x1F1E6,
+	})
+	if strings.Contains(tag, flag) {
+		return true
+	}
+	words := strings.FieldsFunc(tag, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		if strings.EqualFold(word, country) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *ProxyGroup) build(members []string, opt *HiddifyOptions) (option.Outbound, error) {
+	urlTest := option.URLTestOutboundOptions{
+		Outbounds:   members,
+		URL:         opt.ConnectionTestUrl,
+		Interval:    badoption.Duration(opt.URLTestInterval.Duration()),
+		Tolerance:   g.Tolerance,
+		IdleTimeout: badoption.Duration(opt.URLTestInterval.Duration() * 3),
+	}
+	switch g.Type {
+	case ProxyGroupSelector:
+		return option.Outbound{
+			Type: C.TypeSelector,
+			Tag:  g.Name,
+			Options: option.SelectorOutboundOptions{
+				Outbounds:                 members,
+				Default:                   members[0],
+				InterruptExistConnections: true,
+			},
+		}, nil
+	case ProxyGroupURLTest, "":
+		if urlTest.Tolerance == 0 {
+			urlTest.Tolerance = 50
+		}
+	case ProxyGroupFallback:
+		// The first reachable member in order is used until it fails.
+		urlTest.Tolerance = fallbackTolerance
+		urlTest.InterruptExistConnections = true
+	case "load-balance":
+		return option.Outbound{}, fmt.Errorf("group %s: type load-balance is not supported, sing-box has no load-balancing outbound", g.Name)
+	default:
+		return option.Outbound{}, fmt.Errorf("group %s: unknown type %q", g.Name, g.Type)
+	}
+	return option.Outbound{
+		Type:    C.TypeURLTest,
+		Tag:     g.Name,
+		Options: urlTest,
+	}, nil
+}
+
+// buildProxyGroups builds the user groups from the proxies of the profile.
+// A group without members falls back to the main proxy.
+func buildProxyGroups(groups []ProxyGroup, proxies []option.Outbound, opt *HiddifyOptions) ([]option.Outbound, error) {
+	reserved := map[string]bool{
+		OutboundSelectTag:         true,
+		OutboundURLTestTag:        true,
+		OutboundDirectTag:         true,
+		OutboundDirectFragmentTag: true,
+		OutboundBypassTag:         true,
+		OutboundBlockTag:          true,
+		OutboundDNSTag:            true,
+	}
+	for _, proxy := range proxies {
+		reserved[proxy.Tag] = true
+	}
+	var outbounds []option.Outbound
+	for _, group := range groups {
+		if group.Name == "" {
+			return nil, fmt.Errorf("group without a name")
+		}
+		if reserved[group.Name] {
+			return nil, fmt.Errorf("group %s: name already used by another outbound", group.Name)
+		}
+		reserved[group.Name] = true
+		members, err := group.members(proxies)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) == 0 {
+			fmt.Printf("group %s has no members, using %s\n", group.Name, OutboundMainProxyTag)
+			members = []string{OutboundMainProxyTag}
+		}
+		outbound, err := group.build(members, opt)
+		if err != nil {
+			return nil, err
+		}
+		outbounds = append(outbounds, outbound)
+	}
+	return outbounds, nil
+}
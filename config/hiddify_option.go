@@ -22,9 +22,14 @@ type HiddifyOptions struct {
 	UseXrayCoreWhenPossible bool   `json:"use-xray-core-when-possible"`
 	// GeoIPPath        string      `json:"geoip-path"`
 	// GeoSitePath      string      `json:"geosite-path"`
-	Rules     []Rule     `json:"rules"`
-	Mux       MuxOptions `json:"mux"`
-	TLSTricks TLSTricks  `json:"tls-tricks"`
+	Rules      []Rule           `json:"rules"`
+	Groups     []ProxyGroup     `json:"groups"`
+	Chains     []ProxyChain     `json:"chains"`
+	ClashModes ClashModeOptions `json:"clash-modes"`
+	Mux        MuxOptions       `json:"mux"`
+	TLSTricks  TLSTricks        `json:"tls-tricks"`
+	// PerAppProxy splits the TUN traffic by application.
+	PerAppProxy PerAppProxyOptions `json:"per-app-proxy"`
 	DNSOptions
 	InboundOptions
 	URLTestOptions
@@ -38,7 +43,22 @@ type DNSOptions struct {
 	DirectDnsDomainStrategy option.DomainStrategy `json:"direct-dns-domain-strategy"`
 	IndependentDNSCache     bool                  `json:"independent-dns-cache"`
 	EnableFakeDNS           bool                  `json:"enable-fake-dns"`
+	FakeIP                  FakeIPOptions         `json:"fake-ip"`
 	EnableDNSRouting        bool                  `json:"enable-dns-routing"`
+	// Hosts pins domains to addresses and DomainResolvers sends the queries
+	// of domains to a resolver, given as an address or as remote, direct or
+	// local. Keys are exact domains, or "*.example.com" for the subdomains.
+	// RemoteDnsServers and DirectDnsServers replace the single addresses
+	// above when given. Further servers are used according to
+	// DNSServerPolicy, which only supports "empty-answer", the default: the
+	// next server is asked when one answers an address query without
+	// addresses. It is not a failover, queries that fail or time out are
+	// not retried on the next server.
+	RemoteDnsServers []DNSServer         `json:"remote-dns-servers"`
+	DirectDnsServers []DNSServer         `json:"direct-dns-servers"`
+	DNSServerPolicy  string              `json:"dns-server-policy"`
+	Hosts            map[string][]string `json:"hosts"`
+	DomainResolvers  map[string]string   `json:"domain-resolvers"`
 }
 
 type InboundOptions struct {
@@ -46,11 +66,31 @@ type InboundOptions struct {
 	EnableTunService bool   `json:"enable-tun-service"`
 	SetSystemProxy   bool   `json:"set-system-proxy"`
 	MixedPort        uint16 `json:"mixed-port"`
-	TProxyPort       uint16 `json:"tproxy-port"`
+	TProxyPort       uint16 `json:"tproxy-port"`   // linux only, 0 disables
+	RedirectPort     uint16 `json:"redirect-port"` // linux and macOS only, 0 disables
 	LocalDnsPort     uint16 `json:"local-dns-port"`
 	MTU              uint32 `json:"mtu"`
 	StrictRoute      bool   `json:"strict-route"`
 	TUNStack         string `json:"tun-implementation"`
+	// TUNInterfaceName is picked by the system when empty. TUNInet4Address
+	// and TUNInet6Address are the prefixes of the TUN interface,
+	// DefaultTUNInet4Address and DefaultTUNInet6Address when empty.
+	TUNInterfaceName string `json:"tun-interface-name"`
+	TUNInet4Address  string `json:"tun-inet4-address"`
+	TUNInet6Address  string `json:"tun-inet6-address"`
+	// TUNRouteAddress limits the TUN routes to these addresses instead of
+	// all traffic.
+	TUNRouteAddress []string `json:"tun-route-address"`
+	TUNAutoRedirect bool     `json:"tun-auto-redirect"` // linux only
+	// TUNDisableEndpointIndependentNat turns off the endpoint independent
+	// NAT of the TUN, which is on by default.
+	TUNDisableEndpointIndependentNat bool `json:"tun-disable-endpoint-independent-nat"`
+	// TUNExcludeAddress and TUNExcludeRuleSets are kept out of the TUN routes
+	// in addition to the addresses of the region. Rule-sets are given as
+	// urls or paths like Rule.RuleSetUrl.
+	TUNExcludeAddress  []string          `json:"tun-exclude-address"`
+	TUNExcludeRuleSets []string          `json:"tun-exclude-rule-sets"`
+	LANSharing         LANSharingOptions `json:"lan-sharing"`
 }
 
 type URLTestOptions struct {
@@ -66,20 +106,48 @@ type RouteOptions struct {
 	AllowConnectionFromLAN bool                  `json:"allow-connection-from-lan"`
 }
 
+// ClashModeOptions names the clash modes offered by the core. Each mode
+// sends all traffic to its Outbound, which accepts the same values as
+// Rule.Outbound. DefaultMode is the mode selected at start, "Rule" when
+// empty; any name without a mode routes by rules.
+type ClashModeOptions struct {
+	DefaultMode string      `json:"default-mode"`
+	Modes       []ClashMode `json:"modes"`
+}
+
+type ClashMode struct {
+	Name     string `json:"name"`
+	Outbound string `json:"outbound"`
+}
+
+// TLSTricks alter the ClientHello of TLS outbounds. sing-box fragments the
+// ClientHello at the SNI, so FragmentSize and FragmentSleep must be empty
+// when EnableFragment is set, and it cannot pad the ClientHello, so
+// EnablePadding is rejected. PaddingSize is unused.
 type TLSTricks struct {
 	EnableFragment bool   `json:"enable-fragment"`
 	FragmentSize   string `json:"fragment-size"`
 	FragmentSleep  string `json:"fragment-sleep"`
+	RecordFragment bool   `json:"record-fragment"`
 	MixedSNICase   bool   `json:"mixed-sni-case"`
 	EnablePadding  bool   `json:"enable-padding"`
 	PaddingSize    string `json:"padding-size"`
 }
 
 type MuxOptions struct {
-	Enable     bool   `json:"enable"`
-	Padding    bool   `json:"padding"`
-	MaxStreams int    `json:"max-streams"`
-	Protocol   string `json:"protocol"`
+	Enable         bool             `json:"enable"`
+	Padding        bool             `json:"padding"`
+	MaxStreams     int              `json:"max-streams"`
+	MinStreams     int              `json:"min-streams"`
+	MaxConnections int              `json:"max-connections"`
+	Protocol       string           `json:"protocol"`
+	Brutal         MuxBrutalOptions `json:"brutal"`
+}
+
+type MuxBrutalOptions struct {
+	Enable   bool `json:"enable"`
+	UpMbps   int  `json:"up-mbps"`
+	DownMbps int  `json:"down-mbps"`
 }
 
 func DefaultHiddifyOptions() *HiddifyOptions {
@@ -98,7 +166,8 @@ func DefaultHiddifyOptions() *HiddifyOptions {
 			EnableTun:      false,
 			SetSystemProxy: false,
 			MixedPort:      12334,
-			TProxyPort:     12335,
+			TProxyPort:     0,
+			RedirectPort:   0,
 			LocalDnsPort:   16450,
 			MTU:            9000,
 			StrictRoute:    true,
@@ -125,19 +194,31 @@ func DefaultHiddifyOptions() *HiddifyOptions {
 		// GeoIPPath:      "geoip.db",
 		// GeoSitePath:    "geosite.db",
 		Rules: []Rule{},
+		ClashModes: ClashModeOptions{
+			DefaultMode: "",
+			Modes: []ClashMode{
+				{Name: "关闭代理", Outbound: OutboundDirectTag},
+				{Name: "全局代理", Outbound: "proxy"},
+			},
+		},
 		Mux: MuxOptions{
-			Enable:     false,
-			Padding:    true,
-			MaxStreams: 8,
-			Protocol:   "h2mux",
+			Enable:         false,
+			Padding:        true,
+			MaxStreams:     8,
+			MinStreams:     0,
+			MaxConnections: 0,
+			Protocol:       "h2mux",
+			Brutal: MuxBrutalOptions{
+				Enable:   false,
+				UpMbps:   0,
+				DownMbps: 0,
+			},
 		},
 		TLSTricks: TLSTricks{
 			EnableFragment: false,
-			FragmentSize:   "10-100",
-			FragmentSleep:  "50-200",
+			RecordFragment: false,
 			MixedSNICase:   false,
 			EnablePadding:  false,
-			PaddingSize:    "1200-1500",
 		},
 		UseXrayCoreWhenPossible: false,
 	}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/netip"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/auth"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+const defaultLANSharingShadowsocksMethod = "2022-blake3-aes-128-gcm"
+
+// shadowsocks2022KeyLengths are the key lengths of the Shadowsocks 2022
+// methods, whose passwords are base64 keys of exactly that length.
+var shadowsocks2022KeyLengths = map[string]int{
+	"2022-blake3-aes-128-gcm":       16,
+	"2022-blake3-aes-256-gcm":       32,
+	"2022-blake3-chacha20-poly1305": 32,
+}
+
+// LANSharingOptions secures the inbounds other devices use to share the
+// connection when AllowConnectionFromLAN is set. The extra inbounds are only
+// added then, listening on all addresses.
+type LANSharingOptions struct {
+	// Users authenticate the SOCKS and HTTP inbounds, and the mixed inbound
+	// when it is reachable from the LAN. Anyone may connect when empty.
+	// System proxies cannot authenticate, so with SetSystemProxy the mixed
+	// inbound stays local and unauthenticated instead, leaving the LAN to
+	// the extra inbounds.
+	Users               []LANSharingUser `json:"users"`
+	SocksPort           uint16           `json:"socks-port"`       // 0 disables
+	HTTPPort            uint16           `json:"http-port"`        // 0 disables
+	ShadowsocksPort     uint16           `json:"shadowsocks-port"` // 0 disables
+	ShadowsocksMethod   string           `json:"shadowsocks-method"`
+	ShadowsocksPassword string           `json:"shadowsocks-password"` // base64 key
+}
+
+type LANSharingUser struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (l LANSharingOptions) validate(opt *InboundOptions) error {
+	usernames := make(map[string]bool)
+	for _, user := range l.Users {
+		if user.Username == "" || user.Password == "" {
+			return fmt.Errorf("lan sharing: users need a username and a password")
+		}
+		if usernames[user.Username] {
+			return fmt.Errorf("lan sharing: duplicate user %q", user.Username)
+		}
+		usernames[user.Username] = true
+	}
+
+	ports := map[uint16]string{}
+	for _, inbound := range []struct {
+		name    string
+		port    uint16
+		sharing bool
+	}{
+		{"mixed", opt.MixedPort, false},
+		{"tproxy", opt.TProxyPort, false},
+		{"redirect", opt.RedirectPort, false},
+		{"local dns", opt.LocalDnsPort, false},
+		{"socks", l.SocksPort, true},
+		{"http", l.HTTPPort, true},
+		{"shadowsocks", l.ShadowsocksPort, true},
+	} {
+		if inbound.port == 0 {
+			continue
+		}
+		if other, ok := ports[inbound.port]; ok && inbound.sharing {
+			return fmt.Errorf("lan sharing: %s and %s inbounds share port %d", other, inbound.name, inbound.port)
+		}
+		ports[inbound.port] = inbound.name
+	}
+
+	if l.ShadowsocksPort == 0 {
+		return nil
+	}
+	keyLength, ok := shadowsocks2022KeyLengths[l.shadowsocksMethod()]
+	if !ok {
+		return fmt.Errorf("lan sharing: unsupported shadowsocks method %q", l.ShadowsocksMethod)
+	}
+	key, err := base64.StdEncoding.DecodeString(l.ShadowsocksPassword)
+	if err != nil || len(key) != keyLength {
+		return fmt.Errorf("lan sharing: shadowsocks password must be a base64 key of %d bytes", keyLength)
+	}
+	return nil
+}
+
+func (l LANSharingOptions) shadowsocksMethod() string {
+	if l.ShadowsocksMethod == "" {
+		return defaultLANSharingShadowsocksMethod
+	}
+	return l.ShadowsocksMethod
+}
+
+func (l LANSharingOptions) authUsers() []auth.User {
+	if len(l.Users) == 0 {
+		return nil
+	}
+	users := make([]auth.User, 0, len(l.Users))
+	for _, user := range l.Users {
+		users = append(users, auth.User{Username: user.Username, Password: user.Password})
+	}
+	return users
+}
+
+// mixedInboundAccess returns the listen address and the users of the mixed
+// inbound.
+func mixedInboundAccess(opt *HiddifyOptions) (string, []auth.User) {
+	if !opt.AllowConnectionFromLAN {
+		return "127.0.0.1", nil
+	}
+	if len(opt.LANSharing.Users) == 0 {
+		return "0.0.0.0", nil
+	}
+	if opt.SetSystemProxy {
+		return "127.0.0.1", nil
+	}
+	return "0.0.0.0", opt.LANSharing.authUsers()
+}
+
+// inbounds returns the extra inbounds of the enabled ports.
+func (l LANSharingOptions) inbounds(bind string, domainStrategy option.DomainStrategy) []option.Inbound {
+	listen := func(port uint16) option.ListenOptions {
+		addr := badoption.Addr(netip.MustParseAddr(bind))
+		return option.ListenOptions{
+			Listen:     &addr,
+			ListenPort: port,
+			InboundOptions: option.InboundOptions{
+				SniffEnabled:             true,
+				SniffOverrideDestination: true,
+				DomainStrategy:           domainStrategy,
+			},
+		}
+	}
+	var inbounds []option.Inbound
+	if l.SocksPort != 0 {
+		inbounds = append(inbounds, option.Inbound{
+			Type: C.TypeSOCKS,
+			Tag:  InboundSocksTag,
+			Options: option.SocksInboundOptions{
+				ListenOptions: listen(l.SocksPort),
+				Users:         l.authUsers(),
+			},
+		})
+	}
+	if l.HTTPPort != 0 {
+		inbounds = append(inbounds, option.Inbound{
+			Type: C.TypeHTTP,
+			Tag:  InboundHTTPTag,
+			Options: option.HTTPMixedInboundOptions{
+				ListenOptions: listen(l.HTTPPort),
+				Users:         l.authUsers(),
+			},
+		})
+	}
+	if l.ShadowsocksPort != 0 {
+		inbounds = append(inbounds, option.Inbound{
+			Type: C.TypeShadowsocks,
+			Tag:  InboundShadowsocksTag,
+			Options: option.ShadowsocksInboundOptions{
+				ListenOptions: listen(l.ShadowsocksPort),
+				Method:        l.shadowsocksMethod(),
+				Password:      l.ShadowsocksPassword,
+			},
+		})
+	}
+	return inbounds
+}
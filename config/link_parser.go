@@ -0,0 +1,589 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	json "github.com/sagernet/sing/common/json"
+	badoption "github.com/sagernet/sing/common/json/badoption"
+)
+
+// supportedLinkSchemes maps share link schemes to their outbound type.
+var supportedLinkSchemes = map[string]string{
+	"vless":     C.TypeVLESS,
+	"vmess":     C.TypeVMess,
+	"trojan":    C.TypeTrojan,
+	"ss":        C.TypeShadowsocks,
+	"hysteria2": C.TypeHysteria2,
+	"hy2":       C.TypeHysteria2,
+	"tuic":      C.TypeTUIC,
+}
+
+// isShareLink reports whether line starts with one of the supported share link schemes.
+func isShareLink(line string) bool {
+	scheme, _, found := strings.Cut(line, "://")
+	if !found {
+		return false
+	}
+	_, supported := supportedLinkSchemes[strings.ToLower(scheme)]
+	return supported
+}
+
+// linkOutboundType returns the outbound type of a share link without parsing it.
+func linkOutboundType(line string) string {
+	scheme, _, _ := strings.Cut(line, "://")
+	return supportedLinkSchemes[strings.ToLower(scheme)]
+}
+
+// linkFragment returns the decoded name of a share link, if any.
+func linkFragment(line string) string {
+	_, fragment, found := strings.Cut(line, "#")
+	if !found {
+		return ""
+	}
+	if name, err := url.PathUnescape(fragment); err == nil {
+		fragment = name
+	}
+	return strings.TrimSpace(fragment)
+}
+
+// parseShareLinks converts a newline separated list of share links into
+// outbounds. Lines that are not share links (comments, headers, blank lines)
+// are ignored. Tags are taken from the link fragment and made unique.
+func parseShareLinks(content string) ([]option.Outbound, error) {
+	outbounds, _, err := collectShareLinks(content, nil)
+	return outbounds, err
+}
+
+// collectShareLinks parses share links and returns the line number of each
+// outbound. With a report, malformed links are recorded there and skipped
+// instead of failing the whole content.
+func collectShareLinks(content string, report *ParseReport) ([]option.Outbound, []int, error) {
+	var outbounds []option.Outbound
+	var lines []int
+	usedTags := make(map[string]int)
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !isShareLink(line) {
+			continue
+		}
+		outbound, err := parseShareLink(line)
+		if err != nil {
+			if report == nil {
+				return nil, nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			report.Total++
+			report.add(linkFragment(line), linkOutboundType(line), ParseSourceLine, i+1, err)
+			continue
+		}
+		outbound.Tag = uniqueTag(usedTags, outbound.Tag)
+		outbounds = append(outbounds, outbound)
+		lines = append(lines, i+1)
+	}
+	return outbounds, lines, nil
+}
+
+// decodeSubscription expands base64 encoded subscription bodies into plain
+// share links. The whole body is decoded first; if that does not yield any
+// links, every line that is not already a share link is decoded on its own.
+func decodeSubscription(content string) string {
+	content = strings.TrimPrefix(strings.TrimSpace(content), "\ufeff")
+	if decoded, ok := decodeSubscriptionBlock(strings.Join(strings.Fields(content), "")); ok {
+		return decoded
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || isShareLink(line) {
+			continue
+		}
+		if decoded, ok := decodeSubscriptionBlock(line); ok {
+			lines[i] = decoded
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func decodeSubscriptionBlock(block string) (string, bool) {
+	if block == "" || strings.Contains(block, "://") {
+		return "", false
+	}
+	decoded, err := decodeBase64String(block)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(decoded), "\n") {
+		if isShareLink(strings.TrimSpace(line)) {
+			return strings.ReplaceAll(string(decoded), "\r\n", "\n"), true
+		}
+	}
+	return "", false
+}
+
+func uniqueTag(usedTags map[string]int, tag string) string {
+	usedTags[tag]++
+	if count := usedTags[tag]; count > 1 {
+		return fmt.Sprintf("%s %d", tag, count)
+	}
+	return tag
+}
+
+func parseShareLink(link string) (option.Outbound, error) {
+	scheme, _, _ := strings.Cut(link, "://")
+	switch strings.ToLower(scheme) {
+	case "vless":
+		return parseVLESSLink(link)
+	case "vmess":
+		return parseVMessLink(link)
+	case "trojan":
+		return parseTrojanLink(link)
+	case "ss":
+		return parseShadowsocksLink(link)
+	case "hysteria2", "hy2":
+		return parseHysteria2Link(link)
+	case "tuic":
+		return parseTUICLink(link)
+	default:
+		return option.Outbound{}, fmt.Errorf("unsupported link scheme %q", scheme)
+	}
+}
+
+func parseVLESSLink(link string) (option.Outbound, error) {
+	u, serverOptions, err := parseLinkURL(link)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	query := u.Query()
+	uuid := u.User.Username()
+	if uuid == "" {
+		return option.Outbound{}, fmt.Errorf("vless link without uuid")
+	}
+	transport, err := linkTransport(query)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	outboundOptions := &option.VLESSOutboundOptions{
+		ServerOptions: serverOptions,
+		UUID:          uuid,
+		Flow:          query.Get("flow"),
+		OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+			TLS: linkTLSOptions(query, serverOptions.Server, false),
+		},
+		Transport: transport,
+	}
+	if packetEncoding := query.Get("packetEncoding"); packetEncoding != "" {
+		outboundOptions.PacketEncoding = &packetEncoding
+	}
+	return option.Outbound{
+		Type:    C.TypeVLESS,
+		Tag:     linkTag(u, C.TypeVLESS, serverOptions),
+		Options: outboundOptions,
+	}, nil
+}
+
+func parseTrojanLink(link string) (option.Outbound, error) {
+	u, serverOptions, err := parseLinkURL(link)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	query := u.Query()
+	password := u.User.Username()
+	if password == "" {
+		return option.Outbound{}, fmt.Errorf("trojan link without password")
+	}
+	transport, err := linkTransport(query)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	return option.Outbound{
+		Type: C.TypeTrojan,
+		Tag:  linkTag(u, C.TypeTrojan, serverOptions),
+		Options: &option.TrojanOutboundOptions{
+			ServerOptions: serverOptions,
+			Password:      password,
+			OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+				TLS: linkTLSOptions(query, serverOptions.Server, true),
+			},
+			Transport: transport,
+		},
+	}, nil
+}
+
+type vmessLink struct {
+	Ps   string          `json:"ps"`
+	Add  string          `json:"add"`
+	Port json.RawMessage `json:"port"`
+	ID   string          `json:"id"`
+	Aid  json.RawMessage `json:"aid"`
+	Scy  string          `json:"scy"`
+	Net  string          `json:"net"`
+	Type string          `json:"type"`
+	Host string          `json:"host"`
+	Path string          `json:"path"`
+	TLS  string          `json:"tls"`
+	SNI  string          `json:"sni"`
+	ALPN string          `json:"alpn"`
+	FP   string          `json:"fp"`
+}
+
+func parseVMessLink(link string) (option.Outbound, error) {
+	payload := strings.TrimPrefix(link[len("vmess://"):], "//")
+	if fragment := strings.Index(payload, "#"); fragment >= 0 {
+		payload = payload[:fragment]
+	}
+	decoded, err := decodeBase64String(payload)
+	if err != nil {
+		return option.Outbound{}, fmt.Errorf("vmess link payload is not base64: %w", err)
+	}
+	var vmess vmessLink
+	if err := json.Unmarshal(decoded, &vmess); err != nil {
+		return option.Outbound{}, fmt.Errorf("vmess link payload is not json: %w", err)
+	}
+	port, err := parseLooseUint(vmess.Port)
+	if err != nil || port == 0 || port > 65535 {
+		return option.Outbound{}, fmt.Errorf("vmess link has invalid port %s", string(vmess.Port))
+	}
+	alterID, _ := parseLooseUint(vmess.Aid)
+	if vmess.Add == "" || vmess.ID == "" {
+		return option.Outbound{}, fmt.Errorf("vmess link without server or id")
+	}
+	serverOptions := option.ServerOptions{Server: vmess.Add, ServerPort: uint16(port)}
+
+	query := url.Values{}
+	query.Set("type", vmess.Net)
+	query.Set("headerType", vmess.Type)
+	query.Set("host", vmess.Host)
+	query.Set("path", vmess.Path)
+	query.Set("serviceName", vmess.Path)
+	query.Set("security", vmess.TLS)
+	query.Set("sni", vmess.SNI)
+	query.Set("alpn", vmess.ALPN)
+	query.Set("fp", vmess.FP)
+	transport, err := linkTransport(query)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	security := vmess.Scy
+	if security == "" {
+		security = "auto"
+	}
+	tag := vmess.Ps
+	if tag == "" {
+		tag = fmt.Sprintf("%s-%s", C.TypeVMess, serverOptions.Build().String())
+	}
+	return option.Outbound{
+		Type: C.TypeVMess,
+		Tag:  tag,
+		Options: &option.VMessOutboundOptions{
+			ServerOptions: serverOptions,
+			UUID:          vmess.ID,
+			Security:      security,
+			AlterId:       int(alterID),
+			OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+				TLS: linkTLSOptions(query, serverOptions.Server, false),
+			},
+			Transport: transport,
+		},
+	}, nil
+}
+
+func parseShadowsocksLink(link string) (option.Outbound, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	if u.User == nil {
+		// legacy form: ss://base64(method:password@host:port)#name
+		decoded, err := decodeBase64String(u.Host)
+		if err != nil {
+			return option.Outbound{}, fmt.Errorf("shadowsocks link payload is not base64: %w", err)
+		}
+		at := strings.LastIndex(string(decoded), "@")
+		method, password, found := strings.Cut(string(decoded[:max(at, 0)]), ":")
+		if at < 0 || !found {
+			return option.Outbound{}, fmt.Errorf("shadowsocks link has invalid payload")
+		}
+		u = &url.URL{
+			Scheme:   u.Scheme,
+			User:     url.UserPassword(method, password),
+			Host:     string(decoded[at+1:]),
+			RawQuery: u.RawQuery,
+			Fragment: u.Fragment,
+		}
+	}
+	serverOptions, err := linkServerOptions(u)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	method := u.User.Username()
+	password, hasPassword := u.User.Password()
+	if !hasPassword {
+		// SIP002 form: ss://base64(method:password)@host:port
+		decoded, err := decodeBase64String(method)
+		if err != nil {
+			return option.Outbound{}, fmt.Errorf("shadowsocks link user info is not base64: %w", err)
+		}
+		method, password, hasPassword = strings.Cut(string(decoded), ":")
+		if !hasPassword {
+			return option.Outbound{}, fmt.Errorf("shadowsocks link without password")
+		}
+	}
+	outboundOptions := &option.ShadowsocksOutboundOptions{
+		ServerOptions: serverOptions,
+		Method:        method,
+		Password:      password,
+	}
+	if plugin := u.Query().Get("plugin"); plugin != "" {
+		name, pluginOptions, _ := strings.Cut(plugin, ";")
+		outboundOptions.Plugin = name
+		outboundOptions.PluginOptions = pluginOptions
+	}
+	return option.Outbound{
+		Type:    C.TypeShadowsocks,
+		Tag:     linkTag(u, C.TypeShadowsocks, serverOptions),
+		Options: outboundOptions,
+	}, nil
+}
+
+func parseHysteria2Link(link string) (option.Outbound, error) {
+	u, serverOptions, err := parseLinkURL(link)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	query := u.Query()
+	password := u.User.Username()
+	if secret, ok := u.User.Password(); ok {
+		password += ":" + secret
+	}
+	outboundOptions := &option.Hysteria2OutboundOptions{
+		ServerOptions: serverOptions,
+		Password:      password,
+		OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+			TLS: linkTLSOptions(query, serverOptions.Server, true),
+		},
+	}
+	if obfs := query.Get("obfs"); obfs != "" && obfs != "none" {
+		outboundOptions.Obfs = &option.Hysteria2Obfs{
+			Type:     obfs,
+			Password: query.Get("obfs-password"),
+		}
+	}
+	if ports := query.Get("mport"); ports != "" {
+		for _, portRange := range strings.Split(ports, ",") {
+			outboundOptions.ServerPorts = append(outboundOptions.ServerPorts, strings.ReplaceAll(portRange, "-", ":"))
+		}
+	}
+	if up, err := strconv.Atoi(query.Get("upmbps")); err == nil {
+		outboundOptions.UpMbps = up
+	}
+	if down, err := strconv.Atoi(query.Get("downmbps")); err == nil {
+		outboundOptions.DownMbps = down
+	}
+	return option.Outbound{
+		Type:    C.TypeHysteria2,
+		Tag:     linkTag(u, C.TypeHysteria2, serverOptions),
+		Options: outboundOptions,
+	}, nil
+}
+
+func parseTUICLink(link string) (option.Outbound, error) {
+	u, serverOptions, err := parseLinkURL(link)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	query := u.Query()
+	uuid := u.User.Username()
+	password, _ := u.User.Password()
+	if uuid == "" {
+		return option.Outbound{}, fmt.Errorf("tuic link without uuid")
+	}
+	tls := linkTLSOptions(query, serverOptions.Server, true)
+	if len(tls.ALPN) == 0 {
+		tls.ALPN = badoption.Listable[string]{"h3"}
+	}
+	return option.Outbound{
+		Type: C.TypeTUIC,
+		Tag:  linkTag(u, C.TypeTUIC, serverOptions),
+		Options: &option.TUICOutboundOptions{
+			ServerOptions:     serverOptions,
+			UUID:              uuid,
+			Password:          password,
+			CongestionControl: firstNonEmpty(query.Get("congestion_control"), query.Get("congestion-control")),
+			UDPRelayMode:      firstNonEmpty(query.Get("udp_relay_mode"), query.Get("udp-relay-mode")),
+			OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+				TLS: tls,
+			},
+		},
+	}, nil
+}
+
+func parseLinkURL(link string) (*url.URL, option.ServerOptions, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, option.ServerOptions{}, err
+	}
+	if u.User == nil {
+		return nil, option.ServerOptions{}, fmt.Errorf("%s link without credentials", u.Scheme)
+	}
+	serverOptions, err := linkServerOptions(u)
+	if err != nil {
+		return nil, option.ServerOptions{}, err
+	}
+	return u, serverOptions, nil
+}
+
+func linkServerOptions(u *url.URL) (option.ServerOptions, error) {
+	host := u.Hostname()
+	if host == "" {
+		return option.ServerOptions{}, fmt.Errorf("%s link without server", u.Scheme)
+	}
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	if err != nil || port == 0 {
+		return option.ServerOptions{}, fmt.Errorf("%s link has invalid port %q", u.Scheme, u.Port())
+	}
+	return option.ServerOptions{Server: host, ServerPort: uint16(port)}, nil
+}
+
+func linkTag(u *url.URL, outboundType string, serverOptions option.ServerOptions) string {
+	if tag := strings.TrimSpace(u.Fragment); tag != "" {
+		return tag
+	}
+	return fmt.Sprintf("%s-%s", outboundType, net.JoinHostPort(serverOptions.Server, strconv.Itoa(int(serverOptions.ServerPort))))
+}
+
+// linkTLSOptions builds TLS options from the common share link query
+// parameters. defaultEnabled is used when the link has no security parameter.
+func linkTLSOptions(query url.Values, server string, defaultEnabled bool) *option.OutboundTLSOptions {
+	security := strings.ToLower(query.Get("security"))
+	switch security {
+	case "":
+		if !defaultEnabled {
+			return nil
+		}
+	case "none":
+		return nil
+	}
+	tls := &option.OutboundTLSOptions{
+		Enabled:    true,
+		ServerName: firstNonEmpty(query.Get("sni"), query.Get("peer"), query.Get("host"), server),
+		Insecure:   isTrueValue(query.Get("allowInsecure")) || isTrueValue(query.Get("insecure")) || isTrueValue(query.Get("allow_insecure")),
+	}
+	if net.ParseIP(tls.ServerName) != nil && query.Get("sni") == "" {
+		tls.ServerName = ""
+	}
+	if alpn := query.Get("alpn"); alpn != "" {
+		tls.ALPN = strings.Split(alpn, ",")
+	}
+	fingerprint := query.Get("fp")
+	if security == "reality" {
+		tls.Reality = &option.OutboundRealityOptions{
+			Enabled:   true,
+			PublicKey: query.Get("pbk"),
+			ShortID:   query.Get("sid"),
+		}
+		if fingerprint == "" {
+			fingerprint = "chrome"
+		}
+	}
+	if fingerprint != "" && fingerprint != "none" {
+		tls.UTLS = &option.OutboundUTLSOptions{
+			Enabled:     true,
+			Fingerprint: fingerprint,
+		}
+	}
+	return tls
+}
+
+func linkTransport(query url.Values) (*option.V2RayTransportOptions, error) {
+	host := query.Get("host")
+	path := query.Get("path")
+	switch strings.ToLower(query.Get("type")) {
+	case "", "tcp", "raw":
+		if query.Get("headerType") != "http" {
+			return nil, nil
+		}
+		transport := &option.V2RayTransportOptions{Type: C.V2RayTransportTypeHTTP}
+		if host != "" {
+			transport.HTTPOptions.Host = strings.Split(host, ",")
+		}
+		transport.HTTPOptions.Path = path
+		return transport, nil
+	case C.V2RayTransportTypeWebsocket:
+		transport := &option.V2RayTransportOptions{Type: C.V2RayTransportTypeWebsocket}
+		if host != "" {
+			transport.WebsocketOptions.Headers = badoption.HTTPHeader{"Host": {host}}
+		}
+		transport.WebsocketOptions.Path = path
+		if wsPath, rawQuery, found := strings.Cut(path, "?"); found {
+			earlyData, _ := url.ParseQuery(rawQuery)
+			if ed, err := strconv.ParseUint(earlyData.Get("ed"), 10, 32); err == nil {
+				transport.WebsocketOptions.Path = wsPath
+				transport.WebsocketOptions.MaxEarlyData = uint32(ed)
+				transport.WebsocketOptions.EarlyDataHeaderName = "Sec-WebSocket-Protocol"
+			}
+		}
+		return transport, nil
+	case C.V2RayTransportTypeGRPC:
+		transport := &option.V2RayTransportOptions{Type: C.V2RayTransportTypeGRPC}
+		transport.GRPCOptions.ServiceName = firstNonEmpty(query.Get("serviceName"), path)
+		return transport, nil
+	case C.V2RayTransportTypeHTTPUpgrade:
+		transport := &option.V2RayTransportOptions{Type: C.V2RayTransportTypeHTTPUpgrade}
+		transport.HTTPUpgradeOptions.Host = host
+		transport.HTTPUpgradeOptions.Path = path
+		return transport, nil
+	case C.V2RayTransportTypeHTTP, "h2":
+		transport := &option.V2RayTransportOptions{Type: C.V2RayTransportTypeHTTP}
+		if host != "" {
+			transport.HTTPOptions.Host = strings.Split(host, ",")
+		}
+		transport.HTTPOptions.Path = path
+		return transport, nil
+	case C.V2RayTransportTypeQUIC:
+		return &option.V2RayTransportOptions{Type: C.V2RayTransportTypeQUIC}, nil
+	default:
+		return nil, fmt.Errorf("unsupported transport type %q", query.Get("type"))
+	}
+}
+
+func decodeBase64String(content string) ([]byte, error) {
+	content = strings.TrimSpace(content)
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	} {
+		if decoded, err := encoding.DecodeString(content); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("illegal base64 data")
+}
+
+func parseLooseUint(raw json.RawMessage) (uint64, error) {
+	value := strings.Trim(strings.TrimSpace(string(raw)), `"`)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 32)
+}
+
+func isTrueValue(value string) bool {
+	return value == "1" || strings.EqualFold(value, "true")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
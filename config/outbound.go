@@ -2,8 +2,13 @@ package config
 
 import (
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"net"
+	"slices"
+	"strings"
 
+	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 )
 
@@ -13,6 +18,9 @@ func patchOutbound(base option.Outbound, configOpt HiddifyOptions) (*option.Outb
 		return nil, "", fmt.Errorf("error patching outbound[%s][%s]: %w", base.Tag, base.Type, err)
 	}
 
+	if err := patchOutboundDetour(&outbound, configOpt); err != nil {
+		return nil, "", fmt.Errorf("error patching outbound[%s][%s]: %w", base.Tag, base.Type, err)
+	}
 	serverDomain := outboundServerDomain(outbound)
 	patchOutboundTLSTricks(&outbound, configOpt)
 	patchOutboundMux(&outbound, configOpt)
@@ -20,78 +28,192 @@ func patchOutbound(base option.Outbound, configOpt HiddifyOptions) (*option.Outb
 	return &outbound, serverDomain, nil
 }
 
+// validate rejects the multiplex settings sing-box refuses when dialing, so
+// they fail when the config is built instead of on the first connection.
+func (m MuxOptions) validate() error {
+	if !m.Enable {
+		return nil
+	}
+	if m.MaxStreams > 0 && (m.MinStreams > 0 || m.MaxConnections > 0) {
+		return fmt.Errorf("mux: max-streams conflicts with min-streams and max-connections")
+	}
+	if m.Brutal.Enable && (m.Brutal.UpMbps <= 0 || m.Brutal.DownMbps <= 0) {
+		return fmt.Errorf("mux: brutal needs up-mbps and down-mbps")
+	}
+	return nil
+}
+
 func patchOutboundMux(outbound *option.Outbound, configOpt HiddifyOptions) {
 	if !configOpt.Mux.Enable {
 		return
 	}
 	muxOptions := option.OutboundMultiplexOptions{
-		Enabled:    true,
-		Padding:    configOpt.Mux.Padding,
-		Protocol:   configOpt.Mux.Protocol,
-		MaxStreams: configOpt.Mux.MaxStreams,
+		Enabled:        true,
+		Padding:        configOpt.Mux.Padding,
+		Protocol:       configOpt.Mux.Protocol,
+		MaxStreams:     configOpt.Mux.MaxStreams,
+		MinStreams:     configOpt.Mux.MinStreams,
+		MaxConnections: configOpt.Mux.MaxConnections,
+	}
+	if configOpt.Mux.Brutal.Enable {
+		muxOptions.Brutal = &option.BrutalOptions{
+			Enabled:  true,
+			UpMbps:   configOpt.Mux.Brutal.UpMbps,
+			DownMbps: configOpt.Mux.Brutal.DownMbps,
+		}
 	}
 
 	switch opts := outbound.Options.(type) {
 	case *option.VLESSOutboundOptions:
-		opts.Multiplex = cloneMuxOptions(muxOptions)
+		// XTLS flows splice the inner TLS stream and cannot be multiplexed.
+		if opts.Flow == "" && muxCompatibleTransport(opts.Transport) {
+			opts.Multiplex = cloneMuxOptions(muxOptions)
+		}
 	case *option.VMessOutboundOptions:
-		opts.Multiplex = cloneMuxOptions(muxOptions)
+		if muxCompatibleTransport(opts.Transport) {
+			opts.Multiplex = cloneMuxOptions(muxOptions)
+		}
 	case *option.TrojanOutboundOptions:
-		opts.Multiplex = cloneMuxOptions(muxOptions)
+		if muxCompatibleTransport(opts.Transport) {
+			opts.Multiplex = cloneMuxOptions(muxOptions)
+		}
+	case *option.ShadowsocksOutboundOptions:
+		// sing-box ignores multiplex when UDP over TCP is enabled.
+		if opts.UDPOverTCP == nil || !opts.UDPOverTCP.Enabled {
+			opts.Multiplex = cloneMuxOptions(muxOptions)
+		}
 	}
 }
 
+// muxCompatibleTransport reports whether sing-mux can run over the transport.
+// gRPC and QUIC already multiplex streams on their own.
+func muxCompatibleTransport(transport *option.V2RayTransportOptions) bool {
+	if transport == nil {
+		return true
+	}
+	switch transport.Type {
+	case C.V2RayTransportTypeGRPC, C.V2RayTransportTypeQUIC:
+		return false
+	}
+	return true
+}
+
 func cloneMuxOptions(opts option.OutboundMultiplexOptions) *option.OutboundMultiplexOptions {
 	clone := opts
 	return &clone
 }
 
+// validate rejects the TLS tricks sing-box has no equivalent for. Its
+// ClientHello fragmentation splits at the SNI, with neither a fragment size
+// nor a delay between fragments, and it cannot pad the ClientHello.
+func (t TLSTricks) validate() error {
+	if t.EnableFragment && t.FragmentSize != "" {
+		return fmt.Errorf("tls tricks: fragment-size is not supported, sing-box splits the ClientHello at the SNI")
+	}
+	if t.EnableFragment && t.FragmentSleep != "" {
+		return fmt.Errorf("tls tricks: fragment-sleep is not supported, sing-box sends the fragments without delay")
+	}
+	if t.EnablePadding {
+		return fmt.Errorf("tls tricks: padding is not supported by sing-box")
+	}
+	return nil
+}
+
 func patchOutboundTLSTricks(outbound *option.Outbound, configOpt HiddifyOptions) {
+	tricks := configOpt.TLSTricks
 	switch opts := outbound.Options.(type) {
 	case *option.VLESSOutboundOptions:
-		patchTLSOptions(opts.OutboundTLSOptionsContainer.TLS, configOpt)
+		patchTLSOptions(opts.OutboundTLSOptionsContainer.TLS, opts.Server, tricks, true)
 	case *option.VMessOutboundOptions:
-		patchTLSOptions(opts.OutboundTLSOptionsContainer.TLS, configOpt)
+		patchTLSOptions(opts.OutboundTLSOptionsContainer.TLS, opts.Server, tricks, true)
 	case *option.TrojanOutboundOptions:
-		patchTLSOptions(opts.OutboundTLSOptionsContainer.TLS, configOpt)
+		patchTLSOptions(opts.OutboundTLSOptionsContainer.TLS, opts.Server, tricks, true)
+	case *option.HTTPOutboundOptions:
+		patchTLSOptions(opts.OutboundTLSOptionsContainer.TLS, opts.Server, tricks, true)
+	case *option.Hysteria2OutboundOptions:
+		// QUIC handshakes are not sent over a stream, so fragmentation does not apply.
+		patchTLSOptions(opts.OutboundTLSOptionsContainer.TLS, opts.Server, tricks, false)
+	case *option.TUICOutboundOptions:
+		patchTLSOptions(opts.OutboundTLSOptionsContainer.TLS, opts.Server, tricks, false)
+	case *option.ShadowsocksOutboundOptions:
+		patchShadowsocksPluginTricks(opts, tricks)
 	}
 }
 
-func patchTLSOptions(tls *option.OutboundTLSOptions, configOpt HiddifyOptions) {
+// patchTLSOptions applies the TLS tricks to a client TLS config. Reality
+// configs are left alone since their ClientHello must match the fingerprint.
+func patchTLSOptions(tls *option.OutboundTLSOptions, server string, tricks TLSTricks, stream bool) {
 	if tls == nil || !tls.Enabled {
 		return
 	}
 	if tls.Reality != nil && tls.Reality.Enabled {
 		return
 	}
-	if configOpt.TLSTricks.EnableFragment {
+	if tricks.EnableFragment && stream {
 		tls.Fragment = true
+		tls.RecordFragment = tricks.RecordFragment
+	}
+	if tricks.MixedSNICase && !tls.DisableSNI {
+		serverName := tls.ServerName
+		if serverName == "" && net.ParseIP(server) == nil {
+			serverName = server
+		}
+		tls.ServerName = mixedCase(serverName)
 	}
 }
 
-func outboundServerDomain(outbound option.Outbound) string {
-	var server string
-	var detour string
+// patchShadowsocksPluginTricks randomizes the SNI of v2ray-plugin over TLS,
+// the only shadowsocks plugin that sends a ClientHello.
+func patchShadowsocksPluginTricks(opts *option.ShadowsocksOutboundOptions, tricks TLSTricks) {
+	if !tricks.MixedSNICase || opts.Plugin != "v2ray-plugin" {
+		return
+	}
+	pluginOpts := strings.Split(opts.PluginOptions, ";")
+	if !slices.Contains(pluginOpts, "tls") {
+		return
+	}
+	for i, pluginOpt := range pluginOpts {
+		if host, found := strings.CutPrefix(pluginOpt, "host="); found {
+			pluginOpts[i] = "host=" + mixedCase(host)
+		}
+	}
+	opts.PluginOptions = strings.Join(pluginOpts, ";")
+}
 
-	switch opts := outbound.Options.(type) {
-	case *option.VLESSOutboundOptions:
-		server = opts.Server
-		detour = opts.DialerOptions.Detour
-	case *option.VMessOutboundOptions:
-		server = opts.Server
-		detour = opts.DialerOptions.Detour
-	case *option.TrojanOutboundOptions:
-		server = opts.Server
-		detour = opts.DialerOptions.Detour
-	case *option.ShadowsocksOutboundOptions:
-		server = opts.Server
-		detour = opts.DialerOptions.Detour
+// mixedCase randomizes the letter case of a domain. The result is derived
+// from the domain itself so rebuilding the config yields the same outbound.
+func mixedCase(domain string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(domain))
+	random := rand.New(rand.NewSource(int64(hash.Sum64())))
+	mixed := []byte(domain)
+	for i, c := range mixed {
+		if random.Intn(2) == 0 {
+			continue
+		}
+		switch {
+		case 'a' <= c && c <= 'z':
+			mixed[i] = c - 'a' + 'A'
+		case 'A' <= c && c <= 'Z':
+			mixed[i] = c - 'A' + 'a'
+		}
 	}
+	return string(mixed)
+}
 
-	if detour != "" || server == "" {
+// outboundServerDomain returns the server domain of an outbound that dials
+// it directly. Chained outbounds reach their server through the detour, so
+// the domain must not be resolved by the direct DNS.
+func outboundServerDomain(outbound option.Outbound) string {
+	if outboundDetour(outbound) != "" {
+		return ""
+	}
+	wrapper, ok := outbound.Options.(option.ServerOptionsWrapper)
+	if !ok {
 		return ""
 	}
-	if net.ParseIP(server) != nil {
+	server := wrapper.TakeServerOptions().Server
+	if server == "" || net.ParseIP(server) != nil {
 		return ""
 	}
 	return fmt.Sprintf("full:%s", server)
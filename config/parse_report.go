@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/experimental/libbox"
+	"github.com/sagernet/sing-box/option"
+	json "github.com/sagernet/sing/common/json"
+	"github.com/xmdhs/clash2singbox/convert"
+	"github.com/xmdhs/clash2singbox/model/clash"
+)
+
+const (
+	ParseSourceLine      = "line"
+	ParseSourceOutbounds = "outbounds"
+	ParseSourceProxies   = "proxies"
+)
+
+// ParseDiagnostic describes a single proxy that was dropped during a partial
+// parse. Index is the 1-based line number for share links and the 0-based
+// position in the source list otherwise.
+type ParseDiagnostic struct {
+	Tag    string `json:"tag"`
+	Type   string `json:"type"`
+	Source string `json:"source"`
+	Index  int    `json:"index"`
+	Error  string `json:"error"`
+}
+
+// ParseReport summarizes a partial parse.
+type ParseReport struct {
+	Parser      string            `json:"parser"`
+	Total       int               `json:"total"`
+	Accepted    int               `json:"accepted"`
+	Diagnostics []ParseDiagnostic `json:"diagnostics"`
+}
+
+func (r *ParseReport) add(tag, outboundType, source string, index int, err error) {
+	r.Diagnostics = append(r.Diagnostics, ParseDiagnostic{
+		Tag:    tag,
+		Type:   outboundType,
+		Source: source,
+		Index:  index,
+		Error:  err.Error(),
+	})
+}
+
+// outboundLocator maps an outbound position and tag in the generated config
+// back to its place in the original input. position is -1 when only the tag
+// is known.
+type outboundLocator func(position int, tag string) (source string, index int)
+
+func locateByPosition(source string) outboundLocator {
+	return func(position int, _ string) (string, int) {
+		return source, position
+	}
+}
+
+// locateByLine maps outbounds to the share link line they were parsed from.
+func locateByLine(lines []int) outboundLocator {
+	return func(position int, tag string) (string, int) {
+		if position >= 0 && position < len(lines) {
+			return ParseSourceLine, lines[position]
+		}
+		return ParseSourceOutbounds, position
+	}
+}
+
+// locateClashProxy maps outbounds back to the clash proxy with the same name.
+// Outbounds added by the template are located by position.
+func locateClashProxy(clashObj clash.Clash) outboundLocator {
+	indexes := make(map[string]int, len(clashObj.Proxies))
+	for i, proxy := range clashObj.Proxies {
+		indexes[proxy.Name] = i
+	}
+	return func(position int, tag string) (string, int) {
+		if index, ok := indexes[tag]; ok {
+			return ParseSourceProxies, index
+		}
+		return ParseSourceOutbounds, position
+	}
+}
+
+// reportClashProxies converts every clash proxy on its own to find the ones
+// the converter rejects.
+func reportClashProxies(clashObj clash.Clash, report *ParseReport) {
+	for i, proxy := range clashObj.Proxies {
+		if _, err := convert.Clash2sing(clash.Clash{Proxies: []clash.Proxies{proxy}}); err != nil {
+			report.Total++
+			report.add(proxy.Name, proxy.Type, ParseSourceProxies, i, err)
+		}
+	}
+}
+
+// dropInvalidOutbounds validates every outbound of content on its own and
+// removes the ones sing-box rejects, recording them in report. Group members
+// pointing at dropped outbounds are pruned as well.
+func dropInvalidOutbounds(content []byte, report *ParseReport, locate outboundLocator) ([]byte, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+	var rawOutbounds []json.RawMessage
+	if err := json.Unmarshal(raw["outbounds"], &rawOutbounds); err != nil {
+		return nil, err
+	}
+	report.Total += len(rawOutbounds)
+
+	if err := libbox.CheckConfig(string(content)); err == nil {
+		report.Accepted += len(rawOutbounds)
+		return content, nil
+	}
+
+	dropped := make(map[string]bool)
+	outbounds := make([]option.Outbound, 0, len(rawOutbounds))
+	for i, rawOutbound := range rawOutbounds {
+		var outbound option.Outbound
+		err := outbound.UnmarshalJSONContext(OptionsContext(), rawOutbound)
+		if err == nil {
+			err = checkOutbound(outbound)
+		}
+		if err != nil {
+			var header struct {
+				Tag  string `json:"tag"`
+				Type string `json:"type"`
+			}
+			json.Unmarshal(rawOutbound, &header)
+			source, index := locate(i, header.Tag)
+			report.add(header.Tag, header.Type, source, index, err)
+			dropped[header.Tag] = true
+			continue
+		}
+		outbounds = append(outbounds, outbound)
+	}
+	outbounds = dropDependents(outbounds, dropped, report, locate)
+	if len(outbounds) == 0 {
+		return nil, fmt.Errorf("no valid outbounds found")
+	}
+	report.Accepted += len(outbounds)
+
+	rawOutboundsContent, err := json.MarshalContext(OptionsContext(), outbounds)
+	if err != nil {
+		return nil, err
+	}
+	raw["outbounds"] = rawOutboundsContent
+	return json.Marshal(raw)
+}
+
+// checkOutbound runs the sing-box validation on a single outbound. Group
+// outbounds are skipped since their members are validated separately, and a
+// placeholder is provided for the detour so chained outbounds can be checked
+// in isolation.
+func checkOutbound(outbound option.Outbound) error {
+	switch outbound.Type {
+	case C.TypeSelector, C.TypeURLTest:
+		return nil
+	}
+	options := option.Options{Outbounds: []option.Outbound{outbound}}
+	if detour := outboundDetour(outbound); detour != "" && detour != outbound.Tag {
+		options.Outbounds = append(options.Outbounds, option.Outbound{
+			Type:    C.TypeDirect,
+			Tag:     detour,
+			Options: &option.DirectOutboundOptions{},
+		})
+	}
+	content, err := MarshalOptions(&options)
+	if err != nil {
+		return err
+	}
+	return libbox.CheckConfig(string(content))
+}
+
+func outboundDetour(outbound option.Outbound) string {
+	if wrapper, ok := outbound.Options.(option.DialerOptionsWrapper); ok {
+		return wrapper.TakeDialerOptions().Detour
+	}
+	return ""
+}
+
+// dropDependents removes outbounds whose detour was dropped and groups left
+// without members, repeating until nothing else changes.
+func dropDependents(outbounds []option.Outbound, dropped map[string]bool, report *ParseReport, locate outboundLocator) []option.Outbound {
+	for changed := true; changed; {
+		changed = false
+		kept := outbounds[:0]
+		for _, outbound := range outbounds {
+			var err error
+			if detour := outboundDetour(outbound); dropped[detour] {
+				err = fmt.Errorf("detour %s was dropped", detour)
+			} else if !pruneGroupMembers(&outbound, dropped) {
+				err = fmt.Errorf("no valid members left")
+			}
+			if err != nil {
+				source, index := locate(-1, outbound.Tag)
+				report.add(outbound.Tag, outbound.Type, source, index, err)
+				dropped[outbound.Tag] = true
+				changed = true
+				continue
+			}
+			kept = append(kept, outbound)
+		}
+		outbounds = kept
+	}
+	return outbounds
+}
+
+// pruneGroupMembers removes dropped members from group outbounds and reports
+// whether the outbound is still usable.
+func pruneGroupMembers(outbound *option.Outbound, dropped map[string]bool) bool {
+	keep := func(tags []string) []string {
+		kept := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			if !dropped[tag] {
+				kept = append(kept, tag)
+			}
+		}
+		return kept
+	}
+	switch opts := outbound.Options.(type) {
+	case *option.SelectorOutboundOptions:
+		opts.Outbounds = keep(opts.Outbounds)
+		if dropped[opts.Default] {
+			opts.Default = ""
+		}
+		return len(opts.Outbounds) > 0
+	case *option.URLTestOutboundOptions:
+		opts.Outbounds = keep(opts.Outbounds)
+		return len(opts.Outbounds) > 0
+	}
+	return true
+}
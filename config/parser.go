@@ -20,14 +20,31 @@ import (
 var configByte []byte
 
 func ParseConfig(path string, debug bool) ([]byte, error) {
-	content, err := os.ReadFile(path)
-	os.Chdir(filepath.Dir(path))
+	content, err := readConfigFile(path)
 	if err != nil {
 		return nil, err
 	}
 	return ParseConfigContent(string(content), debug, nil, false)
 }
 
+// ParseConfigWithReport parses the file at path like ParseConfig, dropping
+// and reporting invalid outbounds like ParseConfigContentWithReport.
+func ParseConfigWithReport(path string, debug bool) ([]byte, *ParseReport, error) {
+	content, err := readConfigFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ParseConfigContentWithReport(string(content), debug, nil, false)
+}
+
+// readConfigFile reads the config at path and moves to its directory, which
+// relative paths in the config are resolved against.
+func readConfigFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	os.Chdir(filepath.Dir(path))
+	return content, err
+}
+
 func ParseConfigContentToOptions(contentstr string, debug bool, configOpt *HiddifyOptions, fullConfig bool) (*option.Options, error) {
 	content, err := ParseConfigContent(contentstr, debug, configOpt, fullConfig)
 	if err != nil {
@@ -37,6 +54,18 @@ func ParseConfigContentToOptions(contentstr string, debug bool, configOpt *Hiddi
 }
 
 func ParseConfigContent(contentstr string, debug bool, configOpt *HiddifyOptions, fullConfig bool) ([]byte, error) {
+	return parseConfigContent(contentstr, debug, configOpt, fullConfig, nil)
+}
+
+// ParseConfigContentWithReport parses like ParseConfigContent but drops the
+// outbounds sing-box rejects instead of failing, and reports what was dropped.
+func ParseConfigContentWithReport(contentstr string, debug bool, configOpt *HiddifyOptions, fullConfig bool) ([]byte, *ParseReport, error) {
+	report := &ParseReport{}
+	content, err := parseConfigContent(contentstr, debug, configOpt, fullConfig, report)
+	return content, report, err
+}
+
+func parseConfigContent(contentstr string, debug bool, configOpt *HiddifyOptions, fullConfig bool, report *ParseReport) ([]byte, error) {
 	if configOpt == nil {
 		configOpt = DefaultHiddifyOptions()
 	}
@@ -79,7 +108,7 @@ func ParseConfigContent(contentstr string, debug bool, configOpt *HiddifyOptions
 
 		newContent, _ := json.Marshal(jsonObj)
 
-		return patchConfig(newContent, "SingboxParser")
+		return patchConfig(newContent, "SingboxParser", report, locateByPosition(ParseSourceOutbounds))
 	}
 
 	fmt.Printf("Convert using clash\n")
@@ -90,20 +119,36 @@ func ParseConfigContent(contentstr string, debug bool, configOpt *HiddifyOptions
 		}
 		converted, err := convert.Clash2sing(clashObj)
 		if err != nil {
-			return nil, fmt.Errorf("[ClashParser] converting clash to sing-box error: %w", err)
+			if report == nil {
+				return nil, fmt.Errorf("[ClashParser] converting clash to sing-box error: %w", err)
+			}
+			reportClashProxies(clashObj, report)
 		}
 		output := configByte
 		output, err = convert.Patch(output, converted, "", "", nil)
 		if err != nil {
 			return nil, fmt.Errorf("[ClashParser] patching clash config error: %w", err)
 		}
-		return patchConfig(output, "ClashParser")
+		return patchConfig(output, "ClashParser", report, locateClashProxy(clashObj))
+	}
+
+	fmt.Printf("Convert using links\n")
+	outbounds, lines, err := collectShareLinks(decodeSubscription(contentstr), report)
+	if err != nil {
+		return nil, fmt.Errorf("[LinkParser] %w", err)
+	}
+	if len(outbounds) > 0 {
+		output, err := MarshalOptions(&option.Options{Outbounds: outbounds})
+		if err != nil {
+			return nil, fmt.Errorf("[LinkParser] marshal error: %w", err)
+		}
+		return patchConfig(output, "LinkParser", report, locateByLine(lines))
 	}
 
 	return nil, fmt.Errorf("unable to determine config format")
 }
 
-func patchConfig(content []byte, name string) ([]byte, error) {
+func patchConfig(content []byte, name string, report *ParseReport, locate outboundLocator) ([]byte, error) {
 	options, err := UnmarshalOptions(content)
 	if err != nil {
 		return nil, fmt.Errorf("[SingboxParser] unmarshal error: %w", err)
@@ -118,6 +163,14 @@ func patchConfig(content []byte, name string) ([]byte, error) {
 	}
 	content = buffer.Bytes()
 
+	if report != nil {
+		report.Parser = name
+		content, err = dropInvalidOutbounds(content, report, locate)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] %w", name, err)
+		}
+	}
+
 	fmt.Printf("Config: %s\n", content)
 	return validateResult(content, name)
 }
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+const (
+	PerAppProxyModeInclude = "include"
+	PerAppProxyModeExclude = "exclude"
+)
+
+// PerAppProxyOptions splits the TUN traffic by application. In include mode
+// only the listed applications are proxied, in exclude mode the listed
+// applications bypass the proxy. An empty mode proxies every application.
+//
+// Package names are applied by the TUN on Android. Process names and paths
+// are matched by route rules on the other platforms.
+type PerAppProxyOptions struct {
+	Mode         string   `json:"mode"`
+	PackageNames []string `json:"package-names"`
+	ProcessNames []string `json:"process-names"`
+	ProcessPaths []string `json:"process-paths"`
+}
+
+func (p PerAppProxyOptions) validate() error {
+	switch p.Mode {
+	case "", PerAppProxyModeInclude, PerAppProxyModeExclude:
+		return nil
+	default:
+		return fmt.Errorf("per-app proxy: unknown mode %q", p.Mode)
+	}
+}
+
+// setTunPerAppProxy limits the packages routed into the TUN.
+func setTunPerAppProxy(tunOptions *option.TunInboundOptions, perApp PerAppProxyOptions) {
+	if len(perApp.PackageNames) == 0 {
+		return
+	}
+	switch perApp.Mode {
+	case PerAppProxyModeInclude:
+		tunOptions.IncludePackage = append(tunOptions.IncludePackage, perApp.PackageNames...)
+	case PerAppProxyModeExclude:
+		tunOptions.ExcludePackage = append(tunOptions.ExcludePackage, perApp.PackageNames...)
+	}
+}
+
+// PerAppProxyRules returns the route rules sending the connections of the
+// unproxied processes on inbound to bypass. sing-box requires every item of
+// a rule to match, so names and paths get a rule each.
+func PerAppProxyRules(perApp PerAppProxyOptions, inbound string, bypass string) []option.Rule {
+	var processRules []option.Rule
+	if len(perApp.ProcessNames) > 0 {
+		processRules = append(processRules, option.Rule{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultRule{
+				RawDefaultRule: option.RawDefaultRule{ProcessName: perApp.ProcessNames},
+			},
+		})
+	}
+	if len(perApp.ProcessPaths) > 0 {
+		processRules = append(processRules, option.Rule{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultRule{
+				RawDefaultRule: option.RawDefaultRule{ProcessPath: perApp.ProcessPaths},
+			},
+		})
+	}
+	if len(processRules) == 0 {
+		return nil
+	}
+
+	switch perApp.Mode {
+	case PerAppProxyModeExclude:
+		rules := make([]option.Rule, 0, len(processRules))
+		for _, rule := range processRules {
+			rule.DefaultOptions.Inbound = []string{inbound}
+			rule.DefaultOptions.RuleAction = routeActionForOutbound(bypass)
+			rules = append(rules, rule)
+		}
+		return rules
+	case PerAppProxyModeInclude:
+		// Everything from the inbound that is none of the listed processes.
+		subRules := []option.Rule{{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultRule{
+				RawDefaultRule: option.RawDefaultRule{Inbound: []string{inbound}},
+			},
+		}}
+		for _, rule := range processRules {
+			rule.DefaultOptions.Invert = true
+			subRules = append(subRules, rule)
+		}
+		return []option.Rule{{
+			Type: C.RuleTypeLogical,
+			LogicalOptions: option.LogicalRule{
+				RawLogicalRule: option.RawLogicalRule{
+					Mode:  C.LogicalTypeAnd,
+					Rules: subRules,
+				},
+				RuleAction: routeActionForOutbound(bypass),
+			},
+		}}
+	}
+	return nil
+}
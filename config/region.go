@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	badoption "github.com/sagernet/sing/common/json/badoption"
+)
+
+const TUNExcludeRuleSetTagPrefix = "tun-exclude-rule-set"
+
+func normalizeRegion(region string) string {
+	region = strings.ToLower(strings.TrimSpace(region))
+	if region == "" {
+		return "other"
+	}
+	return region
+}
+
+func regionGeoIPTag(region string) string {
+	return "geoip-" + normalizeRegion(region)
+}
+
+func regionGeositeTag(region string) string {
+	return "geosite-" + normalizeRegion(region)
+}
+
+// regionRuleSets returns the rule-sets of the sites and addresses routed
+// directly in a region. There are none for "other".
+func regionRuleSets(region string) []option.RuleSet {
+	switch region = normalizeRegion(region); region {
+	case "other":
+		return nil
+	case "cn":
+		return []option.RuleSet{
+			{
+				Type:   C.RuleSetTypeRemote,
+				Tag:    "geosite-geolocation-!cn",
+				Format: C.RuleSetFormatBinary,
+				RemoteOptions: option.RemoteRuleSet{
+					URL:            "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-geolocation-!cn.srs",
+					DownloadDetour: OutboundMainProxyTag,
+				},
+			},
+			{
+				Type:   C.RuleSetTypeRemote,
+				Tag:    regionGeositeTag(region),
+				Format: C.RuleSetFormatBinary,
+				RemoteOptions: option.RemoteRuleSet{
+					URL:            "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-cn.srs",
+					DownloadDetour: OutboundMainProxyTag,
+				},
+			},
+			{
+				Type:   C.RuleSetTypeRemote,
+				Tag:    regionGeoIPTag(region),
+				Format: C.RuleSetFormatBinary,
+				RemoteOptions: option.RemoteRuleSet{
+					URL:            "https://raw.githubusercontent.com/Loyalsoldier/geoip/release/srs/cn.srs",
+					DownloadDetour: OutboundMainProxyTag,
+				},
+			},
+		}
+	default:
+		return []option.RuleSet{
+			{
+				Type:   C.RuleSetTypeRemote,
+				Tag:    regionGeoIPTag(region),
+				Format: C.RuleSetFormatBinary,
+				RemoteOptions: option.RemoteRuleSet{
+					URL:            "https://raw.githubusercontent.com/hiddify/hiddify-geo/rule-set/country/geoip-" + region + ".srs",
+					UpdateInterval: badoption.Duration(5 * time.Hour * 24),
+				},
+			},
+			{
+				Type:   C.RuleSetTypeRemote,
+				Tag:    regionGeositeTag(region),
+				Format: C.RuleSetFormatBinary,
+				RemoteOptions: option.RemoteRuleSet{
+					URL:            "https://raw.githubusercontent.com/hiddify/hiddify-geo/rule-set/country/geosite-" + region + ".srs",
+					UpdateInterval: badoption.Duration(5 * time.Hour * 24),
+				},
+			},
+		}
+	}
+}
+
+// tunExcludeRuleSets returns the user rule-sets whose addresses bypass the
+// TUN interface.
+func tunExcludeRuleSets(opt *HiddifyOptions) []option.RuleSet {
+	var ruleSets []option.RuleSet
+	for _, location := range opt.TUNExcludeRuleSets {
+		rule := Rule{RuleSetUrl: location}
+		if ruleSet, ok := rule.MakeRuleSet(fmt.Sprintf("%s-%d", TUNExcludeRuleSetTagPrefix, len(ruleSets))); ok {
+			ruleSets = append(ruleSets, ruleSet)
+		}
+	}
+	return ruleSets
+}
+
+// setTunRouteExclusions keeps the direct addresses of the region and the
+// user exclusions out of the TUN routes.
+func setTunRouteExclusions(tunOptions *option.TunInboundOptions, opt *HiddifyOptions) {
+	if regionRuleSets(opt.Region) != nil {
+		tunOptions.RouteExcludeAddressSet = append(tunOptions.RouteExcludeAddressSet, regionGeoIPTag(opt.Region))
+	}
+	for _, ruleSet := range tunExcludeRuleSets(opt) {
+		tunOptions.RouteExcludeAddressSet = append(tunOptions.RouteExcludeAddressSet, ruleSet.Tag)
+	}
+	for _, address := range opt.TUNExcludeAddress {
+		prefix, err := parseTUNRoutePrefix(address)
+		if err != nil {
+			fmt.Printf("ignoring invalid tun exclude address %q: %v\n", address, err)
+			continue
+		}
+		tunOptions.RouteExcludeAddress = append(tunOptions.RouteExcludeAddress, prefix)
+	}
+}
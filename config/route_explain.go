@@ -0,0 +1,297 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/process"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	R "github.com/sagernet/sing-box/route/rule"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+
+	mDNS "github.com/miekg/dns"
+)
+
+// RouteQuery describes a connection to explain. Conditions on fields left
+// empty do not match, like for a real connection lacking them.
+type RouteQuery struct {
+	Domain      string
+	IP          string
+	Port        uint16
+	Network     string // tcp when empty
+	Protocol    string // the sniffed protocol
+	ProcessName string
+	ProcessPath string
+	PackageName string
+	Inbound     string
+	SourceIP    string
+	SourcePort  uint16
+	ClashMode   string // the default mode of the config when empty
+	WifiSSID    string
+}
+
+// RouteExplanation tells which rules a connection and the lookup of its
+// domain match. Indexes are -1 when no rule matches and the final outbound
+// or DNS server is used.
+type RouteExplanation struct {
+	RuleIndex    int
+	Rule         string
+	Action       string
+	Outbound     string
+	DNSRuleIndex int
+	DNSRule      string
+	DNSServer    string
+	Notes        []string
+}
+
+// ExplainRoute walks the route and DNS rules of built options the way
+// sing-box does for the connection described by query. Local and inline
+// rule-sets are loaded; remote ones never match unless they were localized
+// with LocalizeRuleSets.
+func ExplainRoute(options *option.Options, query RouteQuery) (*RouteExplanation, error) {
+	clashMode := query.ClashMode
+	if clashMode == "" && options.Experimental != nil && options.Experimental.ClashAPI != nil {
+		clashMode = options.Experimental.ClashAPI.DefaultMode
+	}
+	if clashMode == "" {
+		clashMode = "Rule"
+	}
+	explainer := &routeExplainer{ruleSets: make(map[string]adapter.RuleSet)}
+	defer explainer.close()
+
+	ctx := service.ContextWith[adapter.Router](OptionsContext(), explainer)
+	ctx = service.ContextWith[adapter.NetworkManager](ctx, &explainNetworkManager{wifiState: adapter.WIFIState{SSID: query.WifiSSID}})
+	ctx = service.ContextWith[adapter.ClashServer](ctx, &explainClashServer{mode: clashMode})
+	logger := log.NewNOPFactory().NewLogger("explain")
+
+	metadata, err := query.metadata()
+	if err != nil {
+		return nil, err
+	}
+	explanation := &RouteExplanation{RuleIndex: -1, DNSRuleIndex: -1}
+	if options.Route != nil {
+		for _, ruleSet := range options.Route.RuleSet {
+			explanation.Notes = append(explanation.Notes, explainer.loadRuleSet(ctx, logger, ruleSet)...)
+		}
+		if err := explainer.explainRoute(ctx, logger, options.Route, metadata, explanation); err != nil {
+			return nil, err
+		}
+		if explanation.RuleIndex == -1 {
+			explanation.Action = C.RuleActionTypeRoute
+			explanation.Outbound = options.Route.Final
+		}
+	}
+	if explanation.RuleIndex == -1 && explanation.Outbound == "" && len(options.Outbounds) > 0 {
+		explanation.Outbound = options.Outbounds[0].Tag
+	}
+	if query.Domain != "" && options.DNS != nil {
+		if err := explainer.explainDNS(ctx, logger, options.DNS, query, metadata, explanation); err != nil {
+			return nil, err
+		}
+	}
+	return explanation, nil
+}
+
+func (q RouteQuery) metadata() (adapter.InboundContext, error) {
+	metadata := adapter.InboundContext{
+		Inbound:  q.Inbound,
+		Network:  N.NetworkTCP,
+		Protocol: q.Protocol,
+		Domain:   strings.ToLower(q.Domain),
+	}
+	if q.Network != "" {
+		metadata.Network = N.NetworkName(q.Network)
+	}
+	metadata.Destination = M.Socksaddr{Fqdn: metadata.Domain, Port: q.Port}
+	if q.IP != "" {
+		addr, err := netip.ParseAddr(q.IP)
+		if err != nil {
+			return metadata, fmt.Errorf("invalid ip %q", q.IP)
+		}
+		metadata.Destination = M.Socksaddr{Addr: addr, Port: q.Port}
+		metadata.DestinationAddresses = []netip.Addr{addr}
+		if addr.Is4() {
+			metadata.IPVersion = 4
+		} else {
+			metadata.IPVersion = 6
+		}
+	}
+	if q.SourceIP != "" {
+		addr, err := netip.ParseAddr(q.SourceIP)
+		if err != nil {
+			return metadata, fmt.Errorf("invalid source ip %q", q.SourceIP)
+		}
+		metadata.Source = M.Socksaddr{Addr: addr, Port: q.SourcePort}
+	}
+	if q.ProcessName != "" || q.ProcessPath != "" || q.PackageName != "" {
+		processPath := q.ProcessPath
+		if processPath == "" {
+			processPath = q.ProcessName
+		} else if q.ProcessName != "" && filepath.Base(processPath) != q.ProcessName {
+			return metadata, fmt.Errorf("process name %q does not match path %q", q.ProcessName, q.ProcessPath)
+		}
+		metadata.ProcessInfo = &process.Info{
+			ProcessPath: processPath,
+			PackageName: q.PackageName,
+			UserId:      -1,
+		}
+	}
+	return metadata, nil
+}
+
+func (e *routeExplainer) explainRoute(ctx context.Context, logger log.ContextLogger, route *option.RouteOptions, metadata adapter.InboundContext, explanation *RouteExplanation) error {
+	for i, ruleOptions := range route.Rules {
+		rule, err := R.NewRule(ctx, logger, ruleOptions, false)
+		if err != nil {
+			return fmt.Errorf("route rule[%d]: %w", i, err)
+		}
+		if err := rule.Start(); err != nil {
+			return fmt.Errorf("route rule[%d]: %w", i, err)
+		}
+		metadata.ResetRuleCache()
+		matched := rule.Match(&metadata)
+		rule.Close()
+		if !matched {
+			continue
+		}
+		action := rule.Action()
+		switch action.Type() {
+		case C.RuleActionTypeRoute, C.RuleActionTypeReject, C.RuleActionTypeHijackDNS:
+			explanation.RuleIndex = i
+			explanation.Rule = rule.String()
+			explanation.Action = action.Type()
+			if route, ok := action.(*R.RuleActionRoute); ok {
+				explanation.Outbound = route.Outbound
+			}
+			return nil
+		case C.RuleActionTypeResolve:
+			if metadata.Destination.IsFqdn() {
+				explanation.Notes = append(explanation.Notes, fmt.Sprintf("route rule[%d] resolves the domain, give its ip to match ip rules", i))
+			}
+		case C.RuleActionTypeSniff:
+		default:
+			explanation.Notes = append(explanation.Notes, fmt.Sprintf("route rule[%d] %s", i, action))
+		}
+	}
+	return nil
+}
+
+func (e *routeExplainer) explainDNS(ctx context.Context, logger log.ContextLogger, dns *option.DNSOptions, query RouteQuery, metadata adapter.InboundContext, explanation *RouteExplanation) error {
+	metadata.Destination = M.Socksaddr{}
+	metadata.DestinationAddresses = nil
+	metadata.IPVersion = 0
+	metadata.Domain = strings.ToLower(query.Domain)
+	metadata.QueryType = mDNS.TypeA
+	for i, ruleOptions := range dns.Rules {
+		rule, err := R.NewDNSRule(ctx, logger, ruleOptions, false)
+		if err != nil {
+			return fmt.Errorf("dns rule[%d]: %w", i, err)
+		}
+		if err := rule.Start(); err != nil {
+			return fmt.Errorf("dns rule[%d]: %w", i, err)
+		}
+		metadata.ResetRuleCache()
+		matched := rule.Match(&metadata)
+		withAddressLimit := rule.WithAddressLimit()
+		rule.Close()
+		if !matched {
+			continue
+		}
+		if withAddressLimit {
+			explanation.Notes = append(explanation.Notes, fmt.Sprintf("dns rule[%d] also depends on the resolved addresses", i))
+		}
+		switch action := rule.Action().(type) {
+		case *R.RuleActionDNSRoute:
+			explanation.DNSRuleIndex = i
+			explanation.DNSRule = rule.String()
+			explanation.DNSServer = action.Server
+			return nil
+		case *R.RuleActionReject, *R.RuleActionPredefined:
+			explanation.DNSRuleIndex = i
+			explanation.DNSRule = rule.String()
+			explanation.DNSServer = action.String()
+			return nil
+		}
+	}
+	explanation.DNSServer = dns.Final
+	if explanation.DNSServer == "" && len(dns.Servers) > 0 {
+		explanation.DNSServer = dns.Servers[0].Tag
+	}
+	return nil
+}
+
+// routeExplainer is the router the sing-box rules look their rule-sets up
+// in. Rules call no other method of the embedded interfaces here and in the
+// stubs below.
+type routeExplainer struct {
+	adapter.Router
+	ruleSets map[string]adapter.RuleSet
+}
+
+func (e *routeExplainer) RuleSet(tag string) (adapter.RuleSet, bool) {
+	ruleSet, loaded := e.ruleSets[tag]
+	return ruleSet, loaded
+}
+
+func (e *routeExplainer) loadRuleSet(ctx context.Context, logger log.ContextLogger, options option.RuleSet) []string {
+	if options.Type == C.RuleSetTypeRemote {
+		e.ruleSets[options.Tag] = &unavailableRuleSet{tag: options.Tag}
+		return []string{fmt.Sprintf("remote rule-set %s is not available offline and never matches", options.Tag)}
+	}
+	ruleSet, err := R.NewLocalRuleSet(ctx, logger, options)
+	if err != nil {
+		e.ruleSets[options.Tag] = &unavailableRuleSet{tag: options.Tag}
+		return []string{fmt.Sprintf("rule-set %s never matches: %v", options.Tag, err)}
+	}
+	e.ruleSets[options.Tag] = ruleSet
+	return nil
+}
+
+func (e *routeExplainer) close() {
+	for _, ruleSet := range e.ruleSets {
+		ruleSet.Close()
+	}
+}
+
+type explainNetworkManager struct {
+	adapter.NetworkManager
+	wifiState adapter.WIFIState
+}
+
+func (m *explainNetworkManager) WIFIState() adapter.WIFIState {
+	return m.wifiState
+}
+
+func (m *explainNetworkManager) DefaultNetworkInterface() *adapter.NetworkInterface {
+	return nil
+}
+
+type explainClashServer struct {
+	adapter.ClashServer
+	mode string
+}
+
+func (s *explainClashServer) Mode() string {
+	return s.mode
+}
+
+type unavailableRuleSet struct {
+	adapter.RuleSet
+	tag string
+}
+
+func (s *unavailableRuleSet) Name() string                       { return s.tag }
+func (s *unavailableRuleSet) String() string                     { return s.tag }
+func (s *unavailableRuleSet) Metadata() adapter.RuleSetMetadata  { return adapter.RuleSetMetadata{} }
+func (s *unavailableRuleSet) Match(*adapter.InboundContext) bool { return false }
+func (s *unavailableRuleSet) IncRef()                            {}
+func (s *unavailableRuleSet) DecRef()                            {}
+func (s *unavailableRuleSet) Close() error                       { return nil }
@@ -1,23 +1,161 @@
 package config
 
 import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
+	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
+	badoption "github.com/sagernet/sing/common/json/badoption"
 )
 
+// Rule routes the connections matching all its conditions to Outbound.
+// List conditions are comma separated and match any of their items.
+//
+// A rule with a Mode of "and" or "or" is a logical rule combining its Rules
+// and, when it has any, its own conditions. Invert negates the match of any
+// rule. Nested rules only contribute their conditions; their Outbound and
+// Priority are ignored.
 type Rule struct {
 	RuleSetUrl string `json:"rule-set-url"`
-	Domains    string `json:"domains"`
-	IP         string `json:"ip"`
-	Port       string `json:"port"`
-	Network    string `json:"network"`
-	Protocol   string `json:"protocol"`
-	Outbound   string `json:"outbound"`
+	// RuleSetUpdateInterval and RuleSetDownloadDetour only apply to remote
+	// rule-sets; the detour accepts the same values as Outbound.
+	RuleSetUpdateInterval DurationInSeconds `json:"rule-set-update-interval"`
+	RuleSetDownloadDetour string            `json:"rule-set-download-detour"`
+	Domains               string            `json:"domains"`
+	IP                    string            `json:"ip"`
+	Port                  string            `json:"port"`
+	Network               string            `json:"network"`
+	Protocol              string            `json:"protocol"`
+	SourceIP              string            `json:"source-ip"`
+	SourcePort            string            `json:"source-port"`
+	ProcessName           string            `json:"process-name"`
+	Inbound               string            `json:"inbound"`
+	WifiSSID              string            `json:"wifi-ssid"`
+	Invert                bool              `json:"invert"`
+	Mode                  string            `json:"mode"`
+	Rules                 []Rule            `json:"rules"`
+	// Priority orders the rules, higher first. Rules of equal priority keep
+	// their order.
+	Priority int    `json:"priority"`
+	Outbound string `json:"outbound"`
 }
 
-func (r *Rule) MakeRule() option.DefaultRule {
+func (r *Rule) isLogical() bool {
+	return r.Mode != "" || len(r.Rules) > 0
+}
+
+// conditions returns r without its logical part.
+func (r *Rule) conditions() Rule {
+	leaf := *r
+	leaf.Mode = ""
+	leaf.Rules = nil
+	leaf.Invert = false
+	return leaf
+}
+
+func (r *Rule) validate() error {
+	switch r.Mode {
+	case C.LogicalTypeAnd, C.LogicalTypeOr:
+	case "":
+		if len(r.Rules) > 0 {
+			return fmt.Errorf("rule: nested rules need a mode")
+		}
+	default:
+		return fmt.Errorf("rule: unknown mode %q", r.Mode)
+	}
+	for i := range r.Rules {
+		if err := r.Rules[i].validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortRules orders rules by priority, keeping the order of equal ones.
+func sortRules(rules []Rule) []Rule {
+	sorted := slices.Clone(rules)
+	slices.SortStableFunc(sorted, func(a, b Rule) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+	return sorted
+}
+
+// MakeRuleSet builds the rule-set referenced by RuleSetUrl. http(s) urls
+// become remote rule-sets, file:// urls and plain paths local ones. Files
+// ending in .json are read as source rule-sets, anything else as binary.
+func (r *Rule) MakeRuleSet(tag string) (option.RuleSet, bool) {
+	location := strings.TrimSpace(r.RuleSetUrl)
+	if location == "" {
+		return option.RuleSet{}, false
+	}
+	ruleSet := option.RuleSet{
+		Tag:    tag,
+		Format: C.RuleSetFormatBinary,
+	}
+	name := location
+	if parsedURL, err := url.Parse(location); err == nil && parsedURL.Scheme != "" {
+		name = parsedURL.Path
+		switch strings.ToLower(parsedURL.Scheme) {
+		case "http", "https":
+			ruleSet.Type = C.RuleSetTypeRemote
+			ruleSet.RemoteOptions = option.RemoteRuleSet{
+				URL:            location,
+				DownloadDetour: resolveRuleOutbound(r.RuleSetDownloadDetour),
+				UpdateInterval: badoption.Duration(r.RuleSetUpdateInterval.Duration()),
+			}
+		case "file":
+			location = filepath.FromSlash(parsedURL.Path)
+			if parsedURL.Host != "" {
+				location = filepath.Join(parsedURL.Host, location)
+			}
+		}
+	}
+	if ruleSet.Type == "" {
+		ruleSet.Type = C.RuleSetTypeLocal
+		ruleSet.LocalOptions = option.LocalRuleSet{Path: location}
+	}
+	if strings.EqualFold(path.Ext(name), ".json") {
+		ruleSet.Format = C.RuleSetFormatSource
+	}
+	return ruleSet, true
+}
+
+// MakeRule builds the route rule matching r, without action. ruleSetTag
+// returns the tag of the rule-set of a rule, "" for none; it may be nil.
+func (r *Rule) MakeRule(ruleSetTag func(*Rule) string) option.Rule {
+	if !r.isLogical() {
+		return option.Rule{Type: C.RuleTypeDefault, DefaultOptions: r.makeDefaultRule(ruleSetTag)}
+	}
+	var rules []option.Rule
+	leaf := r.conditions()
+	if own := leaf.MakeRule(ruleSetTag); own.IsValid() {
+		rules = append(rules, own)
+	}
+	for i := range r.Rules {
+		if rule := r.Rules[i].MakeRule(ruleSetTag); rule.IsValid() {
+			rules = append(rules, rule)
+		}
+	}
+	return option.Rule{
+		Type: C.RuleTypeLogical,
+		LogicalOptions: option.LogicalRule{
+			RawLogicalRule: option.RawLogicalRule{
+				Mode:   r.Mode,
+				Rules:  rules,
+				Invert: r.Invert,
+			},
+		},
+	}
+}
+
+func (r *Rule) makeDefaultRule(ruleSetTag func(*Rule) string) option.DefaultRule {
 	rule := option.DefaultRule{}
 	if len(r.Domains) > 0 {
 		rule = makeDomainRule(rule, strings.Split(r.Domains, ","))
@@ -29,15 +167,55 @@ func (r *Rule) MakeRule() option.DefaultRule {
 		rule = makePortRule(rule, strings.Split(r.Port, ","))
 	}
 	if len(r.Network) > 0 {
-		rule.Network = append(rule.Network, r.Network)
+		rule.Network = append(rule.Network, splitList(r.Network)...)
 	}
 	if len(r.Protocol) > 0 {
 		rule.Protocol = append(rule.Protocol, strings.Split(r.Protocol, ",")...)
 	}
+	if len(r.SourceIP) > 0 {
+		rule.SourceIPCIDR = append(rule.SourceIPCIDR, splitList(r.SourceIP)...)
+	}
+	if len(r.SourcePort) > 0 {
+		rule.SourcePort, rule.SourcePortRange = makePorts(rule.SourcePort, rule.SourcePortRange, strings.Split(r.SourcePort, ","))
+	}
+	r.addCommonConditions(&rule.ProcessName, &rule.Inbound, &rule.WIFISSID)
+	if tag := r.ruleSetTag(ruleSetTag); tag != "" {
+		rule.RuleSet = append(rule.RuleSet, tag)
+	}
+	rule.Invert = r.Invert
 	return rule
 }
 
-func (r *Rule) MakeDNSRule() option.DefaultDNSRule {
+// MakeDNSRule builds the DNS rule of r, without action. Only the conditions
+// known when resolving are kept: destination IPs, ports, networks and
+// protocols are dropped.
+func (r *Rule) MakeDNSRule(ruleSetTag func(*Rule) string) option.DNSRule {
+	if !r.isLogical() {
+		return option.DNSRule{Type: C.RuleTypeDefault, DefaultOptions: r.makeDefaultDNSRule(ruleSetTag)}
+	}
+	var rules []option.DNSRule
+	leaf := r.conditions()
+	if own := leaf.MakeDNSRule(ruleSetTag); own.IsValid() {
+		rules = append(rules, own)
+	}
+	for i := range r.Rules {
+		if rule := r.Rules[i].MakeDNSRule(ruleSetTag); rule.IsValid() {
+			rules = append(rules, rule)
+		}
+	}
+	return option.DNSRule{
+		Type: C.RuleTypeLogical,
+		LogicalOptions: option.LogicalDNSRule{
+			RawLogicalDNSRule: option.RawLogicalDNSRule{
+				Mode:   r.Mode,
+				Rules:  rules,
+				Invert: r.Invert,
+			},
+		},
+	}
+}
+
+func (r *Rule) makeDefaultDNSRule(ruleSetTag func(*Rule) string) option.DefaultDNSRule {
 	rule := option.DefaultDNSRule{}
 	domains := strings.Split(r.Domains, ",")
 	for _, item := range domains {
@@ -53,9 +231,44 @@ func (r *Rule) MakeDNSRule() option.DefaultDNSRule {
 			rule.DomainKeyword = append(rule.DomainKeyword, strings.ToLower(strings.TrimPrefix(item, "keyword:")))
 		}
 	}
+	if len(r.SourceIP) > 0 {
+		rule.SourceIPCIDR = append(rule.SourceIPCIDR, splitList(r.SourceIP)...)
+	}
+	if len(r.SourcePort) > 0 {
+		rule.SourcePort, rule.SourcePortRange = makePorts(rule.SourcePort, rule.SourcePortRange, strings.Split(r.SourcePort, ","))
+	}
+	r.addCommonConditions(&rule.ProcessName, &rule.Inbound, &rule.WIFISSID)
+	if tag := r.ruleSetTag(ruleSetTag); tag != "" {
+		rule.RuleSet = append(rule.RuleSet, tag)
+	}
+	rule.Invert = r.Invert
 	return rule
 }
 
+func (r *Rule) addCommonConditions(processName, inbound, wifiSSID *badoption.Listable[string]) {
+	*processName = append(*processName, splitList(r.ProcessName)...)
+	*inbound = append(*inbound, splitList(r.Inbound)...)
+	*wifiSSID = append(*wifiSSID, splitList(r.WifiSSID)...)
+}
+
+func (r *Rule) ruleSetTag(ruleSetTag func(*Rule) string) string {
+	if ruleSetTag == nil {
+		return ""
+	}
+	return ruleSetTag(r)
+}
+
+// splitList splits a comma separated list, dropping empty items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func makeDomainRule(options option.DefaultRule, list []string) option.DefaultRule {
 	for _, item := range list {
 		if strings.HasPrefix(item, "geosite:") {
@@ -85,12 +298,17 @@ func makeIpRule(options option.DefaultRule, list []string) option.DefaultRule {
 }
 
 func makePortRule(options option.DefaultRule, list []string) option.DefaultRule {
+	options.Port, options.PortRange = makePorts(options.Port, options.PortRange, list)
+	return options
+}
+
+func makePorts(ports badoption.Listable[uint16], portRanges badoption.Listable[string], list []string) (badoption.Listable[uint16], badoption.Listable[string]) {
 	for _, item := range list {
 		if strings.Contains(item, ":") {
-			options.PortRange = append(options.PortRange, item)
+			portRanges = append(portRanges, item)
 		} else if i, err := strconv.Atoi(item); err == nil {
-			options.Port = append(options.Port, uint16(i))
+			ports = append(ports, uint16(i))
 		}
 	}
-	return options
+	return ports, portRanges
 }
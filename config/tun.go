@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"runtime"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	dns "github.com/sagernet/sing-dns"
+)
+
+const (
+	DefaultTUNInet4Address = "172.19.0.1/30"
+	DefaultTUNInet6Address = "2001:0470:f9da:fdfa::1/64"
+)
+
+func (o InboundOptions) validateTUN() error {
+	for i, value := range []string{o.TUNInet4Address, o.TUNInet6Address} {
+		if value == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return fmt.Errorf("tun: invalid address %q", value)
+		}
+		if prefix.Addr().Is4() != (i == 0) {
+			return fmt.Errorf("tun: address %q is not ipv%d", value, []int{4, 6}[i])
+		}
+	}
+	for _, address := range o.TUNRouteAddress {
+		if _, err := parseTUNRoutePrefix(address); err != nil {
+			return fmt.Errorf("tun: invalid route address %q", address)
+		}
+	}
+	return nil
+}
+
+// setTunAddressing sets the addresses of the TUN interface for the families
+// allowed by the IPv6 mode, and the routes it takes over when it should not
+// take over all traffic.
+func setTunAddressing(tunOptions *option.TunInboundOptions, opt *HiddifyOptions) {
+	inet4Address := opt.TUNInet4Address
+	if inet4Address == "" {
+		inet4Address = DefaultTUNInet4Address
+	}
+	inet6Address := opt.TUNInet6Address
+	if inet6Address == "" {
+		inet6Address = DefaultTUNInet6Address
+	}
+	switch opt.IPv6Mode {
+	case option.DomainStrategy(dns.DomainStrategyUseIPv4):
+		tunOptions.Address = append(tunOptions.Address, netip.MustParsePrefix(inet4Address))
+	case option.DomainStrategy(dns.DomainStrategyUseIPv6):
+		tunOptions.Address = append(tunOptions.Address, netip.MustParsePrefix(inet6Address))
+	default:
+		tunOptions.Address = append(
+			tunOptions.Address,
+			netip.MustParsePrefix(inet4Address),
+			netip.MustParsePrefix(inet6Address),
+		)
+	}
+	for _, address := range opt.TUNRouteAddress {
+		prefix, _ := parseTUNRoutePrefix(address)
+		tunOptions.RouteAddress = append(tunOptions.RouteAddress, prefix)
+	}
+	tunOptions.InterfaceName = opt.TUNInterfaceName
+	tunOptions.EndpointIndependentNat = !opt.TUNDisableEndpointIndependentNat
+	tunOptions.AutoRedirect = opt.TUNAutoRedirect && runtime.GOOS == "linux"
+}
+
+// parseTUNRoutePrefix accepts a prefix or a single address.
+func parseTUNRoutePrefix(address string) (netip.Prefix, error) {
+	address = strings.TrimSpace(address)
+	prefix, err := netip.ParsePrefix(address)
+	if err == nil {
+		return prefix, nil
+	}
+	addr, addrErr := netip.ParseAddr(address)
+	if addrErr != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+const tunnelServiceProxyTag = "socks-out"
+
+// tunnelServiceBypassProcesses are the processes of the core, whose own
+// connections must not loop back into the tunnel.
+var tunnelServiceBypassProcesses = []string{
+	"Hiddify.exe",
+	"Hiddify",
+	"HiddifyCli",
+	"HiddifyCli.exe",
+}
+
+// BuildTunnelServiceConfig builds the config of the tunnel service, which
+// sends the traffic of its TUN inbound to the mixed inbound of the core at
+// opt.MixedPort, authenticated as the first LAN sharing user if any.
+func BuildTunnelServiceConfig(opt HiddifyOptions) (*option.Options, error) {
+	if err := opt.InboundOptions.validateTUN(); err != nil {
+		return nil, err
+	}
+	if err := opt.PerAppProxy.validate(); err != nil {
+		return nil, err
+	}
+
+	tunOptions := option.TunInboundOptions{
+		Stack:       opt.TUNStack,
+		MTU:         opt.MTU,
+		AutoRoute:   true,
+		StrictRoute: opt.StrictRoute,
+	}
+	setTunAddressing(&tunOptions, &opt)
+	setTunRouteExclusions(&tunOptions, &opt)
+	setTunPerAppProxy(&tunOptions, opt.PerAppProxy)
+
+	proxyOptions := option.SOCKSOutboundOptions{
+		ServerOptions: option.ServerOptions{Server: "127.0.0.1", ServerPort: opt.MixedPort},
+		Version:       "5",
+	}
+	if users := opt.LANSharing.Users; len(users) > 0 {
+		proxyOptions.Username = users[0].Username
+		proxyOptions.Password = users[0].Password
+	}
+
+	rules := []option.Rule{
+		{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultRule{
+				RawDefaultRule: option.RawDefaultRule{ProcessName: tunnelServiceBypassProcesses},
+				RuleAction:     routeActionForOutbound(OutboundDirectTag),
+			},
+		},
+	}
+	// DNS goes to the core ahead of the per-app rules, so the resolver
+	// queries of bypassed apps are answered by the DNS of the core.
+	rules = append(rules, option.Rule{
+		Type: C.RuleTypeDefault,
+		DefaultOptions: option.DefaultRule{
+			RawDefaultRule: option.RawDefaultRule{Port: []uint16{53}},
+			RuleAction:     routeActionForOutbound(tunnelServiceProxyTag),
+		},
+	})
+	rules = append(rules, PerAppProxyRules(opt.PerAppProxy, InboundTUNTag, OutboundDirectTag)...)
+
+	return &option.Options{
+		Log: &option.LogOptions{Level: "warn"},
+		Inbounds: []option.Inbound{
+			{
+				Type:    C.TypeTun,
+				Tag:     InboundTUNTag,
+				Options: tunOptions,
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type:    C.TypeSOCKS,
+				Tag:     tunnelServiceProxyTag,
+				Options: proxyOptions,
+			},
+			{
+				Type:    C.TypeDirect,
+				Tag:     OutboundDirectTag,
+				Options: option.DirectOutboundOptions{},
+			},
+		},
+		Route: &option.RouteOptions{
+			Rules:               rules,
+			Final:               tunnelServiceProxyTag,
+			AutoDetectInterface: true,
+		},
+	}, nil
+}
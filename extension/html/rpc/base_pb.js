@@ -454,7 +454,8 @@ proto.hiddifyrpc.Empty.serializeBinaryToWriter = function(message, writer) {
  */
 proto.hiddifyrpc.ResponseCode = {
   OK: 0,
-  FAILED: 1
+  FAILED: 1,
+  NOT_FOUND: 2
 };
 
 goog.object.extend(exports, proto.hiddifyrpc);
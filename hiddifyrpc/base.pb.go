@@ -24,8 +24,9 @@ const (
 type ResponseCode int32
 
 const (
-	ResponseCode_OK     ResponseCode = 0
-	ResponseCode_FAILED ResponseCode = 1
+	ResponseCode_OK        ResponseCode = 0
+	ResponseCode_FAILED    ResponseCode = 1
+	ResponseCode_NOT_FOUND ResponseCode = 2
 )
 
 // Enum value maps for ResponseCode.
@@ -33,10 +34,12 @@ var (
 	ResponseCode_name = map[int32]string{
 		0: "OK",
 		1: "FAILED",
+		2: "NOT_FOUND",
 	}
 	ResponseCode_value = map[string]int32{
-		"OK":     0,
-		"FAILED": 1,
+		"OK":        0,
+		"FAILED":    1,
+		"NOT_FOUND": 2,
 	}
 )
 
@@ -202,11 +205,12 @@ const file_base_proto_rawDesc = "" +
 	"\x04name\x18\x01 \x01(\tR\x04name\")\n" +
 	"\rHelloResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\"\a\n" +
-	"\x05Empty*\"\n" +
+	"\x05Empty*1\n" +
 	"\fResponseCode\x12\x06\n" +
 	"\x02OK\x10\x00\x12\n" +
 	"\n" +
-	"\x06FAILED\x10\x01B\x0eZ\f./hiddifyrpcb\x06proto3"
+	"\x06FAILED\x10\x01\x12\r\n" +
+	"\tNOT_FOUND\x10\x02B\x0eZ\f./hiddifyrpcb\x06proto3"
 
 var (
 	file_base_proto_rawDescOnce sync.Once
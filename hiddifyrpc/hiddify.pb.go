@@ -155,6 +155,104 @@ func (MessageType) EnumDescriptor() ([]byte, []int) {
 	return file_hiddify_proto_rawDescGZIP(), []int{1}
 }
 
+type ProfileType int32
+
+const (
+	ProfileType_PROFILE_LOCAL   ProfileType = 0
+	ProfileType_PROFILE_REMOTE  ProfileType = 1
+	ProfileType_PROFILE_CONTENT ProfileType = 2
+)
+
+// Enum value maps for ProfileType.
+var (
+	ProfileType_name = map[int32]string{
+		0: "PROFILE_LOCAL",
+		1: "PROFILE_REMOTE",
+		2: "PROFILE_CONTENT",
+	}
+	ProfileType_value = map[string]int32{
+		"PROFILE_LOCAL":   0,
+		"PROFILE_REMOTE":  1,
+		"PROFILE_CONTENT": 2,
+	}
+)
+
+func (x ProfileType) Enum() *ProfileType {
+	p := new(ProfileType)
+	*p = x
+	return p
+}
+
+func (x ProfileType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProfileType) Descriptor() protoreflect.EnumDescriptor {
+	return file_hiddify_proto_enumTypes[2].Descriptor()
+}
+
+func (ProfileType) Type() protoreflect.EnumType {
+	return &file_hiddify_proto_enumTypes[2]
+}
+
+func (x ProfileType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProfileType.Descriptor instead.
+func (ProfileType) EnumDescriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{2}
+}
+
+type RefreshStatus int32
+
+const (
+	RefreshStatus_REFRESH_SUCCESS   RefreshStatus = 0
+	RefreshStatus_REFRESH_UNCHANGED RefreshStatus = 1
+	RefreshStatus_REFRESH_FAILED    RefreshStatus = 2
+)
+
+// Enum value maps for RefreshStatus.
+var (
+	RefreshStatus_name = map[int32]string{
+		0: "REFRESH_SUCCESS",
+		1: "REFRESH_UNCHANGED",
+		2: "REFRESH_FAILED",
+	}
+	RefreshStatus_value = map[string]int32{
+		"REFRESH_SUCCESS":   0,
+		"REFRESH_UNCHANGED": 1,
+		"REFRESH_FAILED":    2,
+	}
+)
+
+func (x RefreshStatus) Enum() *RefreshStatus {
+	p := new(RefreshStatus)
+	*p = x
+	return p
+}
+
+func (x RefreshStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RefreshStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_hiddify_proto_enumTypes[3].Descriptor()
+}
+
+func (RefreshStatus) Type() protoreflect.EnumType {
+	return &file_hiddify_proto_enumTypes[3]
+}
+
+func (x RefreshStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RefreshStatus.Descriptor instead.
+func (RefreshStatus) EnumDescriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{3}
+}
+
 type LogLevel int32
 
 const (
@@ -194,11 +292,11 @@ func (x LogLevel) String() string {
 }
 
 func (LogLevel) Descriptor() protoreflect.EnumDescriptor {
-	return file_hiddify_proto_enumTypes[2].Descriptor()
+	return file_hiddify_proto_enumTypes[4].Descriptor()
 }
 
 func (LogLevel) Type() protoreflect.EnumType {
-	return &file_hiddify_proto_enumTypes[2]
+	return &file_hiddify_proto_enumTypes[4]
 }
 
 func (x LogLevel) Number() protoreflect.EnumNumber {
@@ -207,7 +305,7 @@ func (x LogLevel) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use LogLevel.Descriptor instead.
 func (LogLevel) EnumDescriptor() ([]byte, []int) {
-	return file_hiddify_proto_rawDescGZIP(), []int{2}
+	return file_hiddify_proto_rawDescGZIP(), []int{4}
 }
 
 type LogType int32
@@ -243,11 +341,11 @@ func (x LogType) String() string {
 }
 
 func (LogType) Descriptor() protoreflect.EnumDescriptor {
-	return file_hiddify_proto_enumTypes[3].Descriptor()
+	return file_hiddify_proto_enumTypes[5].Descriptor()
 }
 
 func (LogType) Type() protoreflect.EnumType {
-	return &file_hiddify_proto_enumTypes[3]
+	return &file_hiddify_proto_enumTypes[5]
 }
 
 func (x LogType) Number() protoreflect.EnumNumber {
@@ -256,7 +354,7 @@ func (x LogType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use LogType.Descriptor instead.
 func (LogType) EnumDescriptor() ([]byte, []int) {
-	return file_hiddify_proto_rawDescGZIP(), []int{3}
+	return file_hiddify_proto_rawDescGZIP(), []int{5}
 }
 
 type CoreInfoResponse struct {
@@ -327,6 +425,7 @@ type StartRequest struct {
 	DelayStart             bool                   `protobuf:"varint,4,opt,name=delay_start,json=delayStart,proto3" json:"delay_start,omitempty"`
 	EnableOldCommandServer bool                   `protobuf:"varint,5,opt,name=enable_old_command_server,json=enableOldCommandServer,proto3" json:"enable_old_command_server,omitempty"`
 	EnableRawConfig        bool                   `protobuf:"varint,6,opt,name=enable_raw_config,json=enableRawConfig,proto3" json:"enable_raw_config,omitempty"`
+	ProfileId              string                 `protobuf:"bytes,7,opt,name=profile_id,json=profileId,proto3" json:"profile_id,omitempty"` // Start a stored profile instead of config_path/config_content.
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
@@ -403,6 +502,13 @@ func (x *StartRequest) GetEnableRawConfig() bool {
 	return false
 }
 
+func (x *StartRequest) GetProfileId() string {
+	if x != nil {
+		return x.ProfileId
+	}
+	return ""
+}
+
 type SetupRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	BasePath      string                 `protobuf:"bytes,1,opt,name=base_path,json=basePath,proto3" json:"base_path,omitempty"`
@@ -861,6 +967,7 @@ type ParseRequest struct {
 	ConfigPath    string                 `protobuf:"bytes,2,opt,name=config_path,json=configPath,proto3" json:"config_path,omitempty"`
 	TempPath      string                 `protobuf:"bytes,3,opt,name=temp_path,json=tempPath,proto3" json:"temp_path,omitempty"`
 	Debug         bool                   `protobuf:"varint,4,opt,name=debug,proto3" json:"debug,omitempty"`
+	AllowPartial  bool                   `protobuf:"varint,5,opt,name=allow_partial,json=allowPartial,proto3" json:"allow_partial,omitempty"` // Drop invalid outbounds instead of failing.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -923,29 +1030,38 @@ func (x *ParseRequest) GetDebug() bool {
 	return false
 }
 
-type ParseResponse struct {
+func (x *ParseRequest) GetAllowPartial() bool {
+	if x != nil {
+		return x.AllowPartial
+	}
+	return false
+}
+
+type ParseDiagnostic struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ResponseCode  ResponseCode           `protobuf:"varint,1,opt,name=response_code,json=responseCode,proto3,enum=hiddifyrpc.ResponseCode" json:"response_code,omitempty"`
-	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"` // "line", "proxies" or "outbounds".
+	Index         int32                  `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ParseResponse) Reset() {
-	*x = ParseResponse{}
+func (x *ParseDiagnostic) Reset() {
+	*x = ParseDiagnostic{}
 	mi := &file_hiddify_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ParseResponse) String() string {
+func (x *ParseDiagnostic) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ParseResponse) ProtoMessage() {}
+func (*ParseDiagnostic) ProtoMessage() {}
 
-func (x *ParseResponse) ProtoReflect() protoreflect.Message {
+func (x *ParseDiagnostic) ProtoReflect() protoreflect.Message {
 	mi := &file_hiddify_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -957,53 +1073,72 @@ func (x *ParseResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ParseResponse.ProtoReflect.Descriptor instead.
-func (*ParseResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ParseDiagnostic.ProtoReflect.Descriptor instead.
+func (*ParseDiagnostic) Descriptor() ([]byte, []int) {
 	return file_hiddify_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *ParseResponse) GetResponseCode() ResponseCode {
+func (x *ParseDiagnostic) GetTag() string {
 	if x != nil {
-		return x.ResponseCode
+		return x.Tag
 	}
-	return ResponseCode_OK
+	return ""
 }
 
-func (x *ParseResponse) GetContent() string {
+func (x *ParseDiagnostic) GetType() string {
 	if x != nil {
-		return x.Content
+		return x.Type
 	}
 	return ""
 }
 
-func (x *ParseResponse) GetMessage() string {
+func (x *ParseDiagnostic) GetSource() string {
 	if x != nil {
-		return x.Message
+		return x.Source
 	}
 	return ""
 }
 
-type ChangeHiddifySettingsRequest struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	HiddifySettingsJson string                 `protobuf:"bytes,1,opt,name=hiddify_settings_json,json=hiddifySettingsJson,proto3" json:"hiddify_settings_json,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+func (x *ParseDiagnostic) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
 }
 
-func (x *ChangeHiddifySettingsRequest) Reset() {
-	*x = ChangeHiddifySettingsRequest{}
+func (x *ParseDiagnostic) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ParseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResponseCode  ResponseCode           `protobuf:"varint,1,opt,name=response_code,json=responseCode,proto3,enum=hiddifyrpc.ResponseCode" json:"response_code,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Total         int32                  `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`
+	Accepted      int32                  `protobuf:"varint,5,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Diagnostics   []*ParseDiagnostic     `protobuf:"bytes,6,rep,name=diagnostics,proto3" json:"diagnostics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseResponse) Reset() {
+	*x = ParseResponse{}
 	mi := &file_hiddify_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ChangeHiddifySettingsRequest) String() string {
+func (x *ParseResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChangeHiddifySettingsRequest) ProtoMessage() {}
+func (*ParseResponse) ProtoMessage() {}
 
-func (x *ChangeHiddifySettingsRequest) ProtoReflect() protoreflect.Message {
+func (x *ParseResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_hiddify_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1015,39 +1150,74 @@ func (x *ChangeHiddifySettingsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ChangeHiddifySettingsRequest.ProtoReflect.Descriptor instead.
-func (*ChangeHiddifySettingsRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ParseResponse.ProtoReflect.Descriptor instead.
+func (*ParseResponse) Descriptor() ([]byte, []int) {
 	return file_hiddify_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *ChangeHiddifySettingsRequest) GetHiddifySettingsJson() string {
+func (x *ParseResponse) GetResponseCode() ResponseCode {
 	if x != nil {
-		return x.HiddifySettingsJson
+		return x.ResponseCode
+	}
+	return ResponseCode_OK
+}
+
+func (x *ParseResponse) GetContent() string {
+	if x != nil {
+		return x.Content
 	}
 	return ""
 }
 
-type HiddifySettingsResponse struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	HiddifySettingsJson string                 `protobuf:"bytes,1,opt,name=hiddify_settings_json,json=hiddifySettingsJson,proto3" json:"hiddify_settings_json,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+func (x *ParseResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
 }
 
-func (x *HiddifySettingsResponse) Reset() {
-	*x = HiddifySettingsResponse{}
+func (x *ParseResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ParseResponse) GetAccepted() int32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *ParseResponse) GetDiagnostics() []*ParseDiagnostic {
+	if x != nil {
+		return x.Diagnostics
+	}
+	return nil
+}
+
+type SubscriptionInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConfigPath    string                 `protobuf:"bytes,1,opt,name=config_path,json=configPath,proto3" json:"config_path,omitempty"` // Subscription url or file path the profile was read from.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscriptionInfoRequest) Reset() {
+	*x = SubscriptionInfoRequest{}
 	mi := &file_hiddify_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HiddifySettingsResponse) String() string {
+func (x *SubscriptionInfoRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HiddifySettingsResponse) ProtoMessage() {}
+func (*SubscriptionInfoRequest) ProtoMessage() {}
 
-func (x *HiddifySettingsResponse) ProtoReflect() protoreflect.Message {
+func (x *SubscriptionInfoRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_hiddify_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1059,41 +1229,48 @@ func (x *HiddifySettingsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HiddifySettingsResponse.ProtoReflect.Descriptor instead.
-func (*HiddifySettingsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SubscriptionInfoRequest.ProtoReflect.Descriptor instead.
+func (*SubscriptionInfoRequest) Descriptor() ([]byte, []int) {
 	return file_hiddify_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *HiddifySettingsResponse) GetHiddifySettingsJson() string {
+func (x *SubscriptionInfoRequest) GetConfigPath() string {
 	if x != nil {
-		return x.HiddifySettingsJson
+		return x.ConfigPath
 	}
 	return ""
 }
 
-type GenerateConfigRequest struct {
+type SubscriptionInfoResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	TempPath      string                 `protobuf:"bytes,2,opt,name=temp_path,json=tempPath,proto3" json:"temp_path,omitempty"`
-	Debug         bool                   `protobuf:"varint,3,opt,name=debug,proto3" json:"debug,omitempty"`
+	ResponseCode  ResponseCode           `protobuf:"varint,1,opt,name=response_code,json=responseCode,proto3,enum=hiddifyrpc.ResponseCode" json:"response_code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Upload        int64                  `protobuf:"varint,3,opt,name=upload,proto3" json:"upload,omitempty"`
+	Download      int64                  `protobuf:"varint,4,opt,name=download,proto3" json:"download,omitempty"`
+	Total         int64                  `protobuf:"varint,5,opt,name=total,proto3" json:"total,omitempty"`
+	Remaining     int64                  `protobuf:"varint,6,opt,name=remaining,proto3" json:"remaining,omitempty"` // -1 if the provider sent no quota.
+	Expire        int64                  `protobuf:"varint,7,opt,name=expire,proto3" json:"expire,omitempty"`       // Unix seconds, 0 if unknown.
+	Title         string                 `protobuf:"bytes,8,opt,name=title,proto3" json:"title,omitempty"`
+	SupportUrl    string                 `protobuf:"bytes,9,opt,name=support_url,json=supportUrl,proto3" json:"support_url,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GenerateConfigRequest) Reset() {
-	*x = GenerateConfigRequest{}
+func (x *SubscriptionInfoResponse) Reset() {
+	*x = SubscriptionInfoResponse{}
 	mi := &file_hiddify_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GenerateConfigRequest) String() string {
+func (x *SubscriptionInfoResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenerateConfigRequest) ProtoMessage() {}
+func (*SubscriptionInfoResponse) ProtoMessage() {}
 
-func (x *GenerateConfigRequest) ProtoReflect() protoreflect.Message {
+func (x *SubscriptionInfoResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_hiddify_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1105,53 +1282,109 @@ func (x *GenerateConfigRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenerateConfigRequest.ProtoReflect.Descriptor instead.
-func (*GenerateConfigRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use SubscriptionInfoResponse.ProtoReflect.Descriptor instead.
+func (*SubscriptionInfoResponse) Descriptor() ([]byte, []int) {
 	return file_hiddify_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *GenerateConfigRequest) GetPath() string {
+func (x *SubscriptionInfoResponse) GetResponseCode() ResponseCode {
 	if x != nil {
-		return x.Path
+		return x.ResponseCode
+	}
+	return ResponseCode_OK
+}
+
+func (x *SubscriptionInfoResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
 	}
 	return ""
 }
 
-func (x *GenerateConfigRequest) GetTempPath() string {
+func (x *SubscriptionInfoResponse) GetUpload() int64 {
 	if x != nil {
-		return x.TempPath
+		return x.Upload
+	}
+	return 0
+}
+
+func (x *SubscriptionInfoResponse) GetDownload() int64 {
+	if x != nil {
+		return x.Download
+	}
+	return 0
+}
+
+func (x *SubscriptionInfoResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SubscriptionInfoResponse) GetRemaining() int64 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+func (x *SubscriptionInfoResponse) GetExpire() int64 {
+	if x != nil {
+		return x.Expire
+	}
+	return 0
+}
+
+func (x *SubscriptionInfoResponse) GetTitle() string {
+	if x != nil {
+		return x.Title
 	}
 	return ""
 }
 
-func (x *GenerateConfigRequest) GetDebug() bool {
+func (x *SubscriptionInfoResponse) GetSupportUrl() string {
 	if x != nil {
-		return x.Debug
+		return x.SupportUrl
 	}
-	return false
+	return ""
 }
 
-type GenerateConfigResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ConfigContent string                 `protobuf:"bytes,1,opt,name=config_content,json=configContent,proto3" json:"config_content,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *SubscriptionInfoResponse) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
 }
 
-func (x *GenerateConfigResponse) Reset() {
-	*x = GenerateConfigResponse{}
+type Profile struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Type           ProfileType            `protobuf:"varint,3,opt,name=type,proto3,enum=hiddifyrpc.ProfileType" json:"type,omitempty"`
+	Url            string                 `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`                                              // Remote url or local file path.
+	Content        string                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`                                      // Inline content for PROFILE_CONTENT.
+	UpdateInterval int32                  `protobuf:"varint,6,opt,name=update_interval,json=updateInterval,proto3" json:"update_interval,omitempty"` // Hours, 0 to use the provider value.
+	LastUpdate     int64                  `protobuf:"varint,7,opt,name=last_update,json=lastUpdate,proto3" json:"last_update,omitempty"`
+	Active         bool                   `protobuf:"varint,8,opt,name=active,proto3" json:"active,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Profile) Reset() {
+	*x = Profile{}
 	mi := &file_hiddify_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GenerateConfigResponse) String() string {
+func (x *Profile) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenerateConfigResponse) ProtoMessage() {}
+func (*Profile) ProtoMessage() {}
 
-func (x *GenerateConfigResponse) ProtoReflect() protoreflect.Message {
+func (x *Profile) ProtoReflect() protoreflect.Message {
 	mi := &file_hiddify_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1163,40 +1396,88 @@ func (x *GenerateConfigResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenerateConfigResponse.ProtoReflect.Descriptor instead.
-func (*GenerateConfigResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use Profile.ProtoReflect.Descriptor instead.
+func (*Profile) Descriptor() ([]byte, []int) {
 	return file_hiddify_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *GenerateConfigResponse) GetConfigContent() string {
+func (x *Profile) GetId() string {
 	if x != nil {
-		return x.ConfigContent
+		return x.Id
 	}
 	return ""
 }
 
-type SelectOutboundRequest struct {
+func (x *Profile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Profile) GetType() ProfileType {
+	if x != nil {
+		return x.Type
+	}
+	return ProfileType_PROFILE_LOCAL
+}
+
+func (x *Profile) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Profile) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Profile) GetUpdateInterval() int32 {
+	if x != nil {
+		return x.UpdateInterval
+	}
+	return 0
+}
+
+func (x *Profile) GetLastUpdate() int64 {
+	if x != nil {
+		return x.LastUpdate
+	}
+	return 0
+}
+
+func (x *Profile) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+type ProfileList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	GroupTag      string                 `protobuf:"bytes,1,opt,name=group_tag,json=groupTag,proto3" json:"group_tag,omitempty"`
-	OutboundTag   string                 `protobuf:"bytes,2,opt,name=outbound_tag,json=outboundTag,proto3" json:"outbound_tag,omitempty"`
+	Items         []*Profile             `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SelectOutboundRequest) Reset() {
-	*x = SelectOutboundRequest{}
+func (x *ProfileList) Reset() {
+	*x = ProfileList{}
 	mi := &file_hiddify_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SelectOutboundRequest) String() string {
+func (x *ProfileList) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SelectOutboundRequest) ProtoMessage() {}
+func (*ProfileList) ProtoMessage() {}
 
-func (x *SelectOutboundRequest) ProtoReflect() protoreflect.Message {
+func (x *ProfileList) ProtoReflect() protoreflect.Message {
 	mi := &file_hiddify_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1208,91 +1489,1077 @@ func (x *SelectOutboundRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SelectOutboundRequest.ProtoReflect.Descriptor instead.
-func (*SelectOutboundRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ProfileList.ProtoReflect.Descriptor instead.
+func (*ProfileList) Descriptor() ([]byte, []int) {
 	return file_hiddify_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *SelectOutboundRequest) GetGroupTag() string {
+func (x *ProfileList) GetItems() []*Profile {
 	if x != nil {
-		return x.GroupTag
+		return x.Items
+	}
+	return nil
+}
+
+type ProfileIdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProfileIdRequest) Reset() {
+	*x = ProfileIdRequest{}
+	mi := &file_hiddify_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProfileIdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProfileIdRequest) ProtoMessage() {}
+
+func (x *ProfileIdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProfileIdRequest.ProtoReflect.Descriptor instead.
+func (*ProfileIdRequest) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ProfileIdRequest) GetId() string {
+	if x != nil {
+		return x.Id
 	}
 	return ""
 }
 
-func (x *SelectOutboundRequest) GetOutboundTag() string {
+type ProfileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResponseCode  ResponseCode           `protobuf:"varint,1,opt,name=response_code,json=responseCode,proto3,enum=hiddifyrpc.ResponseCode" json:"response_code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Profile       *Profile               `protobuf:"bytes,3,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProfileResponse) Reset() {
+	*x = ProfileResponse{}
+	mi := &file_hiddify_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProfileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProfileResponse) ProtoMessage() {}
+
+func (x *ProfileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[17]
 	if x != nil {
-		return x.OutboundTag
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProfileResponse.ProtoReflect.Descriptor instead.
+func (*ProfileResponse) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ProfileResponse) GetResponseCode() ResponseCode {
+	if x != nil {
+		return x.ResponseCode
+	}
+	return ResponseCode_OK
+}
+
+func (x *ProfileResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
 	}
 	return ""
 }
 
-type UrlTestRequest struct {
+func (x *ProfileResponse) GetProfile() *Profile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+type ProfileRefreshEvent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	GroupTag      string                 `protobuf:"bytes,1,opt,name=group_tag,json=groupTag,proto3" json:"group_tag,omitempty"`
+	ProfileId     string                 `protobuf:"bytes,1,opt,name=profile_id,json=profileId,proto3" json:"profile_id,omitempty"`
+	Status        RefreshStatus          `protobuf:"varint,2,opt,name=status,proto3,enum=hiddifyrpc.RefreshStatus" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Time          int64                  `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
+	RetryAt       int64                  `protobuf:"varint,5,opt,name=retry_at,json=retryAt,proto3" json:"retry_at,omitempty"` // Set on failure, unix seconds of the next attempt.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UrlTestRequest) Reset() {
-	*x = UrlTestRequest{}
-	mi := &file_hiddify_proto_msgTypes[16]
+func (x *ProfileRefreshEvent) Reset() {
+	*x = ProfileRefreshEvent{}
+	mi := &file_hiddify_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProfileRefreshEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProfileRefreshEvent) ProtoMessage() {}
+
+func (x *ProfileRefreshEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProfileRefreshEvent.ProtoReflect.Descriptor instead.
+func (*ProfileRefreshEvent) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ProfileRefreshEvent) GetProfileId() string {
+	if x != nil {
+		return x.ProfileId
+	}
+	return ""
+}
+
+func (x *ProfileRefreshEvent) GetStatus() RefreshStatus {
+	if x != nil {
+		return x.Status
+	}
+	return RefreshStatus_REFRESH_SUCCESS
+}
+
+func (x *ProfileRefreshEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ProfileRefreshEvent) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *ProfileRefreshEvent) GetRetryAt() int64 {
+	if x != nil {
+		return x.RetryAt
+	}
+	return 0
+}
+
+type ChangeHiddifySettingsRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	HiddifySettingsJson string                 `protobuf:"bytes,1,opt,name=hiddify_settings_json,json=hiddifySettingsJson,proto3" json:"hiddify_settings_json,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ChangeHiddifySettingsRequest) Reset() {
+	*x = ChangeHiddifySettingsRequest{}
+	mi := &file_hiddify_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeHiddifySettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeHiddifySettingsRequest) ProtoMessage() {}
+
+func (x *ChangeHiddifySettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeHiddifySettingsRequest.ProtoReflect.Descriptor instead.
+func (*ChangeHiddifySettingsRequest) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ChangeHiddifySettingsRequest) GetHiddifySettingsJson() string {
+	if x != nil {
+		return x.HiddifySettingsJson
+	}
+	return ""
+}
+
+type HiddifySettingsResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	HiddifySettingsJson string                 `protobuf:"bytes,1,opt,name=hiddify_settings_json,json=hiddifySettingsJson,proto3" json:"hiddify_settings_json,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *HiddifySettingsResponse) Reset() {
+	*x = HiddifySettingsResponse{}
+	mi := &file_hiddify_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UrlTestRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *HiddifySettingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HiddifySettingsResponse) ProtoMessage() {}
+
+func (x *HiddifySettingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HiddifySettingsResponse.ProtoReflect.Descriptor instead.
+func (*HiddifySettingsResponse) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *HiddifySettingsResponse) GetHiddifySettingsJson() string {
+	if x != nil {
+		return x.HiddifySettingsJson
+	}
+	return ""
+}
+
+type GenerateConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	TempPath      string                 `protobuf:"bytes,2,opt,name=temp_path,json=tempPath,proto3" json:"temp_path,omitempty"`
+	Debug         bool                   `protobuf:"varint,3,opt,name=debug,proto3" json:"debug,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateConfigRequest) Reset() {
+	*x = GenerateConfigRequest{}
+	mi := &file_hiddify_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateConfigRequest) ProtoMessage() {}
+
+func (x *GenerateConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateConfigRequest.ProtoReflect.Descriptor instead.
+func (*GenerateConfigRequest) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GenerateConfigRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GenerateConfigRequest) GetTempPath() string {
+	if x != nil {
+		return x.TempPath
+	}
+	return ""
+}
+
+func (x *GenerateConfigRequest) GetDebug() bool {
+	if x != nil {
+		return x.Debug
+	}
+	return false
+}
+
+type GenerateConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConfigContent string                 `protobuf:"bytes,1,opt,name=config_content,json=configContent,proto3" json:"config_content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateConfigResponse) Reset() {
+	*x = GenerateConfigResponse{}
+	mi := &file_hiddify_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateConfigResponse) ProtoMessage() {}
+
+func (x *GenerateConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateConfigResponse.ProtoReflect.Descriptor instead.
+func (*GenerateConfigResponse) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GenerateConfigResponse) GetConfigContent() string {
+	if x != nil {
+		return x.ConfigContent
+	}
+	return ""
+}
+
+type SelectOutboundRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupTag      string                 `protobuf:"bytes,1,opt,name=group_tag,json=groupTag,proto3" json:"group_tag,omitempty"`
+	OutboundTag   string                 `protobuf:"bytes,2,opt,name=outbound_tag,json=outboundTag,proto3" json:"outbound_tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SelectOutboundRequest) Reset() {
+	*x = SelectOutboundRequest{}
+	mi := &file_hiddify_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelectOutboundRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelectOutboundRequest) ProtoMessage() {}
+
+func (x *SelectOutboundRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelectOutboundRequest.ProtoReflect.Descriptor instead.
+func (*SelectOutboundRequest) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SelectOutboundRequest) GetGroupTag() string {
+	if x != nil {
+		return x.GroupTag
+	}
+	return ""
+}
+
+func (x *SelectOutboundRequest) GetOutboundTag() string {
+	if x != nil {
+		return x.OutboundTag
+	}
+	return ""
+}
+
+type UrlTestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupTag      string                 `protobuf:"bytes,1,opt,name=group_tag,json=groupTag,proto3" json:"group_tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UrlTestRequest) Reset() {
+	*x = UrlTestRequest{}
+	mi := &file_hiddify_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UrlTestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UrlTestRequest) ProtoMessage() {}
+
+func (x *UrlTestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UrlTestRequest.ProtoReflect.Descriptor instead.
+func (*UrlTestRequest) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *UrlTestRequest) GetGroupTag() string {
+	if x != nil {
+		return x.GroupTag
+	}
+	return ""
+}
+
+type SetSystemProxyEnabledRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IsEnabled     bool                   `protobuf:"varint,1,opt,name=is_enabled,json=isEnabled,proto3" json:"is_enabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetSystemProxyEnabledRequest) Reset() {
+	*x = SetSystemProxyEnabledRequest{}
+	mi := &file_hiddify_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetSystemProxyEnabledRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetSystemProxyEnabledRequest) ProtoMessage() {}
+
+func (x *SetSystemProxyEnabledRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetSystemProxyEnabledRequest.ProtoReflect.Descriptor instead.
+func (*SetSystemProxyEnabledRequest) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SetSystemProxyEnabledRequest) GetIsEnabled() bool {
+	if x != nil {
+		return x.IsEnabled
+	}
+	return false
+}
+
+type ConfigCapabilityResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	SupportsTlsFragment bool                   `protobuf:"varint,1,opt,name=supports_tls_fragment,json=supportsTlsFragment,proto3" json:"supports_tls_fragment,omitempty"`
+	SupportsQuic        bool                   `protobuf:"varint,2,opt,name=supports_quic,json=supportsQuic,proto3" json:"supports_quic,omitempty"`
+	SupportsEch         bool                   `protobuf:"varint,3,opt,name=supports_ech,json=supportsEch,proto3" json:"supports_ech,omitempty"`
+	SchemaVersion       string                 `protobuf:"bytes,4,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ConfigCapabilityResponse) Reset() {
+	*x = ConfigCapabilityResponse{}
+	mi := &file_hiddify_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigCapabilityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigCapabilityResponse) ProtoMessage() {}
+
+func (x *ConfigCapabilityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigCapabilityResponse.ProtoReflect.Descriptor instead.
+func (*ConfigCapabilityResponse) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ConfigCapabilityResponse) GetSupportsTlsFragment() bool {
+	if x != nil {
+		return x.SupportsTlsFragment
+	}
+	return false
+}
+
+func (x *ConfigCapabilityResponse) GetSupportsQuic() bool {
+	if x != nil {
+		return x.SupportsQuic
+	}
+	return false
+}
+
+func (x *ConfigCapabilityResponse) GetSupportsEch() bool {
+	if x != nil {
+		return x.SupportsEch
+	}
+	return false
+}
+
+func (x *ConfigCapabilityResponse) GetSchemaVersion() string {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return ""
+}
+
+type LogMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         LogLevel               `protobuf:"varint,1,opt,name=level,proto3,enum=hiddifyrpc.LogLevel" json:"level,omitempty"`
+	Type          LogType                `protobuf:"varint,2,opt,name=type,proto3,enum=hiddifyrpc.LogType" json:"type,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogMessage) Reset() {
+	*x = LogMessage{}
+	mi := &file_hiddify_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogMessage) ProtoMessage() {}
+
+func (x *LogMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogMessage.ProtoReflect.Descriptor instead.
+func (*LogMessage) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *LogMessage) GetLevel() LogLevel {
+	if x != nil {
+		return x.Level
+	}
+	return LogLevel_DEBUG
+}
+
+func (x *LogMessage) GetType() LogType {
+	if x != nil {
+		return x.Type
+	}
+	return LogType_CORE
+}
+
+func (x *LogMessage) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type StopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	mi := &file_hiddify_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRequest) ProtoMessage() {}
+
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{28}
+}
+
+type AssetInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Available     bool                   `protobuf:"varint,3,opt,name=available,proto3" json:"available,omitempty"`
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	Size          int64                  `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	CheckedAt     int64                  `protobuf:"varint,7,opt,name=checked_at,json=checkedAt,proto3" json:"checked_at,omitempty"`
+	Error         string                 `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssetInfo) Reset() {
+	*x = AssetInfo{}
+	mi := &file_hiddify_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssetInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssetInfo) ProtoMessage() {}
+
+func (x *AssetInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssetInfo.ProtoReflect.Descriptor instead.
+func (*AssetInfo) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *AssetInfo) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *AssetInfo) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *AssetInfo) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *AssetInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *AssetInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *AssetInfo) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *AssetInfo) GetCheckedAt() int64 {
+	if x != nil {
+		return x.CheckedAt
+	}
+	return 0
+}
+
+func (x *AssetInfo) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type AssetList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResponseCode  ResponseCode           `protobuf:"varint,1,opt,name=response_code,json=responseCode,proto3,enum=hiddifyrpc.ResponseCode" json:"response_code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Items         []*AssetInfo           `protobuf:"bytes,3,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssetList) Reset() {
+	*x = AssetList{}
+	mi := &file_hiddify_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssetList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssetList) ProtoMessage() {}
+
+func (x *AssetList) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssetList.ProtoReflect.Descriptor instead.
+func (*AssetList) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *AssetList) GetResponseCode() ResponseCode {
+	if x != nil {
+		return x.ResponseCode
+	}
+	return ResponseCode_OK
+}
+
+func (x *AssetList) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AssetList) GetItems() []*AssetInfo {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type UpdateAssetsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []string               `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	Force         bool                   `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAssetsRequest) Reset() {
+	*x = UpdateAssetsRequest{}
+	mi := &file_hiddify_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAssetsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAssetsRequest) ProtoMessage() {}
+
+func (x *UpdateAssetsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAssetsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAssetsRequest) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *UpdateAssetsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *UpdateAssetsRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type RouteQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domain        string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Ip            string                 `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	Port          uint32                 `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	Network       string                 `protobuf:"bytes,4,opt,name=network,proto3" json:"network,omitempty"`
+	Protocol      string                 `protobuf:"bytes,5,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	ProcessName   string                 `protobuf:"bytes,6,opt,name=process_name,json=processName,proto3" json:"process_name,omitempty"`
+	ProcessPath   string                 `protobuf:"bytes,7,opt,name=process_path,json=processPath,proto3" json:"process_path,omitempty"`
+	PackageName   string                 `protobuf:"bytes,8,opt,name=package_name,json=packageName,proto3" json:"package_name,omitempty"`
+	Inbound       string                 `protobuf:"bytes,9,opt,name=inbound,proto3" json:"inbound,omitempty"`
+	SourceIp      string                 `protobuf:"bytes,10,opt,name=source_ip,json=sourceIp,proto3" json:"source_ip,omitempty"`
+	SourcePort    uint32                 `protobuf:"varint,11,opt,name=source_port,json=sourcePort,proto3" json:"source_port,omitempty"`
+	ClashMode     string                 `protobuf:"bytes,12,opt,name=clash_mode,json=clashMode,proto3" json:"clash_mode,omitempty"`
+	WifiSsid      string                 `protobuf:"bytes,13,opt,name=wifi_ssid,json=wifiSsid,proto3" json:"wifi_ssid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteQuery) Reset() {
+	*x = RouteQuery{}
+	mi := &file_hiddify_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteQuery) ProtoMessage() {}
+
+func (x *RouteQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteQuery.ProtoReflect.Descriptor instead.
+func (*RouteQuery) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *RouteQuery) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *RouteQuery) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *RouteQuery) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *RouteQuery) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *RouteQuery) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *RouteQuery) GetProcessName() string {
+	if x != nil {
+		return x.ProcessName
+	}
+	return ""
+}
+
+func (x *RouteQuery) GetProcessPath() string {
+	if x != nil {
+		return x.ProcessPath
+	}
+	return ""
+}
+
+func (x *RouteQuery) GetPackageName() string {
+	if x != nil {
+		return x.PackageName
+	}
+	return ""
+}
+
+func (x *RouteQuery) GetInbound() string {
+	if x != nil {
+		return x.Inbound
+	}
+	return ""
 }
 
-func (*UrlTestRequest) ProtoMessage() {}
+func (x *RouteQuery) GetSourceIp() string {
+	if x != nil {
+		return x.SourceIp
+	}
+	return ""
+}
 
-func (x *UrlTestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_hiddify_proto_msgTypes[16]
+func (x *RouteQuery) GetSourcePort() uint32 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.SourcePort
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use UrlTestRequest.ProtoReflect.Descriptor instead.
-func (*UrlTestRequest) Descriptor() ([]byte, []int) {
-	return file_hiddify_proto_rawDescGZIP(), []int{16}
+func (x *RouteQuery) GetClashMode() string {
+	if x != nil {
+		return x.ClashMode
+	}
+	return ""
 }
 
-func (x *UrlTestRequest) GetGroupTag() string {
+func (x *RouteQuery) GetWifiSsid() string {
 	if x != nil {
-		return x.GroupTag
+		return x.WifiSsid
 	}
 	return ""
 }
 
-type SetSystemProxyEnabledRequest struct {
+type RouteExplanation struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	IsEnabled     bool                   `protobuf:"varint,1,opt,name=is_enabled,json=isEnabled,proto3" json:"is_enabled,omitempty"`
+	ResponseCode  ResponseCode           `protobuf:"varint,1,opt,name=response_code,json=responseCode,proto3,enum=hiddifyrpc.ResponseCode" json:"response_code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	RuleIndex     int32                  `protobuf:"varint,3,opt,name=rule_index,json=ruleIndex,proto3" json:"rule_index,omitempty"` // -1 when the final outbound is used
+	Rule          string                 `protobuf:"bytes,4,opt,name=rule,proto3" json:"rule,omitempty"`
+	Action        string                 `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"`
+	Outbound      string                 `protobuf:"bytes,6,opt,name=outbound,proto3" json:"outbound,omitempty"`
+	DnsRuleIndex  int32                  `protobuf:"varint,7,opt,name=dns_rule_index,json=dnsRuleIndex,proto3" json:"dns_rule_index,omitempty"` // -1 when the final server is used
+	DnsRule       string                 `protobuf:"bytes,8,opt,name=dns_rule,json=dnsRule,proto3" json:"dns_rule,omitempty"`
+	DnsServer     string                 `protobuf:"bytes,9,opt,name=dns_server,json=dnsServer,proto3" json:"dns_server,omitempty"`
+	Notes         []string               `protobuf:"bytes,10,rep,name=notes,proto3" json:"notes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetSystemProxyEnabledRequest) Reset() {
-	*x = SetSystemProxyEnabledRequest{}
-	mi := &file_hiddify_proto_msgTypes[17]
+func (x *RouteExplanation) Reset() {
+	*x = RouteExplanation{}
+	mi := &file_hiddify_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetSystemProxyEnabledRequest) String() string {
+func (x *RouteExplanation) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetSystemProxyEnabledRequest) ProtoMessage() {}
+func (*RouteExplanation) ProtoMessage() {}
 
-func (x *SetSystemProxyEnabledRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_hiddify_proto_msgTypes[17]
+func (x *RouteExplanation) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1303,110 +2570,103 @@ func (x *SetSystemProxyEnabledRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetSystemProxyEnabledRequest.ProtoReflect.Descriptor instead.
-func (*SetSystemProxyEnabledRequest) Descriptor() ([]byte, []int) {
-	return file_hiddify_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use RouteExplanation.ProtoReflect.Descriptor instead.
+func (*RouteExplanation) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *SetSystemProxyEnabledRequest) GetIsEnabled() bool {
+func (x *RouteExplanation) GetResponseCode() ResponseCode {
 	if x != nil {
-		return x.IsEnabled
+		return x.ResponseCode
 	}
-	return false
+	return ResponseCode_OK
 }
 
-type ConfigCapabilityResponse struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	SupportsTlsFragment bool                   `protobuf:"varint,1,opt,name=supports_tls_fragment,json=supportsTlsFragment,proto3" json:"supports_tls_fragment,omitempty"`
-	SupportsQuic        bool                   `protobuf:"varint,2,opt,name=supports_quic,json=supportsQuic,proto3" json:"supports_quic,omitempty"`
-	SupportsEch         bool                   `protobuf:"varint,3,opt,name=supports_ech,json=supportsEch,proto3" json:"supports_ech,omitempty"`
-	SchemaVersion       string                 `protobuf:"bytes,4,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+func (x *RouteExplanation) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
 }
 
-func (x *ConfigCapabilityResponse) Reset() {
-	*x = ConfigCapabilityResponse{}
-	mi := &file_hiddify_proto_msgTypes[18]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *RouteExplanation) GetRuleIndex() int32 {
+	if x != nil {
+		return x.RuleIndex
+	}
+	return 0
 }
 
-func (x *ConfigCapabilityResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *RouteExplanation) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
 }
 
-func (*ConfigCapabilityResponse) ProtoMessage() {}
-
-func (x *ConfigCapabilityResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_hiddify_proto_msgTypes[18]
+func (x *RouteExplanation) GetAction() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Action
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use ConfigCapabilityResponse.ProtoReflect.Descriptor instead.
-func (*ConfigCapabilityResponse) Descriptor() ([]byte, []int) {
-	return file_hiddify_proto_rawDescGZIP(), []int{18}
+func (x *RouteExplanation) GetOutbound() string {
+	if x != nil {
+		return x.Outbound
+	}
+	return ""
 }
 
-func (x *ConfigCapabilityResponse) GetSupportsTlsFragment() bool {
+func (x *RouteExplanation) GetDnsRuleIndex() int32 {
 	if x != nil {
-		return x.SupportsTlsFragment
+		return x.DnsRuleIndex
 	}
-	return false
+	return 0
 }
 
-func (x *ConfigCapabilityResponse) GetSupportsQuic() bool {
+func (x *RouteExplanation) GetDnsRule() string {
 	if x != nil {
-		return x.SupportsQuic
+		return x.DnsRule
 	}
-	return false
+	return ""
 }
 
-func (x *ConfigCapabilityResponse) GetSupportsEch() bool {
+func (x *RouteExplanation) GetDnsServer() string {
 	if x != nil {
-		return x.SupportsEch
+		return x.DnsServer
 	}
-	return false
+	return ""
 }
 
-func (x *ConfigCapabilityResponse) GetSchemaVersion() string {
+func (x *RouteExplanation) GetNotes() []string {
 	if x != nil {
-		return x.SchemaVersion
+		return x.Notes
 	}
-	return ""
+	return nil
 }
 
-type LogMessage struct {
+type ClashModeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Level         LogLevel               `protobuf:"varint,1,opt,name=level,proto3,enum=hiddifyrpc.LogLevel" json:"level,omitempty"`
-	Type          LogType                `protobuf:"varint,2,opt,name=type,proto3,enum=hiddifyrpc.LogType" json:"type,omitempty"`
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Mode          string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogMessage) Reset() {
-	*x = LogMessage{}
-	mi := &file_hiddify_proto_msgTypes[19]
+func (x *ClashModeRequest) Reset() {
+	*x = ClashModeRequest{}
+	mi := &file_hiddify_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogMessage) String() string {
+func (x *ClashModeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogMessage) ProtoMessage() {}
+func (*ClashModeRequest) ProtoMessage() {}
 
-func (x *LogMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_hiddify_proto_msgTypes[19]
+func (x *ClashModeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1417,53 +2677,43 @@ func (x *LogMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogMessage.ProtoReflect.Descriptor instead.
-func (*LogMessage) Descriptor() ([]byte, []int) {
-	return file_hiddify_proto_rawDescGZIP(), []int{19}
-}
-
-func (x *LogMessage) GetLevel() LogLevel {
-	if x != nil {
-		return x.Level
-	}
-	return LogLevel_DEBUG
-}
-
-func (x *LogMessage) GetType() LogType {
-	if x != nil {
-		return x.Type
-	}
-	return LogType_CORE
+// Deprecated: Use ClashModeRequest.ProtoReflect.Descriptor instead.
+func (*ClashModeRequest) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *LogMessage) GetMessage() string {
+func (x *ClashModeRequest) GetMode() string {
 	if x != nil {
-		return x.Message
+		return x.Mode
 	}
 	return ""
 }
 
-type StopRequest struct {
+type ClashModeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResponseCode  ResponseCode           `protobuf:"varint,1,opt,name=response_code,json=responseCode,proto3,enum=hiddifyrpc.ResponseCode" json:"response_code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Mode          string                 `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	Modes         []string               `protobuf:"bytes,4,rep,name=modes,proto3" json:"modes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StopRequest) Reset() {
-	*x = StopRequest{}
-	mi := &file_hiddify_proto_msgTypes[20]
+func (x *ClashModeResponse) Reset() {
+	*x = ClashModeResponse{}
+	mi := &file_hiddify_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StopRequest) String() string {
+func (x *ClashModeResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StopRequest) ProtoMessage() {}
+func (*ClashModeResponse) ProtoMessage() {}
 
-func (x *StopRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_hiddify_proto_msgTypes[20]
+func (x *ClashModeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hiddify_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1474,9 +2724,37 @@ func (x *StopRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
-func (*StopRequest) Descriptor() ([]byte, []int) {
-	return file_hiddify_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use ClashModeResponse.ProtoReflect.Descriptor instead.
+func (*ClashModeResponse) Descriptor() ([]byte, []int) {
+	return file_hiddify_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ClashModeResponse) GetResponseCode() ResponseCode {
+	if x != nil {
+		return x.ResponseCode
+	}
+	return ResponseCode_OK
+}
+
+func (x *ClashModeResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ClashModeResponse) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *ClashModeResponse) GetModes() []string {
+	if x != nil {
+		return x.Modes
+	}
+	return nil
 }
 
 type TunnelStartRequest struct {
@@ -1486,13 +2764,28 @@ type TunnelStartRequest struct {
 	StrictRoute            bool                   `protobuf:"varint,3,opt,name=strict_route,json=strictRoute,proto3" json:"strict_route,omitempty"`
 	EndpointIndependentNat bool                   `protobuf:"varint,4,opt,name=endpoint_independent_nat,json=endpointIndependentNat,proto3" json:"endpoint_independent_nat,omitempty"`
 	Stack                  string                 `protobuf:"bytes,5,opt,name=stack,proto3" json:"stack,omitempty"`
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+	// per-app proxy of the desktop tunnel, see config.PerAppProxyOptions
+	PerAppMode   string   `protobuf:"bytes,6,opt,name=per_app_mode,json=perAppMode,proto3" json:"per_app_mode,omitempty"`
+	ProcessNames []string `protobuf:"bytes,7,rep,name=process_names,json=processNames,proto3" json:"process_names,omitempty"`
+	ProcessPaths []string `protobuf:"bytes,8,rep,name=process_paths,json=processPaths,proto3" json:"process_paths,omitempty"`
+	// TUN addressing of the desktop tunnel, see config.InboundOptions
+	InterfaceName       string   `protobuf:"bytes,9,opt,name=interface_name,json=interfaceName,proto3" json:"interface_name,omitempty"`
+	Inet4Address        string   `protobuf:"bytes,10,opt,name=inet4_address,json=inet4Address,proto3" json:"inet4_address,omitempty"`
+	Inet6Address        string   `protobuf:"bytes,11,opt,name=inet6_address,json=inet6Address,proto3" json:"inet6_address,omitempty"`
+	RouteAddress        []string `protobuf:"bytes,12,rep,name=route_address,json=routeAddress,proto3" json:"route_address,omitempty"`
+	RouteExcludeAddress []string `protobuf:"bytes,13,rep,name=route_exclude_address,json=routeExcludeAddress,proto3" json:"route_exclude_address,omitempty"`
+	AutoRedirect        bool     `protobuf:"varint,14,opt,name=auto_redirect,json=autoRedirect,proto3" json:"auto_redirect,omitempty"`
+	Mtu                 uint32   `protobuf:"varint,15,opt,name=mtu,proto3" json:"mtu,omitempty"`
+	// credentials of the mixed inbound at server_port, if it requires them
+	ProxyUsername string `protobuf:"bytes,16,opt,name=proxy_username,json=proxyUsername,proto3" json:"proxy_username,omitempty"`
+	ProxyPassword string `protobuf:"bytes,17,opt,name=proxy_password,json=proxyPassword,proto3" json:"proxy_password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TunnelStartRequest) Reset() {
 	*x = TunnelStartRequest{}
-	mi := &file_hiddify_proto_msgTypes[21]
+	mi := &file_hiddify_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1504,7 +2797,7 @@ func (x *TunnelStartRequest) String() string {
 func (*TunnelStartRequest) ProtoMessage() {}
 
 func (x *TunnelStartRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_hiddify_proto_msgTypes[21]
+	mi := &file_hiddify_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1517,7 +2810,7 @@ func (x *TunnelStartRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TunnelStartRequest.ProtoReflect.Descriptor instead.
 func (*TunnelStartRequest) Descriptor() ([]byte, []int) {
-	return file_hiddify_proto_rawDescGZIP(), []int{21}
+	return file_hiddify_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *TunnelStartRequest) GetIpv6() bool {
@@ -1555,6 +2848,90 @@ func (x *TunnelStartRequest) GetStack() string {
 	return ""
 }
 
+func (x *TunnelStartRequest) GetPerAppMode() string {
+	if x != nil {
+		return x.PerAppMode
+	}
+	return ""
+}
+
+func (x *TunnelStartRequest) GetProcessNames() []string {
+	if x != nil {
+		return x.ProcessNames
+	}
+	return nil
+}
+
+func (x *TunnelStartRequest) GetProcessPaths() []string {
+	if x != nil {
+		return x.ProcessPaths
+	}
+	return nil
+}
+
+func (x *TunnelStartRequest) GetInterfaceName() string {
+	if x != nil {
+		return x.InterfaceName
+	}
+	return ""
+}
+
+func (x *TunnelStartRequest) GetInet4Address() string {
+	if x != nil {
+		return x.Inet4Address
+	}
+	return ""
+}
+
+func (x *TunnelStartRequest) GetInet6Address() string {
+	if x != nil {
+		return x.Inet6Address
+	}
+	return ""
+}
+
+func (x *TunnelStartRequest) GetRouteAddress() []string {
+	if x != nil {
+		return x.RouteAddress
+	}
+	return nil
+}
+
+func (x *TunnelStartRequest) GetRouteExcludeAddress() []string {
+	if x != nil {
+		return x.RouteExcludeAddress
+	}
+	return nil
+}
+
+func (x *TunnelStartRequest) GetAutoRedirect() bool {
+	if x != nil {
+		return x.AutoRedirect
+	}
+	return false
+}
+
+func (x *TunnelStartRequest) GetMtu() uint32 {
+	if x != nil {
+		return x.Mtu
+	}
+	return 0
+}
+
+func (x *TunnelStartRequest) GetProxyUsername() string {
+	if x != nil {
+		return x.ProxyUsername
+	}
+	return ""
+}
+
+func (x *TunnelStartRequest) GetProxyPassword() string {
+	if x != nil {
+		return x.ProxyPassword
+	}
+	return ""
+}
+
 type TunnelResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
@@ -1564,7 +2941,7 @@ type TunnelResponse struct {
 
 func (x *TunnelResponse) Reset() {
 	*x = TunnelResponse{}
-	mi := &file_hiddify_proto_msgTypes[22]
+	mi := &file_hiddify_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1576,7 +2953,7 @@ func (x *TunnelResponse) String() string {
 func (*TunnelResponse) ProtoMessage() {}
 
 func (x *TunnelResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_hiddify_proto_msgTypes[22]
+	mi := &file_hiddify_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1589,7 +2966,7 @@ func (x *TunnelResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TunnelResponse.ProtoReflect.Descriptor instead.
 func (*TunnelResponse) Descriptor() ([]byte, []int) {
-	return file_hiddify_proto_rawDescGZIP(), []int{22}
+	return file_hiddify_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *TunnelResponse) GetMessage() string {
@@ -1610,7 +2987,7 @@ const file_hiddify_proto_rawDesc = "" +
 	"\n" +
 	"core_state\x18\x01 \x01(\x0e2\x15.hiddifyrpc.CoreStateR\tcoreState\x12:\n" +
 	"\fmessage_type\x18\x02 \x01(\x0e2\x17.hiddifyrpc.MessageTypeR\vmessageType\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"\x90\x02\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\xaf\x02\n" +
 	"\fStartRequest\x12\x1f\n" +
 	"\vconfig_path\x18\x01 \x01(\tR\n" +
 	"configPath\x12%\n" +
@@ -1619,7 +2996,9 @@ const file_hiddify_proto_rawDesc = "" +
 	"\vdelay_start\x18\x04 \x01(\bR\n" +
 	"delayStart\x129\n" +
 	"\x19enable_old_command_server\x18\x05 \x01(\bR\x16enableOldCommandServer\x12*\n" +
-	"\x11enable_raw_config\x18\x06 \x01(\bR\x0fenableRawConfig\"k\n" +
+	"\x11enable_raw_config\x18\x06 \x01(\bR\x0fenableRawConfig\x12\x1d\n" +
+	"\n" +
+	"profile_id\x18\a \x01(\tR\tprofileId\"k\n" +
 	"\fSetupRequest\x12\x1b\n" +
 	"\tbase_path\x18\x01 \x01(\tR\bbasePath\x12!\n" +
 	"\fworking_path\x18\x02 \x01(\tR\vworkingPath\x12\x1b\n" +
@@ -1654,17 +3033,69 @@ const file_hiddify_proto_rawDesc = "" +
 	"\x05items\x18\x01 \x03(\v2\x19.hiddifyrpc.OutboundGroupR\x05items\"K\n" +
 	"\x11SystemProxyStatus\x12\x1c\n" +
 	"\tavailable\x18\x01 \x01(\bR\tavailable\x12\x18\n" +
-	"\aenabled\x18\x02 \x01(\bR\aenabled\"|\n" +
+	"\aenabled\x18\x02 \x01(\bR\aenabled\"\xa1\x01\n" +
 	"\fParseRequest\x12\x18\n" +
 	"\acontent\x18\x01 \x01(\tR\acontent\x12\x1f\n" +
 	"\vconfig_path\x18\x02 \x01(\tR\n" +
 	"configPath\x12\x1b\n" +
 	"\ttemp_path\x18\x03 \x01(\tR\btempPath\x12\x14\n" +
-	"\x05debug\x18\x04 \x01(\bR\x05debug\"\x82\x01\n" +
+	"\x05debug\x18\x04 \x01(\bR\x05debug\x12#\n" +
+	"\rallow_partial\x18\x05 \x01(\bR\fallowPartial\"{\n" +
+	"\x0fParseDiagnostic\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x14\n" +
+	"\x05index\x18\x04 \x01(\x05R\x05index\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"\xf3\x01\n" +
 	"\rParseResponse\x12=\n" +
 	"\rresponse_code\x18\x01 \x01(\x0e2\x18.hiddifyrpc.ResponseCodeR\fresponseCode\x12\x18\n" +
 	"\acontent\x18\x02 \x01(\tR\acontent\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"R\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x14\n" +
+	"\x05total\x18\x04 \x01(\x05R\x05total\x12\x1a\n" +
+	"\baccepted\x18\x05 \x01(\x05R\baccepted\x12=\n" +
+	"\vdiagnostics\x18\x06 \x03(\v2\x1b.hiddifyrpc.ParseDiagnosticR\vdiagnostics\":\n" +
+	"\x17SubscriptionInfoRequest\x12\x1f\n" +
+	"\vconfig_path\x18\x01 \x01(\tR\n" +
+	"configPath\"\xc9\x02\n" +
+	"\x18SubscriptionInfoResponse\x12=\n" +
+	"\rresponse_code\x18\x01 \x01(\x0e2\x18.hiddifyrpc.ResponseCodeR\fresponseCode\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x16\n" +
+	"\x06upload\x18\x03 \x01(\x03R\x06upload\x12\x1a\n" +
+	"\bdownload\x18\x04 \x01(\x03R\bdownload\x12\x14\n" +
+	"\x05total\x18\x05 \x01(\x03R\x05total\x12\x1c\n" +
+	"\tremaining\x18\x06 \x01(\x03R\tremaining\x12\x16\n" +
+	"\x06expire\x18\a \x01(\x03R\x06expire\x12\x14\n" +
+	"\x05title\x18\b \x01(\tR\x05title\x12\x1f\n" +
+	"\vsupport_url\x18\t \x01(\tR\n" +
+	"supportUrl\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\x03R\tupdatedAt\"\xe8\x01\n" +
+	"\aProfile\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12+\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x17.hiddifyrpc.ProfileTypeR\x04type\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\tR\acontent\x12'\n" +
+	"\x0fupdate_interval\x18\x06 \x01(\x05R\x0eupdateInterval\x12\x1f\n" +
+	"\vlast_update\x18\a \x01(\x03R\n" +
+	"lastUpdate\x12\x16\n" +
+	"\x06active\x18\b \x01(\bR\x06active\"8\n" +
+	"\vProfileList\x12)\n" +
+	"\x05items\x18\x01 \x03(\v2\x13.hiddifyrpc.ProfileR\x05items\"\"\n" +
+	"\x10ProfileIdRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x99\x01\n" +
+	"\x0fProfileResponse\x12=\n" +
+	"\rresponse_code\x18\x01 \x01(\x0e2\x18.hiddifyrpc.ResponseCodeR\fresponseCode\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12-\n" +
+	"\aprofile\x18\x03 \x01(\v2\x13.hiddifyrpc.ProfileR\aprofile\"\xb0\x01\n" +
+	"\x13ProfileRefreshEvent\x12\x1d\n" +
+	"\n" +
+	"profile_id\x18\x01 \x01(\tR\tprofileId\x121\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x19.hiddifyrpc.RefreshStatusR\x06status\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x12\n" +
+	"\x04time\x18\x04 \x01(\x03R\x04time\x12\x19\n" +
+	"\bretry_at\x18\x05 \x01(\x03R\aretryAt\"R\n" +
 	"\x1cChangeHiddifySettingsRequest\x122\n" +
 	"\x15hiddify_settings_json\x18\x01 \x01(\tR\x13hiddifySettingsJson\"M\n" +
 	"\x17HiddifySettingsResponse\x122\n" +
@@ -1693,14 +3124,85 @@ const file_hiddify_proto_rawDesc = "" +
 	"\x05level\x18\x01 \x01(\x0e2\x14.hiddifyrpc.LogLevelR\x05level\x12'\n" +
 	"\x04type\x18\x02 \x01(\x0e2\x13.hiddifyrpc.LogTypeR\x04type\x12\x18\n" +
 	"\amessage\x18\x03 \x01(\tR\amessage\"\r\n" +
-	"\vStopRequest\"\xbc\x01\n" +
+	"\vStopRequest\"\xcf\x01\n" +
+	"\tAssetInfo\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x1c\n" +
+	"\tavailable\x18\x03 \x01(\bR\tavailable\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\x03R\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"checked_at\x18\a \x01(\x03R\tcheckedAt\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\"\x91\x01\n" +
+	"\tAssetList\x12=\n" +
+	"\rresponse_code\x18\x01 \x01(\x0e2\x18.hiddifyrpc.ResponseCodeR\fresponseCode\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12+\n" +
+	"\x05items\x18\x03 \x03(\v2\x15.hiddifyrpc.AssetInfoR\x05items\"?\n" +
+	"\x13UpdateAssetsRequest\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tags\x12\x14\n" +
+	"\x05force\x18\x02 \x01(\bR\x05force\"\xfb\x02\n" +
+	"\n" +
+	"RouteQuery\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\x12\x12\n" +
+	"\x04port\x18\x03 \x01(\rR\x04port\x12\x18\n" +
+	"\anetwork\x18\x04 \x01(\tR\anetwork\x12\x1a\n" +
+	"\bprotocol\x18\x05 \x01(\tR\bprotocol\x12!\n" +
+	"\fprocess_name\x18\x06 \x01(\tR\vprocessName\x12!\n" +
+	"\fprocess_path\x18\a \x01(\tR\vprocessPath\x12!\n" +
+	"\fpackage_name\x18\b \x01(\tR\vpackageName\x12\x18\n" +
+	"\ainbound\x18\t \x01(\tR\ainbound\x12\x1b\n" +
+	"\tsource_ip\x18\n" +
+	" \x01(\tR\bsourceIp\x12\x1f\n" +
+	"\vsource_port\x18\v \x01(\rR\n" +
+	"sourcePort\x12\x1d\n" +
+	"\n" +
+	"clash_mode\x18\f \x01(\tR\tclashMode\x12\x1b\n" +
+	"\twifi_ssid\x18\r \x01(\tR\bwifiSsid\"\xc8\x02\n" +
+	"\x10RouteExplanation\x12=\n" +
+	"\rresponse_code\x18\x01 \x01(\x0e2\x18.hiddifyrpc.ResponseCodeR\fresponseCode\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"rule_index\x18\x03 \x01(\x05R\truleIndex\x12\x12\n" +
+	"\x04rule\x18\x04 \x01(\tR\x04rule\x12\x16\n" +
+	"\x06action\x18\x05 \x01(\tR\x06action\x12\x1a\n" +
+	"\boutbound\x18\x06 \x01(\tR\boutbound\x12$\n" +
+	"\x0edns_rule_index\x18\a \x01(\x05R\fdnsRuleIndex\x12\x19\n" +
+	"\bdns_rule\x18\b \x01(\tR\adnsRule\x12\x1d\n" +
+	"\n" +
+	"dns_server\x18\t \x01(\tR\tdnsServer\x12\x14\n" +
+	"\x05notes\x18\n" +
+	" \x03(\tR\x05notes\"&\n" +
+	"\x10ClashModeRequest\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\"\x96\x01\n" +
+	"\x11ClashModeResponse\x12=\n" +
+	"\rresponse_code\x18\x01 \x01(\x0e2\x18.hiddifyrpc.ResponseCodeR\fresponseCode\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\tR\x04mode\x12\x14\n" +
+	"\x05modes\x18\x04 \x03(\tR\x05modes\"\xf7\x04\n" +
 	"\x12TunnelStartRequest\x12\x12\n" +
 	"\x04ipv6\x18\x01 \x01(\bR\x04ipv6\x12\x1f\n" +
 	"\vserver_port\x18\x02 \x01(\x05R\n" +
 	"serverPort\x12!\n" +
 	"\fstrict_route\x18\x03 \x01(\bR\vstrictRoute\x128\n" +
 	"\x18endpoint_independent_nat\x18\x04 \x01(\bR\x16endpointIndependentNat\x12\x14\n" +
-	"\x05stack\x18\x05 \x01(\tR\x05stack\"*\n" +
+	"\x05stack\x18\x05 \x01(\tR\x05stack\x12 \n" +
+	"\fper_app_mode\x18\x06 \x01(\tR\n" +
+	"perAppMode\x12#\n" +
+	"\rprocess_names\x18\a \x03(\tR\fprocessNames\x12#\n" +
+	"\rprocess_paths\x18\b \x03(\tR\fprocessPaths\x12%\n" +
+	"\x0einterface_name\x18\t \x01(\tR\rinterfaceName\x12#\n" +
+	"\rinet4_address\x18\n" +
+	" \x01(\tR\finet4Address\x12#\n" +
+	"\rinet6_address\x18\v \x01(\tR\finet6Address\x12#\n" +
+	"\rroute_address\x18\f \x03(\tR\frouteAddress\x122\n" +
+	"\x15route_exclude_address\x18\r \x03(\tR\x13routeExcludeAddress\x12#\n" +
+	"\rauto_redirect\x18\x0e \x01(\bR\fautoRedirect\x12\x10\n" +
+	"\x03mtu\x18\x0f \x01(\rR\x03mtu\x12%\n" +
+	"\x0eproxy_username\x18\x10 \x01(\tR\rproxyUsername\x12%\n" +
+	"\x0eproxy_password\x18\x11 \x01(\tR\rproxyPassword\"*\n" +
 	"\x0eTunnelResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage*A\n" +
 	"\tCoreState\x12\v\n" +
@@ -1723,7 +3225,15 @@ const file_hiddify_proto_rawDesc = "" +
 	"\x12\x19\n" +
 	"\x15ERROR_BUILDING_CONFIG\x10\v\x12\x18\n" +
 	"\x14ERROR_PARSING_CONFIG\x10\f\x12\x18\n" +
-	"\x14ERROR_READING_CONFIG\x10\r*B\n" +
+	"\x14ERROR_READING_CONFIG\x10\r*I\n" +
+	"\vProfileType\x12\x11\n" +
+	"\rPROFILE_LOCAL\x10\x00\x12\x12\n" +
+	"\x0ePROFILE_REMOTE\x10\x01\x12\x13\n" +
+	"\x0fPROFILE_CONTENT\x10\x02*O\n" +
+	"\rRefreshStatus\x12\x13\n" +
+	"\x0fREFRESH_SUCCESS\x10\x00\x12\x15\n" +
+	"\x11REFRESH_UNCHANGED\x10\x01\x12\x12\n" +
+	"\x0eREFRESH_FAILED\x10\x02*B\n" +
 	"\bLogLevel\x12\t\n" +
 	"\x05DEBUG\x10\x00\x12\b\n" +
 	"\x04INFO\x10\x01\x12\v\n" +
@@ -1737,7 +3247,7 @@ const file_hiddify_proto_rawDesc = "" +
 	"\x06CONFIG\x10\x022\x93\x01\n" +
 	"\x05Hello\x12?\n" +
 	"\bSayHello\x12\x18.hiddifyrpc.HelloRequest\x1a\x19.hiddifyrpc.HelloResponse\x12I\n" +
-	"\x0eSayHelloStream\x12\x18.hiddifyrpc.HelloRequest\x1a\x19.hiddifyrpc.HelloResponse(\x010\x012\xfd\t\n" +
+	"\x0eSayHelloStream\x12\x18.hiddifyrpc.HelloRequest\x1a\x19.hiddifyrpc.HelloResponse(\x010\x012\x8f\x11\n" +
 	"\x04Core\x12?\n" +
 	"\x05Start\x12\x18.hiddifyrpc.StartRequest\x1a\x1c.hiddifyrpc.CoreInfoResponse\x12E\n" +
 	"\x10CoreInfoListener\x12\x11.hiddifyrpc.Empty\x1a\x1c.hiddifyrpc.CoreInfoResponse0\x01\x12C\n" +
@@ -1756,7 +3266,21 @@ const file_hiddify_proto_rawDesc = "" +
 	"\x14GetSystemProxyStatus\x12\x11.hiddifyrpc.Empty\x1a\x1d.hiddifyrpc.SystemProxyStatus\x12W\n" +
 	"\x15SetSystemProxyEnabled\x12(.hiddifyrpc.SetSystemProxyEnabledRequest\x1a\x14.hiddifyrpc.Response\x12P\n" +
 	"\x15GetConfigCapabilities\x12\x11.hiddifyrpc.Empty\x1a$.hiddifyrpc.ConfigCapabilityResponse\x12:\n" +
-	"\vLogListener\x12\x11.hiddifyrpc.Empty\x1a\x16.hiddifyrpc.LogMessage0\x012\xfb\x01\n" +
+	"\vLogListener\x12\x11.hiddifyrpc.Empty\x1a\x16.hiddifyrpc.LogMessage0\x01\x12`\n" +
+	"\x13GetSubscriptionInfo\x12#.hiddifyrpc.SubscriptionInfoRequest\x1a$.hiddifyrpc.SubscriptionInfoResponse\x12>\n" +
+	"\n" +
+	"AddProfile\x12\x13.hiddifyrpc.Profile\x1a\x1b.hiddifyrpc.ProfileResponse\x12:\n" +
+	"\fListProfiles\x12\x11.hiddifyrpc.Empty\x1a\x17.hiddifyrpc.ProfileList\x12A\n" +
+	"\rUpdateProfile\x12\x13.hiddifyrpc.Profile\x1a\x1b.hiddifyrpc.ProfileResponse\x12C\n" +
+	"\rDeleteProfile\x12\x1c.hiddifyrpc.ProfileIdRequest\x1a\x14.hiddifyrpc.Response\x12F\n" +
+	"\x10SetActiveProfile\x12\x1c.hiddifyrpc.ProfileIdRequest\x1a\x14.hiddifyrpc.Response\x12N\n" +
+	"\x16ProfileRefreshListener\x12\x11.hiddifyrpc.Empty\x1a\x1f.hiddifyrpc.ProfileRefreshEvent0\x01\x12;\n" +
+	"\aGetMode\x12\x11.hiddifyrpc.Empty\x1a\x1d.hiddifyrpc.ClashModeResponse\x12F\n" +
+	"\aSetMode\x12\x1c.hiddifyrpc.ClashModeRequest\x1a\x1d.hiddifyrpc.ClashModeResponse\x12B\n" +
+	"\fModeListener\x12\x11.hiddifyrpc.Empty\x1a\x1d.hiddifyrpc.ClashModeResponse0\x01\x12;\n" +
+	"\x0fGetAssetsStatus\x12\x11.hiddifyrpc.Empty\x1a\x15.hiddifyrpc.AssetList\x12F\n" +
+	"\fUpdateAssets\x12\x1f.hiddifyrpc.UpdateAssetsRequest\x1a\x15.hiddifyrpc.AssetList\x12D\n" +
+	"\fExplainRoute\x12\x16.hiddifyrpc.RouteQuery\x1a\x1c.hiddifyrpc.RouteExplanation2\xfb\x01\n" +
 	"\rTunnelService\x12C\n" +
 	"\x05Start\x12\x1e.hiddifyrpc.TunnelStartRequest\x1a\x1a.hiddifyrpc.TunnelResponse\x125\n" +
 	"\x04Stop\x12\x11.hiddifyrpc.Empty\x1a\x1a.hiddifyrpc.TunnelResponse\x127\n" +
@@ -1775,103 +3299,157 @@ func file_hiddify_proto_rawDescGZIP() []byte {
 	return file_hiddify_proto_rawDescData
 }
 
-var file_hiddify_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_hiddify_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_hiddify_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_hiddify_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_hiddify_proto_goTypes = []any{
 	(CoreState)(0),                       // 0: hiddifyrpc.CoreState
 	(MessageType)(0),                     // 1: hiddifyrpc.MessageType
-	(LogLevel)(0),                        // 2: hiddifyrpc.LogLevel
-	(LogType)(0),                         // 3: hiddifyrpc.LogType
-	(*CoreInfoResponse)(nil),             // 4: hiddifyrpc.CoreInfoResponse
-	(*StartRequest)(nil),                 // 5: hiddifyrpc.StartRequest
-	(*SetupRequest)(nil),                 // 6: hiddifyrpc.SetupRequest
-	(*Response)(nil),                     // 7: hiddifyrpc.Response
-	(*SystemInfo)(nil),                   // 8: hiddifyrpc.SystemInfo
-	(*OutboundGroupItem)(nil),            // 9: hiddifyrpc.OutboundGroupItem
-	(*OutboundGroup)(nil),                // 10: hiddifyrpc.OutboundGroup
-	(*OutboundGroupList)(nil),            // 11: hiddifyrpc.OutboundGroupList
-	(*SystemProxyStatus)(nil),            // 12: hiddifyrpc.SystemProxyStatus
-	(*ParseRequest)(nil),                 // 13: hiddifyrpc.ParseRequest
-	(*ParseResponse)(nil),                // 14: hiddifyrpc.ParseResponse
-	(*ChangeHiddifySettingsRequest)(nil), // 15: hiddifyrpc.ChangeHiddifySettingsRequest
-	(*HiddifySettingsResponse)(nil),      // 16: hiddifyrpc.HiddifySettingsResponse
-	(*GenerateConfigRequest)(nil),        // 17: hiddifyrpc.GenerateConfigRequest
-	(*GenerateConfigResponse)(nil),       // 18: hiddifyrpc.GenerateConfigResponse
-	(*SelectOutboundRequest)(nil),        // 19: hiddifyrpc.SelectOutboundRequest
-	(*UrlTestRequest)(nil),               // 20: hiddifyrpc.UrlTestRequest
-	(*SetSystemProxyEnabledRequest)(nil), // 21: hiddifyrpc.SetSystemProxyEnabledRequest
-	(*ConfigCapabilityResponse)(nil),     // 22: hiddifyrpc.ConfigCapabilityResponse
-	(*LogMessage)(nil),                   // 23: hiddifyrpc.LogMessage
-	(*StopRequest)(nil),                  // 24: hiddifyrpc.StopRequest
-	(*TunnelStartRequest)(nil),           // 25: hiddifyrpc.TunnelStartRequest
-	(*TunnelResponse)(nil),               // 26: hiddifyrpc.TunnelResponse
-	(ResponseCode)(0),                    // 27: hiddifyrpc.ResponseCode
-	(*HelloRequest)(nil),                 // 28: hiddifyrpc.HelloRequest
-	(*Empty)(nil),                        // 29: hiddifyrpc.Empty
-	(*HelloResponse)(nil),                // 30: hiddifyrpc.HelloResponse
+	(ProfileType)(0),                     // 2: hiddifyrpc.ProfileType
+	(RefreshStatus)(0),                   // 3: hiddifyrpc.RefreshStatus
+	(LogLevel)(0),                        // 4: hiddifyrpc.LogLevel
+	(LogType)(0),                         // 5: hiddifyrpc.LogType
+	(*CoreInfoResponse)(nil),             // 6: hiddifyrpc.CoreInfoResponse
+	(*StartRequest)(nil),                 // 7: hiddifyrpc.StartRequest
+	(*SetupRequest)(nil),                 // 8: hiddifyrpc.SetupRequest
+	(*Response)(nil),                     // 9: hiddifyrpc.Response
+	(*SystemInfo)(nil),                   // 10: hiddifyrpc.SystemInfo
+	(*OutboundGroupItem)(nil),            // 11: hiddifyrpc.OutboundGroupItem
+	(*OutboundGroup)(nil),                // 12: hiddifyrpc.OutboundGroup
+	(*OutboundGroupList)(nil),            // 13: hiddifyrpc.OutboundGroupList
+	(*SystemProxyStatus)(nil),            // 14: hiddifyrpc.SystemProxyStatus
+	(*ParseRequest)(nil),                 // 15: hiddifyrpc.ParseRequest
+	(*ParseDiagnostic)(nil),              // 16: hiddifyrpc.ParseDiagnostic
+	(*ParseResponse)(nil),                // 17: hiddifyrpc.ParseResponse
+	(*SubscriptionInfoRequest)(nil),      // 18: hiddifyrpc.SubscriptionInfoRequest
+	(*SubscriptionInfoResponse)(nil),     // 19: hiddifyrpc.SubscriptionInfoResponse
+	(*Profile)(nil),                      // 20: hiddifyrpc.Profile
+	(*ProfileList)(nil),                  // 21: hiddifyrpc.ProfileList
+	(*ProfileIdRequest)(nil),             // 22: hiddifyrpc.ProfileIdRequest
+	(*ProfileResponse)(nil),              // 23: hiddifyrpc.ProfileResponse
+	(*ProfileRefreshEvent)(nil),          // 24: hiddifyrpc.ProfileRefreshEvent
+	(*ChangeHiddifySettingsRequest)(nil), // 25: hiddifyrpc.ChangeHiddifySettingsRequest
+	(*HiddifySettingsResponse)(nil),      // 26: hiddifyrpc.HiddifySettingsResponse
+	(*GenerateConfigRequest)(nil),        // 27: hiddifyrpc.GenerateConfigRequest
+	(*GenerateConfigResponse)(nil),       // 28: hiddifyrpc.GenerateConfigResponse
+	(*SelectOutboundRequest)(nil),        // 29: hiddifyrpc.SelectOutboundRequest
+	(*UrlTestRequest)(nil),               // 30: hiddifyrpc.UrlTestRequest
+	(*SetSystemProxyEnabledRequest)(nil), // 31: hiddifyrpc.SetSystemProxyEnabledRequest
+	(*ConfigCapabilityResponse)(nil),     // 32: hiddifyrpc.ConfigCapabilityResponse
+	(*LogMessage)(nil),                   // 33: hiddifyrpc.LogMessage
+	(*StopRequest)(nil),                  // 34: hiddifyrpc.StopRequest
+	(*AssetInfo)(nil),                    // 35: hiddifyrpc.AssetInfo
+	(*AssetList)(nil),                    // 36: hiddifyrpc.AssetList
+	(*UpdateAssetsRequest)(nil),          // 37: hiddifyrpc.UpdateAssetsRequest
+	(*RouteQuery)(nil),                   // 38: hiddifyrpc.RouteQuery
+	(*RouteExplanation)(nil),             // 39: hiddifyrpc.RouteExplanation
+	(*ClashModeRequest)(nil),             // 40: hiddifyrpc.ClashModeRequest
+	(*ClashModeResponse)(nil),            // 41: hiddifyrpc.ClashModeResponse
+	(*TunnelStartRequest)(nil),           // 42: hiddifyrpc.TunnelStartRequest
+	(*TunnelResponse)(nil),               // 43: hiddifyrpc.TunnelResponse
+	(ResponseCode)(0),                    // 44: hiddifyrpc.ResponseCode
+	(*HelloRequest)(nil),                 // 45: hiddifyrpc.HelloRequest
+	(*Empty)(nil),                        // 46: hiddifyrpc.Empty
+	(*HelloResponse)(nil),                // 47: hiddifyrpc.HelloResponse
 }
 var file_hiddify_proto_depIdxs = []int32{
 	0,  // 0: hiddifyrpc.CoreInfoResponse.core_state:type_name -> hiddifyrpc.CoreState
 	1,  // 1: hiddifyrpc.CoreInfoResponse.message_type:type_name -> hiddifyrpc.MessageType
-	27, // 2: hiddifyrpc.Response.response_code:type_name -> hiddifyrpc.ResponseCode
-	9,  // 3: hiddifyrpc.OutboundGroup.items:type_name -> hiddifyrpc.OutboundGroupItem
-	10, // 4: hiddifyrpc.OutboundGroupList.items:type_name -> hiddifyrpc.OutboundGroup
-	27, // 5: hiddifyrpc.ParseResponse.response_code:type_name -> hiddifyrpc.ResponseCode
-	2,  // 6: hiddifyrpc.LogMessage.level:type_name -> hiddifyrpc.LogLevel
-	3,  // 7: hiddifyrpc.LogMessage.type:type_name -> hiddifyrpc.LogType
-	28, // 8: hiddifyrpc.Hello.SayHello:input_type -> hiddifyrpc.HelloRequest
-	28, // 9: hiddifyrpc.Hello.SayHelloStream:input_type -> hiddifyrpc.HelloRequest
-	5,  // 10: hiddifyrpc.Core.Start:input_type -> hiddifyrpc.StartRequest
-	29, // 11: hiddifyrpc.Core.CoreInfoListener:input_type -> hiddifyrpc.Empty
-	29, // 12: hiddifyrpc.Core.OutboundsInfo:input_type -> hiddifyrpc.Empty
-	29, // 13: hiddifyrpc.Core.MainOutboundsInfo:input_type -> hiddifyrpc.Empty
-	29, // 14: hiddifyrpc.Core.GetSystemInfo:input_type -> hiddifyrpc.Empty
-	6,  // 15: hiddifyrpc.Core.Setup:input_type -> hiddifyrpc.SetupRequest
-	13, // 16: hiddifyrpc.Core.Parse:input_type -> hiddifyrpc.ParseRequest
-	15, // 17: hiddifyrpc.Core.ChangeHiddifySettings:input_type -> hiddifyrpc.ChangeHiddifySettingsRequest
-	29, // 18: hiddifyrpc.Core.GetHiddifySettings:input_type -> hiddifyrpc.Empty
-	5,  // 19: hiddifyrpc.Core.StartService:input_type -> hiddifyrpc.StartRequest
-	29, // 20: hiddifyrpc.Core.Stop:input_type -> hiddifyrpc.Empty
-	5,  // 21: hiddifyrpc.Core.Restart:input_type -> hiddifyrpc.StartRequest
-	19, // 22: hiddifyrpc.Core.SelectOutbound:input_type -> hiddifyrpc.SelectOutboundRequest
-	20, // 23: hiddifyrpc.Core.UrlTest:input_type -> hiddifyrpc.UrlTestRequest
-	29, // 24: hiddifyrpc.Core.GetSystemProxyStatus:input_type -> hiddifyrpc.Empty
-	21, // 25: hiddifyrpc.Core.SetSystemProxyEnabled:input_type -> hiddifyrpc.SetSystemProxyEnabledRequest
-	29, // 26: hiddifyrpc.Core.GetConfigCapabilities:input_type -> hiddifyrpc.Empty
-	29, // 27: hiddifyrpc.Core.LogListener:input_type -> hiddifyrpc.Empty
-	25, // 28: hiddifyrpc.TunnelService.Start:input_type -> hiddifyrpc.TunnelStartRequest
-	29, // 29: hiddifyrpc.TunnelService.Stop:input_type -> hiddifyrpc.Empty
-	29, // 30: hiddifyrpc.TunnelService.Status:input_type -> hiddifyrpc.Empty
-	29, // 31: hiddifyrpc.TunnelService.Exit:input_type -> hiddifyrpc.Empty
-	30, // 32: hiddifyrpc.Hello.SayHello:output_type -> hiddifyrpc.HelloResponse
-	30, // 33: hiddifyrpc.Hello.SayHelloStream:output_type -> hiddifyrpc.HelloResponse
-	4,  // 34: hiddifyrpc.Core.Start:output_type -> hiddifyrpc.CoreInfoResponse
-	4,  // 35: hiddifyrpc.Core.CoreInfoListener:output_type -> hiddifyrpc.CoreInfoResponse
-	11, // 36: hiddifyrpc.Core.OutboundsInfo:output_type -> hiddifyrpc.OutboundGroupList
-	11, // 37: hiddifyrpc.Core.MainOutboundsInfo:output_type -> hiddifyrpc.OutboundGroupList
-	8,  // 38: hiddifyrpc.Core.GetSystemInfo:output_type -> hiddifyrpc.SystemInfo
-	7,  // 39: hiddifyrpc.Core.Setup:output_type -> hiddifyrpc.Response
-	14, // 40: hiddifyrpc.Core.Parse:output_type -> hiddifyrpc.ParseResponse
-	4,  // 41: hiddifyrpc.Core.ChangeHiddifySettings:output_type -> hiddifyrpc.CoreInfoResponse
-	16, // 42: hiddifyrpc.Core.GetHiddifySettings:output_type -> hiddifyrpc.HiddifySettingsResponse
-	4,  // 43: hiddifyrpc.Core.StartService:output_type -> hiddifyrpc.CoreInfoResponse
-	4,  // 44: hiddifyrpc.Core.Stop:output_type -> hiddifyrpc.CoreInfoResponse
-	4,  // 45: hiddifyrpc.Core.Restart:output_type -> hiddifyrpc.CoreInfoResponse
-	7,  // 46: hiddifyrpc.Core.SelectOutbound:output_type -> hiddifyrpc.Response
-	7,  // 47: hiddifyrpc.Core.UrlTest:output_type -> hiddifyrpc.Response
-	12, // 48: hiddifyrpc.Core.GetSystemProxyStatus:output_type -> hiddifyrpc.SystemProxyStatus
-	7,  // 49: hiddifyrpc.Core.SetSystemProxyEnabled:output_type -> hiddifyrpc.Response
-	22, // 50: hiddifyrpc.Core.GetConfigCapabilities:output_type -> hiddifyrpc.ConfigCapabilityResponse
-	23, // 51: hiddifyrpc.Core.LogListener:output_type -> hiddifyrpc.LogMessage
-	26, // 52: hiddifyrpc.TunnelService.Start:output_type -> hiddifyrpc.TunnelResponse
-	26, // 53: hiddifyrpc.TunnelService.Stop:output_type -> hiddifyrpc.TunnelResponse
-	26, // 54: hiddifyrpc.TunnelService.Status:output_type -> hiddifyrpc.TunnelResponse
-	26, // 55: hiddifyrpc.TunnelService.Exit:output_type -> hiddifyrpc.TunnelResponse
-	32, // [32:56] is the sub-list for method output_type
-	8,  // [8:32] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	44, // 2: hiddifyrpc.Response.response_code:type_name -> hiddifyrpc.ResponseCode
+	11, // 3: hiddifyrpc.OutboundGroup.items:type_name -> hiddifyrpc.OutboundGroupItem
+	12, // 4: hiddifyrpc.OutboundGroupList.items:type_name -> hiddifyrpc.OutboundGroup
+	44, // 5: hiddifyrpc.ParseResponse.response_code:type_name -> hiddifyrpc.ResponseCode
+	16, // 6: hiddifyrpc.ParseResponse.diagnostics:type_name -> hiddifyrpc.ParseDiagnostic
+	44, // 7: hiddifyrpc.SubscriptionInfoResponse.response_code:type_name -> hiddifyrpc.ResponseCode
+	2,  // 8: hiddifyrpc.Profile.type:type_name -> hiddifyrpc.ProfileType
+	20, // 9: hiddifyrpc.ProfileList.items:type_name -> hiddifyrpc.Profile
+	44, // 10: hiddifyrpc.ProfileResponse.response_code:type_name -> hiddifyrpc.ResponseCode
+	20, // 11: hiddifyrpc.ProfileResponse.profile:type_name -> hiddifyrpc.Profile
+	3,  // 12: hiddifyrpc.ProfileRefreshEvent.status:type_name -> hiddifyrpc.RefreshStatus
+	4,  // 13: hiddifyrpc.LogMessage.level:type_name -> hiddifyrpc.LogLevel
+	5,  // 14: hiddifyrpc.LogMessage.type:type_name -> hiddifyrpc.LogType
+	44, // 15: hiddifyrpc.AssetList.response_code:type_name -> hiddifyrpc.ResponseCode
+	35, // 16: hiddifyrpc.AssetList.items:type_name -> hiddifyrpc.AssetInfo
+	44, // 17: hiddifyrpc.RouteExplanation.response_code:type_name -> hiddifyrpc.ResponseCode
+	44, // 18: hiddifyrpc.ClashModeResponse.response_code:type_name -> hiddifyrpc.ResponseCode
+	45, // 19: hiddifyrpc.Hello.SayHello:input_type -> hiddifyrpc.HelloRequest
+	45, // 20: hiddifyrpc.Hello.SayHelloStream:input_type -> hiddifyrpc.HelloRequest
+	7,  // 21: hiddifyrpc.Core.Start:input_type -> hiddifyrpc.StartRequest
+	46, // 22: hiddifyrpc.Core.CoreInfoListener:input_type -> hiddifyrpc.Empty
+	46, // 23: hiddifyrpc.Core.OutboundsInfo:input_type -> hiddifyrpc.Empty
+	46, // 24: hiddifyrpc.Core.MainOutboundsInfo:input_type -> hiddifyrpc.Empty
+	46, // 25: hiddifyrpc.Core.GetSystemInfo:input_type -> hiddifyrpc.Empty
+	8,  // 26: hiddifyrpc.Core.Setup:input_type -> hiddifyrpc.SetupRequest
+	15, // 27: hiddifyrpc.Core.Parse:input_type -> hiddifyrpc.ParseRequest
+	25, // 28: hiddifyrpc.Core.ChangeHiddifySettings:input_type -> hiddifyrpc.ChangeHiddifySettingsRequest
+	46, // 29: hiddifyrpc.Core.GetHiddifySettings:input_type -> hiddifyrpc.Empty
+	7,  // 30: hiddifyrpc.Core.StartService:input_type -> hiddifyrpc.StartRequest
+	46, // 31: hiddifyrpc.Core.Stop:input_type -> hiddifyrpc.Empty
+	7,  // 32: hiddifyrpc.Core.Restart:input_type -> hiddifyrpc.StartRequest
+	29, // 33: hiddifyrpc.Core.SelectOutbound:input_type -> hiddifyrpc.SelectOutboundRequest
+	30, // 34: hiddifyrpc.Core.UrlTest:input_type -> hiddifyrpc.UrlTestRequest
+	46, // 35: hiddifyrpc.Core.GetSystemProxyStatus:input_type -> hiddifyrpc.Empty
+	31, // 36: hiddifyrpc.Core.SetSystemProxyEnabled:input_type -> hiddifyrpc.SetSystemProxyEnabledRequest
+	46, // 37: hiddifyrpc.Core.GetConfigCapabilities:input_type -> hiddifyrpc.Empty
+	46, // 38: hiddifyrpc.Core.LogListener:input_type -> hiddifyrpc.Empty
+	18, // 39: hiddifyrpc.Core.GetSubscriptionInfo:input_type -> hiddifyrpc.SubscriptionInfoRequest
+	20, // 40: hiddifyrpc.Core.AddProfile:input_type -> hiddifyrpc.Profile
+	46, // 41: hiddifyrpc.Core.ListProfiles:input_type -> hiddifyrpc.Empty
+	20, // 42: hiddifyrpc.Core.UpdateProfile:input_type -> hiddifyrpc.Profile
+	22, // 43: hiddifyrpc.Core.DeleteProfile:input_type -> hiddifyrpc.ProfileIdRequest
+	22, // 44: hiddifyrpc.Core.SetActiveProfile:input_type -> hiddifyrpc.ProfileIdRequest
+	46, // 45: hiddifyrpc.Core.ProfileRefreshListener:input_type -> hiddifyrpc.Empty
+	46, // 46: hiddifyrpc.Core.GetMode:input_type -> hiddifyrpc.Empty
+	40, // 47: hiddifyrpc.Core.SetMode:input_type -> hiddifyrpc.ClashModeRequest
+	46, // 48: hiddifyrpc.Core.ModeListener:input_type -> hiddifyrpc.Empty
+	46, // 49: hiddifyrpc.Core.GetAssetsStatus:input_type -> hiddifyrpc.Empty
+	37, // 50: hiddifyrpc.Core.UpdateAssets:input_type -> hiddifyrpc.UpdateAssetsRequest
+	38, // 51: hiddifyrpc.Core.ExplainRoute:input_type -> hiddifyrpc.RouteQuery
+	42, // 52: hiddifyrpc.TunnelService.Start:input_type -> hiddifyrpc.TunnelStartRequest
+	46, // 53: hiddifyrpc.TunnelService.Stop:input_type -> hiddifyrpc.Empty
+	46, // 54: hiddifyrpc.TunnelService.Status:input_type -> hiddifyrpc.Empty
+	46, // 55: hiddifyrpc.TunnelService.Exit:input_type -> hiddifyrpc.Empty
+	47, // 56: hiddifyrpc.Hello.SayHello:output_type -> hiddifyrpc.HelloResponse
+	47, // 57: hiddifyrpc.Hello.SayHelloStream:output_type -> hiddifyrpc.HelloResponse
+	6,  // 58: hiddifyrpc.Core.Start:output_type -> hiddifyrpc.CoreInfoResponse
+	6,  // 59: hiddifyrpc.Core.CoreInfoListener:output_type -> hiddifyrpc.CoreInfoResponse
+	13, // 60: hiddifyrpc.Core.OutboundsInfo:output_type -> hiddifyrpc.OutboundGroupList
+	13, // 61: hiddifyrpc.Core.MainOutboundsInfo:output_type -> hiddifyrpc.OutboundGroupList
+	10, // 62: hiddifyrpc.Core.GetSystemInfo:output_type -> hiddifyrpc.SystemInfo
+	9,  // 63: hiddifyrpc.Core.Setup:output_type -> hiddifyrpc.Response
+	17, // 64: hiddifyrpc.Core.Parse:output_type -> hiddifyrpc.ParseResponse
+	6,  // 65: hiddifyrpc.Core.ChangeHiddifySettings:output_type -> hiddifyrpc.CoreInfoResponse
+	26, // 66: hiddifyrpc.Core.GetHiddifySettings:output_type -> hiddifyrpc.HiddifySettingsResponse
+	6,  // 67: hiddifyrpc.Core.StartService:output_type -> hiddifyrpc.CoreInfoResponse
+	6,  // 68: hiddifyrpc.Core.Stop:output_type -> hiddifyrpc.CoreInfoResponse
+	6,  // 69: hiddifyrpc.Core.Restart:output_type -> hiddifyrpc.CoreInfoResponse
+	9,  // 70: hiddifyrpc.Core.SelectOutbound:output_type -> hiddifyrpc.Response
+	9,  // 71: hiddifyrpc.Core.UrlTest:output_type -> hiddifyrpc.Response
+	14, // 72: hiddifyrpc.Core.GetSystemProxyStatus:output_type -> hiddifyrpc.SystemProxyStatus
+	9,  // 73: hiddifyrpc.Core.SetSystemProxyEnabled:output_type -> hiddifyrpc.Response
+	32, // 74: hiddifyrpc.Core.GetConfigCapabilities:output_type -> hiddifyrpc.ConfigCapabilityResponse
+	33, // 75: hiddifyrpc.Core.LogListener:output_type -> hiddifyrpc.LogMessage
+	19, // 76: hiddifyrpc.Core.GetSubscriptionInfo:output_type -> hiddifyrpc.SubscriptionInfoResponse
+	23, // 77: hiddifyrpc.Core.AddProfile:output_type -> hiddifyrpc.ProfileResponse
+	21, // 78: hiddifyrpc.Core.ListProfiles:output_type -> hiddifyrpc.ProfileList
+	23, // 79: hiddifyrpc.Core.UpdateProfile:output_type -> hiddifyrpc.ProfileResponse
+	9,  // 80: hiddifyrpc.Core.DeleteProfile:output_type -> hiddifyrpc.Response
+	9,  // 81: hiddifyrpc.Core.SetActiveProfile:output_type -> hiddifyrpc.Response
+	24, // 82: hiddifyrpc.Core.ProfileRefreshListener:output_type -> hiddifyrpc.ProfileRefreshEvent
+	41, // 83: hiddifyrpc.Core.GetMode:output_type -> hiddifyrpc.ClashModeResponse
+	41, // 84: hiddifyrpc.Core.SetMode:output_type -> hiddifyrpc.ClashModeResponse
+	41, // 85: hiddifyrpc.Core.ModeListener:output_type -> hiddifyrpc.ClashModeResponse
+	36, // 86: hiddifyrpc.Core.GetAssetsStatus:output_type -> hiddifyrpc.AssetList
+	36, // 87: hiddifyrpc.Core.UpdateAssets:output_type -> hiddifyrpc.AssetList
+	39, // 88: hiddifyrpc.Core.ExplainRoute:output_type -> hiddifyrpc.RouteExplanation
+	43, // 89: hiddifyrpc.TunnelService.Start:output_type -> hiddifyrpc.TunnelResponse
+	43, // 90: hiddifyrpc.TunnelService.Stop:output_type -> hiddifyrpc.TunnelResponse
+	43, // 91: hiddifyrpc.TunnelService.Status:output_type -> hiddifyrpc.TunnelResponse
+	43, // 92: hiddifyrpc.TunnelService.Exit:output_type -> hiddifyrpc.TunnelResponse
+	56, // [56:93] is the sub-list for method output_type
+	19, // [19:56] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_hiddify_proto_init() }
@@ -1885,8 +3463,8 @@ func file_hiddify_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_hiddify_proto_rawDesc), len(file_hiddify_proto_rawDesc)),
-			NumEnums:      4,
-			NumMessages:   23,
+			NumEnums:      6,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   3,
 		},
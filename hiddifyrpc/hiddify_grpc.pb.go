@@ -154,24 +154,37 @@ var Hello_ServiceDesc = grpc.ServiceDesc{
 }
 
 const (
-	Core_Start_FullMethodName                 = "/hiddifyrpc.Core/Start"
-	Core_CoreInfoListener_FullMethodName      = "/hiddifyrpc.Core/CoreInfoListener"
-	Core_OutboundsInfo_FullMethodName         = "/hiddifyrpc.Core/OutboundsInfo"
-	Core_MainOutboundsInfo_FullMethodName     = "/hiddifyrpc.Core/MainOutboundsInfo"
-	Core_GetSystemInfo_FullMethodName         = "/hiddifyrpc.Core/GetSystemInfo"
-	Core_Setup_FullMethodName                 = "/hiddifyrpc.Core/Setup"
-	Core_Parse_FullMethodName                 = "/hiddifyrpc.Core/Parse"
-	Core_ChangeHiddifySettings_FullMethodName = "/hiddifyrpc.Core/ChangeHiddifySettings"
-	Core_GetHiddifySettings_FullMethodName    = "/hiddifyrpc.Core/GetHiddifySettings"
-	Core_StartService_FullMethodName          = "/hiddifyrpc.Core/StartService"
-	Core_Stop_FullMethodName                  = "/hiddifyrpc.Core/Stop"
-	Core_Restart_FullMethodName               = "/hiddifyrpc.Core/Restart"
-	Core_SelectOutbound_FullMethodName        = "/hiddifyrpc.Core/SelectOutbound"
-	Core_UrlTest_FullMethodName               = "/hiddifyrpc.Core/UrlTest"
-	Core_GetSystemProxyStatus_FullMethodName  = "/hiddifyrpc.Core/GetSystemProxyStatus"
-	Core_SetSystemProxyEnabled_FullMethodName = "/hiddifyrpc.Core/SetSystemProxyEnabled"
-	Core_GetConfigCapabilities_FullMethodName = "/hiddifyrpc.Core/GetConfigCapabilities"
-	Core_LogListener_FullMethodName           = "/hiddifyrpc.Core/LogListener"
+	Core_Start_FullMethodName                  = "/hiddifyrpc.Core/Start"
+	Core_CoreInfoListener_FullMethodName       = "/hiddifyrpc.Core/CoreInfoListener"
+	Core_OutboundsInfo_FullMethodName          = "/hiddifyrpc.Core/OutboundsInfo"
+	Core_MainOutboundsInfo_FullMethodName      = "/hiddifyrpc.Core/MainOutboundsInfo"
+	Core_GetSystemInfo_FullMethodName          = "/hiddifyrpc.Core/GetSystemInfo"
+	Core_Setup_FullMethodName                  = "/hiddifyrpc.Core/Setup"
+	Core_Parse_FullMethodName                  = "/hiddifyrpc.Core/Parse"
+	Core_ChangeHiddifySettings_FullMethodName  = "/hiddifyrpc.Core/ChangeHiddifySettings"
+	Core_GetHiddifySettings_FullMethodName     = "/hiddifyrpc.Core/GetHiddifySettings"
+	Core_StartService_FullMethodName           = "/hiddifyrpc.Core/StartService"
+	Core_Stop_FullMethodName                   = "/hiddifyrpc.Core/Stop"
+	Core_Restart_FullMethodName                = "/hiddifyrpc.Core/Restart"
+	Core_SelectOutbound_FullMethodName         = "/hiddifyrpc.Core/SelectOutbound"
+	Core_UrlTest_FullMethodName                = "/hiddifyrpc.Core/UrlTest"
+	Core_GetSystemProxyStatus_FullMethodName   = "/hiddifyrpc.Core/GetSystemProxyStatus"
+	Core_SetSystemProxyEnabled_FullMethodName  = "/hiddifyrpc.Core/SetSystemProxyEnabled"
+	Core_GetConfigCapabilities_FullMethodName  = "/hiddifyrpc.Core/GetConfigCapabilities"
+	Core_LogListener_FullMethodName            = "/hiddifyrpc.Core/LogListener"
+	Core_GetSubscriptionInfo_FullMethodName    = "/hiddifyrpc.Core/GetSubscriptionInfo"
+	Core_AddProfile_FullMethodName             = "/hiddifyrpc.Core/AddProfile"
+	Core_ListProfiles_FullMethodName           = "/hiddifyrpc.Core/ListProfiles"
+	Core_UpdateProfile_FullMethodName          = "/hiddifyrpc.Core/UpdateProfile"
+	Core_DeleteProfile_FullMethodName          = "/hiddifyrpc.Core/DeleteProfile"
+	Core_SetActiveProfile_FullMethodName       = "/hiddifyrpc.Core/SetActiveProfile"
+	Core_ProfileRefreshListener_FullMethodName = "/hiddifyrpc.Core/ProfileRefreshListener"
+	Core_GetMode_FullMethodName                = "/hiddifyrpc.Core/GetMode"
+	Core_SetMode_FullMethodName                = "/hiddifyrpc.Core/SetMode"
+	Core_ModeListener_FullMethodName           = "/hiddifyrpc.Core/ModeListener"
+	Core_GetAssetsStatus_FullMethodName        = "/hiddifyrpc.Core/GetAssetsStatus"
+	Core_UpdateAssets_FullMethodName           = "/hiddifyrpc.Core/UpdateAssets"
+	Core_ExplainRoute_FullMethodName           = "/hiddifyrpc.Core/ExplainRoute"
 )
 
 // CoreClient is the client API for Core service.
@@ -197,6 +210,19 @@ type CoreClient interface {
 	SetSystemProxyEnabled(ctx context.Context, in *SetSystemProxyEnabledRequest, opts ...grpc.CallOption) (*Response, error)
 	GetConfigCapabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ConfigCapabilityResponse, error)
 	LogListener(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogMessage], error)
+	GetSubscriptionInfo(ctx context.Context, in *SubscriptionInfoRequest, opts ...grpc.CallOption) (*SubscriptionInfoResponse, error)
+	AddProfile(ctx context.Context, in *Profile, opts ...grpc.CallOption) (*ProfileResponse, error)
+	ListProfiles(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ProfileList, error)
+	UpdateProfile(ctx context.Context, in *Profile, opts ...grpc.CallOption) (*ProfileResponse, error)
+	DeleteProfile(ctx context.Context, in *ProfileIdRequest, opts ...grpc.CallOption) (*Response, error)
+	SetActiveProfile(ctx context.Context, in *ProfileIdRequest, opts ...grpc.CallOption) (*Response, error)
+	ProfileRefreshListener(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProfileRefreshEvent], error)
+	GetMode(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ClashModeResponse, error)
+	SetMode(ctx context.Context, in *ClashModeRequest, opts ...grpc.CallOption) (*ClashModeResponse, error)
+	ModeListener(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ClashModeResponse], error)
+	GetAssetsStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*AssetList, error)
+	UpdateAssets(ctx context.Context, in *UpdateAssetsRequest, opts ...grpc.CallOption) (*AssetList, error)
+	ExplainRoute(ctx context.Context, in *RouteQuery, opts ...grpc.CallOption) (*RouteExplanation, error)
 }
 
 type coreClient struct {
@@ -432,6 +458,154 @@ func (c *coreClient) LogListener(ctx context.Context, in *Empty, opts ...grpc.Ca
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Core_LogListenerClient = grpc.ServerStreamingClient[LogMessage]
 
+func (c *coreClient) GetSubscriptionInfo(ctx context.Context, in *SubscriptionInfoRequest, opts ...grpc.CallOption) (*SubscriptionInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubscriptionInfoResponse)
+	err := c.cc.Invoke(ctx, Core_GetSubscriptionInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) AddProfile(ctx context.Context, in *Profile, opts ...grpc.CallOption) (*ProfileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProfileResponse)
+	err := c.cc.Invoke(ctx, Core_AddProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) ListProfiles(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ProfileList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProfileList)
+	err := c.cc.Invoke(ctx, Core_ListProfiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) UpdateProfile(ctx context.Context, in *Profile, opts ...grpc.CallOption) (*ProfileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProfileResponse)
+	err := c.cc.Invoke(ctx, Core_UpdateProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) DeleteProfile(ctx context.Context, in *ProfileIdRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, Core_DeleteProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) SetActiveProfile(ctx context.Context, in *ProfileIdRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, Core_SetActiveProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) ProfileRefreshListener(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProfileRefreshEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Core_ServiceDesc.Streams[5], Core_ProfileRefreshListener_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Empty, ProfileRefreshEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Core_ProfileRefreshListenerClient = grpc.ServerStreamingClient[ProfileRefreshEvent]
+
+func (c *coreClient) GetMode(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ClashModeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClashModeResponse)
+	err := c.cc.Invoke(ctx, Core_GetMode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) SetMode(ctx context.Context, in *ClashModeRequest, opts ...grpc.CallOption) (*ClashModeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClashModeResponse)
+	err := c.cc.Invoke(ctx, Core_SetMode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) ModeListener(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ClashModeResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Core_ServiceDesc.Streams[6], Core_ModeListener_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Empty, ClashModeResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Core_ModeListenerClient = grpc.ServerStreamingClient[ClashModeResponse]
+
+func (c *coreClient) GetAssetsStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*AssetList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AssetList)
+	err := c.cc.Invoke(ctx, Core_GetAssetsStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) UpdateAssets(ctx context.Context, in *UpdateAssetsRequest, opts ...grpc.CallOption) (*AssetList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AssetList)
+	err := c.cc.Invoke(ctx, Core_UpdateAssets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) ExplainRoute(ctx context.Context, in *RouteQuery, opts ...grpc.CallOption) (*RouteExplanation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RouteExplanation)
+	err := c.cc.Invoke(ctx, Core_ExplainRoute_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CoreServer is the server API for Core service.
 // All implementations must embed UnimplementedCoreServer
 // for forward compatibility.
@@ -455,6 +629,19 @@ type CoreServer interface {
 	SetSystemProxyEnabled(context.Context, *SetSystemProxyEnabledRequest) (*Response, error)
 	GetConfigCapabilities(context.Context, *Empty) (*ConfigCapabilityResponse, error)
 	LogListener(*Empty, grpc.ServerStreamingServer[LogMessage]) error
+	GetSubscriptionInfo(context.Context, *SubscriptionInfoRequest) (*SubscriptionInfoResponse, error)
+	AddProfile(context.Context, *Profile) (*ProfileResponse, error)
+	ListProfiles(context.Context, *Empty) (*ProfileList, error)
+	UpdateProfile(context.Context, *Profile) (*ProfileResponse, error)
+	DeleteProfile(context.Context, *ProfileIdRequest) (*Response, error)
+	SetActiveProfile(context.Context, *ProfileIdRequest) (*Response, error)
+	ProfileRefreshListener(*Empty, grpc.ServerStreamingServer[ProfileRefreshEvent]) error
+	GetMode(context.Context, *Empty) (*ClashModeResponse, error)
+	SetMode(context.Context, *ClashModeRequest) (*ClashModeResponse, error)
+	ModeListener(*Empty, grpc.ServerStreamingServer[ClashModeResponse]) error
+	GetAssetsStatus(context.Context, *Empty) (*AssetList, error)
+	UpdateAssets(context.Context, *UpdateAssetsRequest) (*AssetList, error)
+	ExplainRoute(context.Context, *RouteQuery) (*RouteExplanation, error)
 	mustEmbedUnimplementedCoreServer()
 }
 
@@ -519,6 +706,45 @@ func (UnimplementedCoreServer) GetConfigCapabilities(context.Context, *Empty) (*
 func (UnimplementedCoreServer) LogListener(*Empty, grpc.ServerStreamingServer[LogMessage]) error {
 	return status.Errorf(codes.Unimplemented, "method LogListener not implemented")
 }
+func (UnimplementedCoreServer) GetSubscriptionInfo(context.Context, *SubscriptionInfoRequest) (*SubscriptionInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSubscriptionInfo not implemented")
+}
+func (UnimplementedCoreServer) AddProfile(context.Context, *Profile) (*ProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddProfile not implemented")
+}
+func (UnimplementedCoreServer) ListProfiles(context.Context, *Empty) (*ProfileList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProfiles not implemented")
+}
+func (UnimplementedCoreServer) UpdateProfile(context.Context, *Profile) (*ProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProfile not implemented")
+}
+func (UnimplementedCoreServer) DeleteProfile(context.Context, *ProfileIdRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteProfile not implemented")
+}
+func (UnimplementedCoreServer) SetActiveProfile(context.Context, *ProfileIdRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetActiveProfile not implemented")
+}
+func (UnimplementedCoreServer) ProfileRefreshListener(*Empty, grpc.ServerStreamingServer[ProfileRefreshEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method ProfileRefreshListener not implemented")
+}
+func (UnimplementedCoreServer) GetMode(context.Context, *Empty) (*ClashModeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMode not implemented")
+}
+func (UnimplementedCoreServer) SetMode(context.Context, *ClashModeRequest) (*ClashModeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMode not implemented")
+}
+func (UnimplementedCoreServer) ModeListener(*Empty, grpc.ServerStreamingServer[ClashModeResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ModeListener not implemented")
+}
+func (UnimplementedCoreServer) GetAssetsStatus(context.Context, *Empty) (*AssetList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAssetsStatus not implemented")
+}
+func (UnimplementedCoreServer) UpdateAssets(context.Context, *UpdateAssetsRequest) (*AssetList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAssets not implemented")
+}
+func (UnimplementedCoreServer) ExplainRoute(context.Context, *RouteQuery) (*RouteExplanation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExplainRoute not implemented")
+}
 func (UnimplementedCoreServer) mustEmbedUnimplementedCoreServer() {}
 func (UnimplementedCoreServer) testEmbeddedByValue()              {}
 
@@ -829,6 +1055,226 @@ func _Core_LogListener_Handler(srv interface{}, stream grpc.ServerStream) error
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Core_LogListenerServer = grpc.ServerStreamingServer[LogMessage]
 
+func _Core_GetSubscriptionInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscriptionInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).GetSubscriptionInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_GetSubscriptionInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).GetSubscriptionInfo(ctx, req.(*SubscriptionInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_AddProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Profile)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).AddProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_AddProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).AddProfile(ctx, req.(*Profile))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_ListProfiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).ListProfiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_ListProfiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).ListProfiles(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_UpdateProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Profile)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).UpdateProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_UpdateProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).UpdateProfile(ctx, req.(*Profile))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_DeleteProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProfileIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).DeleteProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_DeleteProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).DeleteProfile(ctx, req.(*ProfileIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_SetActiveProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProfileIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).SetActiveProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_SetActiveProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).SetActiveProfile(ctx, req.(*ProfileIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_ProfileRefreshListener_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CoreServer).ProfileRefreshListener(m, &grpc.GenericServerStream[Empty, ProfileRefreshEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Core_ProfileRefreshListenerServer = grpc.ServerStreamingServer[ProfileRefreshEvent]
+
+func _Core_GetMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).GetMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_GetMode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).GetMode(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_SetMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClashModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).SetMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_SetMode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).SetMode(ctx, req.(*ClashModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_ModeListener_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CoreServer).ModeListener(m, &grpc.GenericServerStream[Empty, ClashModeResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Core_ModeListenerServer = grpc.ServerStreamingServer[ClashModeResponse]
+
+func _Core_GetAssetsStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).GetAssetsStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_GetAssetsStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).GetAssetsStatus(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_UpdateAssets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAssetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).UpdateAssets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_UpdateAssets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).UpdateAssets(ctx, req.(*UpdateAssetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_ExplainRoute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RouteQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).ExplainRoute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Core_ExplainRoute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).ExplainRoute(ctx, req.(*RouteQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Core_ServiceDesc is the grpc.ServiceDesc for Core service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -888,6 +1334,50 @@ var Core_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetConfigCapabilities",
 			Handler:    _Core_GetConfigCapabilities_Handler,
 		},
+		{
+			MethodName: "GetSubscriptionInfo",
+			Handler:    _Core_GetSubscriptionInfo_Handler,
+		},
+		{
+			MethodName: "AddProfile",
+			Handler:    _Core_AddProfile_Handler,
+		},
+		{
+			MethodName: "ListProfiles",
+			Handler:    _Core_ListProfiles_Handler,
+		},
+		{
+			MethodName: "UpdateProfile",
+			Handler:    _Core_UpdateProfile_Handler,
+		},
+		{
+			MethodName: "DeleteProfile",
+			Handler:    _Core_DeleteProfile_Handler,
+		},
+		{
+			MethodName: "SetActiveProfile",
+			Handler:    _Core_SetActiveProfile_Handler,
+		},
+		{
+			MethodName: "GetMode",
+			Handler:    _Core_GetMode_Handler,
+		},
+		{
+			MethodName: "SetMode",
+			Handler:    _Core_SetMode_Handler,
+		},
+		{
+			MethodName: "GetAssetsStatus",
+			Handler:    _Core_GetAssetsStatus_Handler,
+		},
+		{
+			MethodName: "UpdateAssets",
+			Handler:    _Core_UpdateAssets_Handler,
+		},
+		{
+			MethodName: "ExplainRoute",
+			Handler:    _Core_ExplainRoute_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -915,6 +1405,16 @@ var Core_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Core_LogListener_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "ProfileRefreshListener",
+			Handler:       _Core_ProfileRefreshListener_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ModeListener",
+			Handler:       _Core_ModeListener_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "hiddify.proto",
 }
@@ -0,0 +1,447 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiddify/hiddify-core/config"
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	"github.com/hiddify/hiddify-core/v2/db"
+	"github.com/sagernet/sing-box/common/srs"
+	"github.com/sagernet/sing-box/option"
+)
+
+const (
+	defaultAssetUpdateInterval = 24 * time.Hour
+	assetCheckInterval         = time.Hour
+	maxAssetSize               = 64 << 20
+)
+
+var (
+	assets           *assetManager
+	startAssetsOnce  sync.Once
+	errAssetNotFound = fmt.Errorf("asset not found")
+)
+
+// AssetInfo records the state of a rule-set kept on disk, keyed by the
+// rule-set tag.
+type AssetInfo struct {
+	Id             string
+	Url            string
+	ETag           string
+	LastModified   string
+	Version        string // sha256 of the file
+	Size           int64
+	UpdateInterval int64 // seconds, 0 for the default
+	UpdatedAt      int64
+	CheckedAt      int64
+	Error          string
+}
+
+// assetManager keeps the remote rule-sets of the config in a local directory
+// and refreshes them in the background, so routing does not depend on
+// downloading them at start.
+type assetManager struct {
+	dir    string
+	client func() *http.Client
+	now    func() time.Time
+	load   func() ([]*AssetInfo, error)
+	save   func(*AssetInfo) error
+
+	access sync.Mutex
+}
+
+func newAssetManager(dir string) *assetManager {
+	return &assetManager{
+		dir:    dir,
+		client: assetHTTPClient,
+		now:    time.Now,
+		load:   db.GetTable[AssetInfo]().All,
+		save:   func(info *AssetInfo) error { return db.GetTable[AssetInfo]().UpdateInsert(info) },
+	}
+}
+
+func assetsDir() string {
+	return filepath.Join(sWorkingPath, "data", "assets")
+}
+
+// startAssetManager seeds the assets shipped in basePath/assets and starts
+// the background updates.
+func startAssetManager(basePath string) {
+	startAssetsOnce.Do(func() {
+		assets = newAssetManager(assetsDir())
+		if err := seedAssets(filepath.Join(basePath, "assets"), assets.dir); err != nil {
+			Log(pb.LogLevel_WARNING, pb.LogType_CORE, "failed to seed assets: "+err.Error())
+		}
+		go assets.run(context.Background(), assetCheckInterval)
+	})
+}
+
+// seedAssets copies the bundled rule-sets missing from dir.
+func seedAssets(bundleDir string, dir string) error {
+	entries, err := os.ReadDir(bundleDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".srs" {
+			continue
+		}
+		target := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(bundleDir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := writeAsset(target, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assetHTTPClient downloads through the local proxy while the core runs,
+// since the rule-set hosts may only be reachable through it.
+func assetHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options := HiddifyOptions; CoreState == pb.CoreState_STARTED && options != nil && options.MixedPort > 0 {
+		proxyURL := &url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", options.MixedPort)}
+		if users := options.LANSharing.Users; len(users) > 0 {
+			proxyURL.User = url.UserPassword(users[0].Username, users[0].Password)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Client{Timeout: time.Minute, Transport: transport}
+}
+
+// apply serves the rule-sets of options from disk where possible and
+// registers every remote rule-set for background updates. New and missing
+// assets are fetched right away. Tags are positional for user rule-sets, so
+// a file is only served for the url it was downloaded from; files without a
+// record are the bundled ones.
+func (m *assetManager) apply(options *option.Options) {
+	m.access.Lock()
+	defer m.access.Unlock()
+	infos, err := m.load()
+	if err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CORE, "failed to load assets: "+err.Error())
+		return
+	}
+	known := make(map[string]*AssetInfo, len(infos))
+	for _, info := range infos {
+		known[info.Id] = info
+	}
+	ruleSets := config.LocalizeRuleSets(options, m.dir, func(ruleSet option.RuleSet) bool {
+		info := known[ruleSet.Tag]
+		return info == nil || info.Url == ruleSet.RemoteOptions.URL
+	})
+	due := false
+	for _, ruleSet := range ruleSets {
+		interval := int64(time.Duration(ruleSet.RemoteOptions.UpdateInterval).Seconds())
+		info := known[ruleSet.Tag]
+		if info == nil || info.Url != ruleSet.RemoteOptions.URL {
+			if info != nil {
+				// The file holds the rule-set of the previous url.
+				os.Remove(m.path(ruleSet.Tag))
+			}
+			info = &AssetInfo{Id: ruleSet.Tag, Url: ruleSet.RemoteOptions.URL}
+			due = true
+		} else if info.UpdateInterval == interval {
+			due = due || !m.exists(info.Id)
+			continue
+		}
+		info.UpdateInterval = interval
+		if err := m.save(info); err != nil {
+			Log(pb.LogLevel_WARNING, pb.LogType_CORE, "failed to save asset: "+err.Error())
+		}
+		due = due || !m.exists(info.Id)
+	}
+	if due {
+		go m.updateDue(context.Background())
+	}
+}
+
+func (m *assetManager) path(tag string) string {
+	return filepath.Join(m.dir, config.AssetFileName(tag))
+}
+
+func (m *assetManager) exists(tag string) bool {
+	info, err := os.Stat(m.path(tag))
+	return err == nil && info.Size() > 0
+}
+
+func (m *assetManager) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.updateDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateDue updates the assets that are missing or older than their update
+// interval.
+func (m *assetManager) updateDue(ctx context.Context) {
+	m.update(ctx, nil, false)
+}
+
+// update refreshes the assets with the given tags, or every due asset when
+// tags is empty. force skips the interval and the conditional request. The
+// lock is only held to read and store the records, never while downloading,
+// so apply does not wait for slow hosts.
+func (m *assetManager) update(ctx context.Context, tags []string, force bool) error {
+	due, err := m.dueAssets(tags, force)
+	if err != nil {
+		return err
+	}
+	now := m.now()
+	var errs []string
+	for _, info := range due {
+		download, err := m.download(ctx, &info, force)
+		if err := m.store(&info, download, err, now); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", info.Id, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// dueAssets returns copies of the records update has to download.
+func (m *assetManager) dueAssets(tags []string, force bool) ([]AssetInfo, error) {
+	m.access.Lock()
+	defer m.access.Unlock()
+	infos, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		for _, tag := range tags {
+			if !slices.ContainsFunc(infos, func(info *AssetInfo) bool { return info.Id == tag }) {
+				return nil, fmt.Errorf("%w: %s", errAssetNotFound, tag)
+			}
+		}
+	}
+	now := m.now()
+	var due []AssetInfo
+	for _, info := range infos {
+		if len(tags) > 0 && !slices.Contains(tags, info.Id) {
+			continue
+		}
+		if len(tags) == 0 && !force && m.exists(info.Id) && now.Before(time.Unix(info.UpdatedAt, 0).Add(info.updateInterval())) {
+			continue
+		}
+		due = append(due, *info)
+	}
+	return due, nil
+}
+
+// assetDownload holds a downloaded asset; content is nil when the asset was
+// not modified.
+type assetDownload struct {
+	content      []byte
+	etag         string
+	lastModified string
+}
+
+func (m *assetManager) download(ctx context.Context, info *AssetInfo, force bool) (*assetDownload, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, info.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !force && m.exists(info.Id) {
+		if info.ETag != "" {
+			req.Header.Set("If-None-Match", info.ETag)
+		}
+		if info.LastModified != "" {
+			req.Header.Set("If-Modified-Since", info.LastModified)
+		}
+	}
+	resp, err := m.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return &assetDownload{}, nil
+	case http.StatusOK:
+		content, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > maxAssetSize {
+			return nil, fmt.Errorf("rule-set larger than %d bytes", maxAssetSize)
+		}
+		if _, err := srs.Read(bytes.NewReader(content), false); err != nil {
+			return nil, fmt.Errorf("invalid rule-set: %w", err)
+		}
+		return &assetDownload{
+			content:      content,
+			etag:         resp.Header.Get("ETag"),
+			lastModified: resp.Header.Get("Last-Modified"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+}
+
+// store writes the outcome of downloading fetched to its record and the asset
+// file. It is dropped when apply removed the asset or changed its url in the
+// meantime.
+func (m *assetManager) store(fetched *AssetInfo, download *assetDownload, downloadErr error, now time.Time) error {
+	m.access.Lock()
+	defer m.access.Unlock()
+	infos, err := m.load()
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(infos, func(info *AssetInfo) bool {
+		return info.Id == fetched.Id && info.Url == fetched.Url
+	})
+	if index == -1 {
+		return nil
+	}
+	info := infos[index]
+	if downloadErr == nil {
+		downloadErr = m.write(info, download)
+	}
+	if downloadErr != nil {
+		info.Error = downloadErr.Error()
+		Log(pb.LogLevel_WARNING, pb.LogType_CORE, fmt.Sprintf("updating asset %s failed: %v", info.Id, downloadErr))
+	} else {
+		info.Error = ""
+	}
+	info.CheckedAt = now.Unix()
+	return errors.Join(downloadErr, m.save(info))
+}
+
+func (m *assetManager) write(info *AssetInfo, download *assetDownload) error {
+	if download.content != nil {
+		sum := sha256.Sum256(download.content)
+		version := hex.EncodeToString(sum[:])
+		if version != info.Version || !m.exists(info.Id) {
+			if err := os.MkdirAll(m.dir, 0o755); err != nil {
+				return err
+			}
+			if err := writeAsset(m.path(info.Id), download.content); err != nil {
+				return err
+			}
+			info.Version = version
+			info.Size = int64(len(download.content))
+		}
+		info.ETag = download.etag
+		info.LastModified = download.lastModified
+	}
+	info.UpdatedAt = m.now().Unix()
+	return nil
+}
+
+// writeAsset replaces the file atomically so sing-box never reads a partial
+// rule-set.
+func writeAsset(path string, content []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (info *AssetInfo) updateInterval() time.Duration {
+	if info.UpdateInterval > 0 {
+		return time.Duration(info.UpdateInterval) * time.Second
+	}
+	return defaultAssetUpdateInterval
+}
+
+func (m *assetManager) status() (*pb.AssetList, error) {
+	infos, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	list := &pb.AssetList{ResponseCode: pb.ResponseCode_OK}
+	for _, info := range infos {
+		list.Items = append(list.Items, &pb.AssetInfo{
+			Tag:       info.Id,
+			Url:       info.Url,
+			Available: m.exists(info.Id),
+			Version:   info.Version,
+			Size:      info.Size,
+			UpdatedAt: info.UpdatedAt,
+			CheckedAt: info.CheckedAt,
+			Error:     info.Error,
+		})
+	}
+	return list, nil
+}
+
+func assetListFailed(err error) (*pb.AssetList, error) {
+	return &pb.AssetList{
+		ResponseCode: pb.ResponseCode_FAILED,
+		Message:      err.Error(),
+	}, err
+}
+
+func (s *CoreService) GetAssetsStatus(ctx context.Context, req *pb.Empty) (*pb.AssetList, error) {
+	return GetAssetsStatus()
+}
+
+func GetAssetsStatus() (*pb.AssetList, error) {
+	if assets == nil {
+		return assetListFailed(fmt.Errorf("asset manager not started"))
+	}
+	list, err := assets.status()
+	if err != nil {
+		return assetListFailed(err)
+	}
+	return list, nil
+}
+
+func (s *CoreService) UpdateAssets(ctx context.Context, in *pb.UpdateAssetsRequest) (*pb.AssetList, error) {
+	return UpdateAssets(ctx, in)
+}
+
+// UpdateAssets updates the requested assets, or every due asset when no tag
+// is given, and returns the resulting status.
+func UpdateAssets(ctx context.Context, in *pb.UpdateAssetsRequest) (*pb.AssetList, error) {
+	if assets == nil {
+		return assetListFailed(fmt.Errorf("asset manager not started"))
+	}
+	updateErr := assets.update(ctx, in.Tags, in.Force)
+	list, err := assets.status()
+	if err != nil {
+		return assetListFailed(err)
+	}
+	if updateErr != nil {
+		list.ResponseCode = pb.ResponseCode_FAILED
+		list.Message = updateErr.Error()
+	}
+	return list, nil
+}
@@ -0,0 +1,262 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+func testRuleSetContent(t *testing.T, domain string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	ruleSet := option.PlainRuleSet{Rules: []option.HeadlessRule{{
+		Type:           C.RuleTypeDefault,
+		DefaultOptions: option.DefaultHeadlessRule{DomainSuffix: []string{domain}},
+	}}}
+	if err := srs.Write(&buf, ruleSet, C.RuleSetVersionCurrent); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAssetManager(t *testing.T) {
+	content := testRuleSetContent(t, "example.com")
+	var body atomic.Value
+	body.Store(content)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(body.Load().([]byte))
+	}))
+	defer server.Close()
+
+	now := time.Unix(1700000000, 0)
+	infos := map[string]*AssetInfo{}
+	manager := newAssetManager(t.TempDir())
+	manager.client = server.Client
+	manager.now = func() time.Time { return now }
+	manager.load = func() ([]*AssetInfo, error) {
+		var list []*AssetInfo
+		for _, info := range infos {
+			list = append(list, info)
+		}
+		return list, nil
+	}
+	manager.save = func(info *AssetInfo) error { infos[info.Id] = info; return nil }
+
+	options := func() *option.Options {
+		return &option.Options{Route: &option.RouteOptions{RuleSet: []option.RuleSet{{
+			Type:          C.RuleSetTypeRemote,
+			Tag:           "geosite-test",
+			Format:        C.RuleSetFormatBinary,
+			RemoteOptions: option.RemoteRuleSet{URL: server.URL + "/geosite-test.srs"},
+		}}}}
+	}
+
+	first := options()
+	manager.apply(first)
+	if first.Route.RuleSet[0].Type != C.RuleSetTypeRemote {
+		t.Fatalf("rule-set localized before download")
+	}
+	// New assets are downloaded in the background right away.
+	for deadline := time.Now().Add(5 * time.Second); !manager.exists("geosite-test"); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("asset not downloaded")
+		}
+	}
+	manager.access.Lock()
+	info := infos["geosite-test"]
+	manager.access.Unlock()
+	if info == nil || info.Version == "" || info.Size != int64(len(content)) || info.ETag != `"v1"` {
+		t.Fatalf("unexpected asset after download: %+v", info)
+	}
+	saved, err := os.ReadFile(filepath.Join(manager.dir, "geosite-test.srs"))
+	if err != nil || !bytes.Equal(saved, content) {
+		t.Fatalf("asset not written: %v", err)
+	}
+
+	second := options()
+	manager.apply(second)
+	if ruleSet := second.Route.RuleSet[0]; ruleSet.Type != C.RuleSetTypeLocal || ruleSet.LocalOptions.Path != manager.path("geosite-test") {
+		t.Fatalf("rule-set not served from disk: %+v", ruleSet)
+	}
+
+	count := requests.Load()
+	if err := manager.update(context.Background(), nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != count {
+		t.Fatalf("asset updated before its interval")
+	}
+
+	now = now.Add(defaultAssetUpdateInterval + time.Minute)
+	version := info.Version
+	if err := manager.update(context.Background(), nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != count+1 || info.Version != version || info.UpdatedAt != now.Unix() {
+		t.Fatalf("unexpected asset after not modified: %+v", info)
+	}
+
+	body.Store([]byte("not a rule-set"))
+	if err := manager.update(context.Background(), []string{"geosite-test"}, true); err == nil {
+		t.Fatalf("invalid rule-set accepted")
+	}
+	if info.Error == "" || info.Version != version {
+		t.Fatalf("unexpected asset after invalid download: %+v", info)
+	}
+	saved, _ = os.ReadFile(manager.path("geosite-test"))
+	if !bytes.Equal(saved, content) {
+		t.Fatalf("invalid rule-set replaced the asset")
+	}
+
+	if err := manager.update(context.Background(), []string{"missing"}, false); err == nil {
+		t.Fatalf("unknown asset accepted")
+	}
+}
+
+func TestSeedAssets(t *testing.T) {
+	bundle := t.TempDir()
+	dir := filepath.Join(t.TempDir(), "assets")
+	content := testRuleSetContent(t, "example.com")
+	os.WriteFile(filepath.Join(bundle, "geoip-test.srs"), content, 0o644)
+	os.WriteFile(filepath.Join(bundle, "readme.txt"), []byte("skip"), 0o644)
+	if err := seedAssets(bundle, dir); err != nil {
+		t.Fatal(err)
+	}
+	if saved, err := os.ReadFile(filepath.Join(dir, "geoip-test.srs")); err != nil || !bytes.Equal(saved, content) {
+		t.Fatalf("asset not seeded: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "readme.txt")); err == nil {
+		t.Fatalf("non rule-set file seeded")
+	}
+
+	newer := testRuleSetContent(t, "example.org")
+	os.WriteFile(filepath.Join(dir, "geoip-test.srs"), newer, 0o644)
+	if err := seedAssets(bundle, dir); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := os.ReadFile(filepath.Join(dir, "geoip-test.srs")); !bytes.Equal(saved, newer) {
+		t.Fatalf("seeding replaced an updated asset")
+	}
+}
+
+func TestAssetManagerIgnoresFilesOfAnotherURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	infos := map[string]*AssetInfo{
+		"user-rule-set-0": {Id: "user-rule-set-0", Url: server.URL + "/ads.srs", Version: "v"},
+	}
+	manager := newAssetManager(t.TempDir())
+	manager.client = server.Client
+	manager.load = func() ([]*AssetInfo, error) {
+		var list []*AssetInfo
+		for _, info := range infos {
+			list = append(list, info)
+		}
+		return list, nil
+	}
+	manager.save = func(info *AssetInfo) error { infos[info.Id] = info; return nil }
+	os.MkdirAll(manager.dir, 0o755)
+	os.WriteFile(manager.path("user-rule-set-0"), testRuleSetContent(t, "ads.example"), 0o644)
+
+	// The rules were reordered, the tag now names another rule-set.
+	options := &option.Options{Route: &option.RouteOptions{RuleSet: []option.RuleSet{{
+		Type:          C.RuleSetTypeRemote,
+		Tag:           "user-rule-set-0",
+		Format:        C.RuleSetFormatBinary,
+		RemoteOptions: option.RemoteRuleSet{URL: server.URL + "/streaming.srs"},
+	}}}}
+	manager.apply(options)
+	if options.Route.RuleSet[0].Type != C.RuleSetTypeRemote {
+		t.Fatalf("rule-set of another url served from disk")
+	}
+	manager.access.Lock()
+	defer manager.access.Unlock()
+	if manager.exists("user-rule-set-0") {
+		t.Fatalf("file of the previous url kept")
+	}
+	if info := infos["user-rule-set-0"]; info.Url != server.URL+"/streaming.srs" || info.Version != "" {
+		t.Fatalf("unexpected asset after url change: %+v", info)
+	}
+}
+
+func TestAssetManagerAppliesDuringDownload(t *testing.T) {
+	requested, release := make(chan struct{}), make(chan struct{})
+	content := testRuleSetContent(t, "example.com")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/slow.srs" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		close(requested)
+		<-release
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	infos := map[string]*AssetInfo{
+		"geosite-test": {Id: "geosite-test", Url: server.URL + "/slow.srs"},
+	}
+	manager := newAssetManager(t.TempDir())
+	manager.client = server.Client
+	manager.load = func() ([]*AssetInfo, error) {
+		var list []*AssetInfo
+		for _, info := range infos {
+			list = append(list, info)
+		}
+		return list, nil
+	}
+	manager.save = func(info *AssetInfo) error { infos[info.Id] = info; return nil }
+
+	updated := make(chan error, 1)
+	go func() { updated <- manager.update(context.Background(), []string{"geosite-test"}, true) }()
+	<-requested
+
+	applied := make(chan struct{})
+	go func() {
+		manager.apply(&option.Options{Route: &option.RouteOptions{RuleSet: []option.RuleSet{{
+			Type:          C.RuleSetTypeRemote,
+			Tag:           "geosite-test",
+			Format:        C.RuleSetFormatBinary,
+			RemoteOptions: option.RemoteRuleSet{URL: server.URL + "/moved.srs"},
+		}}}})
+		close(applied)
+	}()
+	select {
+	case <-applied:
+	case <-time.After(5 * time.Second):
+		t.Fatal("apply blocked by a download")
+	}
+
+	close(release)
+	if err := <-updated; err != nil {
+		t.Fatal(err)
+	}
+	manager.access.Lock()
+	defer manager.access.Unlock()
+	if info := infos["geosite-test"]; info.Url != server.URL+"/moved.srs" || info.Version != "" {
+		t.Fatalf("download of the previous url stored: %+v", info)
+	}
+	if _, err := os.Stat(manager.path("geosite-test")); err == nil {
+		t.Fatalf("file of the previous url written")
+	}
+}
@@ -0,0 +1,169 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	"github.com/sagernet/sing-box/experimental/libbox"
+	"github.com/sagernet/sing-box/log"
+	"google.golang.org/grpc"
+)
+
+const clashModeTimeout = 3 * time.Second
+
+var (
+	modeObserver = NewObserver[*pb.ClashModeResponse](10)
+	modeClient   *libbox.CommandClient
+
+	clashModeAccess sync.Mutex
+	clashModeList   []string
+)
+
+func (cch *CommandClientHandler) InitializeClashMode(modeList libbox.StringIterator, currentMode string) {
+	setClashModeList(modeList)
+	modeObserver.Emit(clashModeResponse(currentMode))
+}
+
+func (cch *CommandClientHandler) UpdateClashMode(newMode string) {
+	modeObserver.Emit(clashModeResponse(newMode))
+}
+
+// clashModeReader hands the clash mode to a single reader instead of
+// emitting it to the mode listeners.
+type clashModeReader struct {
+	CommandClientHandler
+	modes chan *pb.ClashModeResponse
+}
+
+func (r *clashModeReader) InitializeClashMode(modeList libbox.StringIterator, currentMode string) {
+	setClashModeList(modeList)
+	r.UpdateClashMode(currentMode)
+}
+
+func (r *clashModeReader) UpdateClashMode(newMode string) {
+	select {
+	case r.modes <- clashModeResponse(newMode):
+	default:
+	}
+}
+
+func setClashModeList(modeList libbox.StringIterator) {
+	var modes []string
+	for modeList != nil && modeList.HasNext() {
+		modes = append(modes, modeList.Next())
+	}
+	clashModeAccess.Lock()
+	clashModeList = modes
+	clashModeAccess.Unlock()
+}
+
+func clashModeResponse(mode string) *pb.ClashModeResponse {
+	clashModeAccess.Lock()
+	defer clashModeAccess.Unlock()
+	return &pb.ClashModeResponse{
+		ResponseCode: pb.ResponseCode_OK,
+		Mode:         mode,
+		Modes:        append([]string(nil), clashModeList...),
+	}
+}
+
+func clashModeFailed(err error) (*pb.ClashModeResponse, error) {
+	return &pb.ClashModeResponse{
+		ResponseCode: pb.ResponseCode_FAILED,
+		Message:      err.Error(),
+	}, err
+}
+
+func (s *CoreService) GetMode(ctx context.Context, req *pb.Empty) (*pb.ClashModeResponse, error) {
+	return GetMode()
+}
+
+// GetMode asks the running service for its clash modes and the current one.
+// The mode listeners are not notified.
+func GetMode() (*pb.ClashModeResponse, error) {
+	if CoreState != pb.CoreState_STARTED {
+		return clashModeFailed(fmt.Errorf("instance not started"))
+	}
+	reader := &clashModeReader{
+		CommandClientHandler: CommandClientHandler{logger: log.StdLogger()},
+		modes:                make(chan *pb.ClashModeResponse, 1),
+	}
+	client := libbox.NewCommandClient(reader, &libbox.CommandClientOptions{Command: libbox.CommandClashMode})
+	if err := client.Connect(); err != nil {
+		return clashModeFailed(err)
+	}
+	defer client.Disconnect()
+
+	select {
+	case resp := <-reader.modes:
+		return resp, nil
+	case <-time.After(clashModeTimeout):
+		return clashModeFailed(fmt.Errorf("timeout reading clash mode"))
+	}
+}
+
+func (s *CoreService) SetMode(ctx context.Context, in *pb.ClashModeRequest) (*pb.ClashModeResponse, error) {
+	return SetMode(in)
+}
+
+// SetMode switches the clash mode of the running service. Mode names are
+// matched case-insensitively; unknown modes are rejected since the service
+// would silently ignore them.
+func SetMode(in *pb.ClashModeRequest) (*pb.ClashModeResponse, error) {
+	current, err := GetMode()
+	if err != nil {
+		return current, err
+	}
+	mode := ""
+	for _, name := range current.Modes {
+		if strings.EqualFold(name, in.Mode) {
+			mode = name
+			break
+		}
+	}
+	if mode == "" {
+		return clashModeFailed(fmt.Errorf("unknown mode %q, available: %s", in.Mode, strings.Join(current.Modes, ", ")))
+	}
+	if err := libbox.NewStandaloneCommandClient().SetClashMode(mode); err != nil {
+		return clashModeFailed(err)
+	}
+	Log(pb.LogLevel_INFO, pb.LogType_CORE, "Clash mode set to "+mode)
+	current.Mode = mode
+	return current, nil
+}
+
+func (s *CoreService) ModeListener(req *pb.Empty, stream grpc.ServerStreamingServer[pb.ClashModeResponse]) error {
+	modeSub, done, err := modeObserver.Subscribe()
+	if err != nil {
+		return err
+	}
+	defer modeObserver.UnSubscribe(modeSub)
+
+	if modeClient == nil {
+		modeClient = libbox.NewCommandClient(
+			&CommandClientHandler{logger: log.StdLogger()},
+			&libbox.CommandClientOptions{Command: libbox.CommandClashMode},
+		)
+
+		defer func() {
+			modeClient.Disconnect()
+			modeClient = nil
+		}()
+		modeClient.Connect()
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-done:
+			return nil
+		case resp := <-modeSub:
+			stream.Send(resp)
+		}
+	}
+}
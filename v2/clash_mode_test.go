@@ -0,0 +1,28 @@
+package v2
+
+import (
+	"testing"
+
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+)
+
+func TestClashModeReaderDoesNotNotifyListeners(t *testing.T) {
+	modeSub, _, err := modeObserver.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer modeObserver.UnSubscribe(modeSub)
+
+	reader := &clashModeReader{modes: make(chan *pb.ClashModeResponse, 1)}
+	reader.InitializeClashMode(nil, "Rule")
+	reader.UpdateClashMode("Global")
+
+	if resp := <-reader.modes; resp.Mode != "Rule" {
+		t.Fatalf("read mode = %s", resp.Mode)
+	}
+	select {
+	case resp := <-modeSub:
+		t.Fatalf("listener notified of %s", resp.Mode)
+	default:
+	}
+}
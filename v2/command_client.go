@@ -70,14 +70,6 @@ func (cch *CommandClientHandler) WriteGroups(message libbox.OutboundGroupIterato
 	mainOutboundsInfoObserver.Emit(groups)
 }
 
-func (cch *CommandClientHandler) InitializeClashMode(modeList libbox.StringIterator, currentMode string) {
-	cch.logger.Debug("initial clash mode: ", currentMode)
-}
-
-func (cch *CommandClientHandler) UpdateClashMode(newMode string) {
-	cch.logger.Debug("update clash mode: ", newMode)
-}
-
 func (cch *CommandClientHandler) WriteConnections(message *libbox.Connections) {
 	// TODO: surface active connections via observer if needed
 }
@@ -76,6 +76,8 @@ func StopAndAlert(msgType pb.MessageType, message string) {
 		Box.Close()
 		Box = nil
 	}
+	runningOptions = nil
+	runningProfileId = ""
 	if oldCommandServer != nil {
 		oldCommandServer.Close()
 	}
@@ -95,10 +97,12 @@ func Start(in *pb.StartRequest) (*pb.CoreInfoResponse, error) {
 		Log(pb.LogLevel_FATAL, pb.LogType_CORE, err.Error())
 		StopAndAlert(pb.MessageType_UNEXPECTED_ERROR, err.Error())
 	})
+	reloadAccess.Lock()
+	defer reloadAccess.Unlock()
 	Log(pb.LogLevel_INFO, pb.LogType_CORE, "Starting")
 	if CoreState != pb.CoreState_STOPPED {
 		Log(pb.LogLevel_INFO, pb.LogType_CORE, "Starting0000")
-		Stop()
+		stop()
 		// return &pb.CoreInfoResponse{
 		// 	CoreState:   CoreState,
 		// 	MessageType: pb.MessageType_INSTANCE_NOT_STOPPED,
@@ -107,7 +111,7 @@ func Start(in *pb.StartRequest) (*pb.CoreInfoResponse, error) {
 	Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Starting Core")
 	SetCoreStatus(pb.CoreState_STARTING, pb.MessageType_EMPTY, "")
 	libbox.SetMemoryLimit(!in.DisableMemoryLimit)
-	resp, err := StartService(in)
+	resp, err := startService(in)
 	return resp, err
 }
 
@@ -116,43 +120,21 @@ func (s *CoreService) StartService(ctx context.Context, in *pb.StartRequest) (*p
 }
 
 func StartService(in *pb.StartRequest) (*pb.CoreInfoResponse, error) {
-	Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Starting Core Service")
-	currentOptions := ensureHiddifyOptions()
-	content := in.ConfigContent
-	if content == "" {
-
-		activeConfigPath = in.ConfigPath
-		fileContent, err := os.ReadFile(activeConfigPath)
-		if err != nil {
-			Log(pb.LogLevel_FATAL, pb.LogType_CORE, err.Error())
-			resp := SetCoreStatus(pb.CoreState_STOPPED, pb.MessageType_ERROR_READING_CONFIG, err.Error())
-			StopAndAlert(pb.MessageType_UNEXPECTED_ERROR, err.Error())
-			return resp, err
-		}
-		content = string(fileContent)
-	}
-	Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Parsing Config")
-
-	parsedContent, err := readOptions(content)
-	Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Parsed")
+	reloadAccess.Lock()
+	defer reloadAccess.Unlock()
+	return startService(in)
+}
 
+func startService(in *pb.StartRequest) (*pb.CoreInfoResponse, error) {
+	Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Starting Core Service")
+	profileId := startProfileId(in)
+	parsedContent, msgType, err := readStartOptions(in)
 	if err != nil {
 		Log(pb.LogLevel_FATAL, pb.LogType_CORE, err.Error())
-		resp := SetCoreStatus(pb.CoreState_STOPPED, pb.MessageType_ERROR_PARSING_CONFIG, err.Error())
+		resp := SetCoreStatus(pb.CoreState_STOPPED, msgType, err.Error())
 		StopAndAlert(pb.MessageType_UNEXPECTED_ERROR, err.Error())
 		return resp, err
 	}
-	if !in.EnableRawConfig {
-		Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Building config")
-		parsedContentTmp, err := config.BuildConfig(*currentOptions, parsedContent)
-		if err != nil {
-			Log(pb.LogLevel_FATAL, pb.LogType_CORE, err.Error())
-			resp := SetCoreStatus(pb.CoreState_STOPPED, pb.MessageType_ERROR_BUILDING_CONFIG, err.Error())
-			StopAndAlert(pb.MessageType_UNEXPECTED_ERROR, err.Error())
-			return resp, err
-		}
-		parsedContent = *parsedContentTmp
-	}
 	Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Saving config")
 	currentBuildConfigPath := filepath.Join(sWorkingPath, "current-config.json")
 	config.SaveCurrentConfig(currentBuildConfigPath, parsedContent)
@@ -191,6 +173,9 @@ func StartService(in *pb.StartRequest) (*pb.CoreInfoResponse, error) {
 		return resp, err
 	}
 	Box = instance
+	runningOptions = &parsedContent
+	runningTunService = ensureHiddifyOptions().EnableTunService
+	runningProfileId = profileId
 	if in.EnableOldCommandServer {
 		oldCommandServer.SetService(Box)
 	}
@@ -199,6 +184,62 @@ func StartService(in *pb.StartRequest) (*pb.CoreInfoResponse, error) {
 	return resp, nil
 }
 
+// readStartOptions reads and builds the sing-box options for a start request.
+// On failure it returns the message type describing the failed step.
+func readStartOptions(in *pb.StartRequest) (option.Options, pb.MessageType, error) {
+	currentOptions := ensureHiddifyOptions()
+	content := in.ConfigContent
+	if profileId := startProfileId(in); profileId != "" {
+		profileContent, err := loadProfileConfig(profileId, currentOptions)
+		if err != nil {
+			return option.Options{}, pb.MessageType_ERROR_READING_CONFIG, err
+		}
+		content = profileContent
+		// The profile has no file of its own, StartService points
+		// activeConfigPath at the config it saves instead.
+		activeConfigPath = ""
+	} else if content == "" {
+
+		activeConfigPath = in.ConfigPath
+		fileContent, err := os.ReadFile(activeConfigPath)
+		if err != nil {
+			return option.Options{}, pb.MessageType_ERROR_READING_CONFIG, err
+		}
+		content = string(fileContent)
+	}
+	Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Parsing Config")
+
+	parsedContent, err := readOptions(content)
+	Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Parsed")
+
+	if err != nil {
+		return option.Options{}, pb.MessageType_ERROR_PARSING_CONFIG, err
+	}
+	if !in.EnableRawConfig {
+		Log(pb.LogLevel_DEBUG, pb.LogType_CORE, "Building config")
+		parsedContentTmp, err := config.BuildConfig(*currentOptions, parsedContent)
+		if err != nil {
+			return option.Options{}, pb.MessageType_ERROR_BUILDING_CONFIG, err
+		}
+		parsedContent = *parsedContentTmp
+		if assets != nil {
+			assets.apply(&parsedContent)
+		}
+	}
+	return parsedContent, pb.MessageType_EMPTY, nil
+}
+
+// startProfileId returns the profile a start request runs: the requested one,
+// or the active profile when the request names no config.
+func startProfileId(in *pb.StartRequest) string {
+	if in.ProfileId == "" && in.ConfigContent == "" && in.ConfigPath == "" {
+		if profile := activeProfile(); profile != nil {
+			return profile.Id
+		}
+	}
+	return in.ProfileId
+}
+
 func startInstanceWithTimeout(instance *libbox.BoxService) error {
 	type result struct {
 		err error
@@ -264,27 +305,47 @@ func Parse(in *pb.ParseRequest) (*pb.ParseResponse, error) {
 	}
 
 	configOpt := ensureHiddifyOptions()
-	config, err := config.ParseConfigContent(content, true, configOpt, false)
+	var parsed []byte
+	var report *config.ParseReport
+	var err error
+	if in.AllowPartial {
+		parsed, report, err = config.ParseConfigContentWithReport(content, true, configOpt, false)
+	} else {
+		parsed, err = config.ParseConfigContent(content, true, configOpt, false)
+	}
 	if err != nil {
-		return &pb.ParseResponse{
-			ResponseCode: pb.ResponseCode_FAILED,
-			Message:      err.Error(),
-		}, err
+		return parseResponse(pb.ResponseCode_FAILED, nil, err.Error(), report), err
 	}
 	if in.ConfigPath != "" {
-		err = os.WriteFile(in.ConfigPath, config, 0o644)
+		err = os.WriteFile(in.ConfigPath, parsed, 0o644)
 		if err != nil {
-			return &pb.ParseResponse{
-				ResponseCode: pb.ResponseCode_FAILED,
-				Message:      err.Error(),
-			}, err
+			return parseResponse(pb.ResponseCode_FAILED, nil, err.Error(), report), err
 		}
 	}
-	return &pb.ParseResponse{
-		ResponseCode: pb.ResponseCode_OK,
-		Content:      string(config),
-		Message:      "",
-	}, err
+	return parseResponse(pb.ResponseCode_OK, parsed, "", report), err
+}
+
+func parseResponse(code pb.ResponseCode, content []byte, message string, report *config.ParseReport) *pb.ParseResponse {
+	res := &pb.ParseResponse{
+		ResponseCode: code,
+		Content:      string(content),
+		Message:      message,
+	}
+	if report == nil {
+		return res
+	}
+	res.Total = int32(report.Total)
+	res.Accepted = int32(report.Accepted)
+	for _, diagnostic := range report.Diagnostics {
+		res.Diagnostics = append(res.Diagnostics, &pb.ParseDiagnostic{
+			Tag:    diagnostic.Tag,
+			Type:   diagnostic.Type,
+			Source: diagnostic.Source,
+			Index:  int32(diagnostic.Index),
+			Error:  diagnostic.Error,
+		})
+	}
+	return res
 }
 
 func (s *CoreService) ChangeHiddifySettings(ctx context.Context, in *pb.ChangeHiddifySettingsRequest) (*pb.CoreInfoResponse, error) {
@@ -371,7 +432,12 @@ func Stop() (*pb.CoreInfoResponse, error) {
 		Log(pb.LogLevel_FATAL, pb.LogType_CORE, err.Error())
 		StopAndAlert(pb.MessageType_UNEXPECTED_ERROR, err.Error())
 	})
+	reloadAccess.Lock()
+	defer reloadAccess.Unlock()
+	return stop()
+}
 
+func stop() (*pb.CoreInfoResponse, error) {
 	if CoreState != pb.CoreState_STARTED {
 		Log(pb.LogLevel_FATAL, pb.LogType_CORE, "Core is not started")
 		return &pb.CoreInfoResponse{
@@ -402,6 +468,8 @@ func Stop() (*pb.CoreInfoResponse, error) {
 		}, fmt.Errorf("Error while stopping the service.")
 	}
 	Box = nil
+	runningOptions = nil
+	runningProfileId = ""
 	if oldCommandServer != nil {
 		err = oldCommandServer.Close()
 		if err != nil {
@@ -421,12 +489,16 @@ func (s *CoreService) Restart(ctx context.Context, in *pb.StartRequest) (*pb.Cor
 	return Restart(in)
 }
 
+// Restart applies a start request to the running core, reloading the config
+// in place when it can and stopping and starting the core otherwise.
 func Restart(in *pb.StartRequest) (*pb.CoreInfoResponse, error) {
 	defer config.DeferPanicToError("restart", func(err error) {
 		Log(pb.LogLevel_FATAL, pb.LogType_CORE, err.Error())
 		StopAndAlert(pb.MessageType_UNEXPECTED_ERROR, err.Error())
 	})
 	log.Debug("[Service] Restarting")
+	reloadAccess.Lock()
+	defer reloadAccess.Unlock()
 
 	if CoreState != pb.CoreState_STARTED {
 		return &pb.CoreInfoResponse{
@@ -443,15 +515,38 @@ func Restart(in *pb.StartRequest) (*pb.CoreInfoResponse, error) {
 		}, fmt.Errorf("instance not found")
 	}
 
-	resp, err := Stop()
+	libbox.SetMemoryLimit(!in.DisableMemoryLimit)
+	if restartNeedsStop(in) {
+		resp, err := stop()
+		if err != nil {
+			return resp, err
+		}
+		SetCoreStatus(pb.CoreState_STARTING, pb.MessageType_EMPTY, "")
+		<-time.After(250 * time.Millisecond)
+		return startService(in)
+	}
+	profileId := startProfileId(in)
+	options, msgType, err := readStartOptions(in)
 	if err != nil {
-		return resp, err
+		Log(pb.LogLevel_ERROR, pb.LogType_CORE, err.Error())
+		return &pb.CoreInfoResponse{
+			CoreState:   CoreState,
+			MessageType: msgType,
+			Message:     err.Error(),
+		}, err
 	}
+	resp, err := reload(options)
+	if err == nil {
+		runningProfileId = profileId
+	}
+	return resp, err
+}
 
-	SetCoreStatus(pb.CoreState_STARTING, pb.MessageType_EMPTY, "")
-	<-time.After(250 * time.Millisecond)
-
-	libbox.SetMemoryLimit(!in.DisableMemoryLimit)
-	resp, gErr := StartService(in)
-	return resp, gErr
+// restartNeedsStop reports whether a restart request changes what only a
+// full start sets up: the command server, the tunnel service or the delay
+// before starting.
+func restartNeedsStop(in *pb.StartRequest) bool {
+	return in.EnableOldCommandServer != (oldCommandServer != nil) ||
+		in.DelayStart ||
+		ensureHiddifyOptions().EnableTunService != runningTunService
 }
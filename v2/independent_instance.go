@@ -45,6 +45,7 @@ func RunInstance(hiddifySettings *config.HiddifyOptions, singconfig *option.Opti
 	hiddifySettings.InboundOptions.EnableTunService = false
 	hiddifySettings.InboundOptions.SetSystemProxy = false
 	hiddifySettings.InboundOptions.TProxyPort = 0
+	hiddifySettings.InboundOptions.RedirectPort = 0
 	hiddifySettings.InboundOptions.LocalDnsPort = 0
 	hiddifySettings.Region = "other"
 	hiddifySettings.BlockAds = false
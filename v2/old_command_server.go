@@ -15,6 +15,8 @@ type CommandServerHandler struct {
 
 func (csh *CommandServerHandler) ServiceReload() error {
 	csh.logger.Trace("Reloading service")
+	reloadAccess.Lock()
+	defer reloadAccess.Unlock()
 	SetCoreStatus(pb.CoreState_STARTING, pb.MessageType_EMPTY, "")
 
 	if oldCommandServer != nil {
@@ -25,7 +27,7 @@ func (csh *CommandServerHandler) ServiceReload() error {
 		Box.Close()
 		Box = nil
 	}
-	_, err := StartService(&pb.StartRequest{
+	_, err := startService(&pb.StartRequest{
 		EnableOldCommandServer: true,
 		DelayStart:             true,
 	})
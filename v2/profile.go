@@ -0,0 +1,318 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/hiddify/hiddify-core/config"
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	"github.com/hiddify/hiddify-core/v2/db"
+)
+
+// Profile is a stored subscription. Url is the remote url for remote profiles
+// and the file path for local ones; inline profiles keep their Content.
+type Profile struct {
+	Id             string
+	Name           string
+	Type           pb.ProfileType
+	Url            string
+	Content        string
+	UpdateInterval int // hours, 0 to use the provider value
+	LastUpdate     int64
+	Active         bool
+
+	ProviderInterval int // hours, from profile-update-interval
+	ETag             string
+	LastModified     string
+}
+
+// profileAccess serializes the read-modify-write updates of stored profiles.
+var profileAccess sync.Mutex
+
+var errProfileNotFound = errors.New("profile not found")
+
+func profileTable() *db.Table[Profile] {
+	return db.GetTable[Profile]()
+}
+
+// getProfile returns the stored profile with the given id, or an error
+// wrapping errProfileNotFound when there is none.
+func getProfile(id string) (*Profile, error) {
+	profiles, err := profileTable().All()
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		if profile.Id == id {
+			return profile, nil
+		}
+	}
+	return nil, fmt.Errorf("profile %s: %w", id, errProfileNotFound)
+}
+
+// profileResponseCode is NOT_FOUND for unknown profiles and FAILED otherwise.
+func profileResponseCode(err error) pb.ResponseCode {
+	if errors.Is(err, errProfileNotFound) {
+		return pb.ResponseCode_NOT_FOUND
+	}
+	return pb.ResponseCode_FAILED
+}
+
+// updateStoredProfile applies update to the stored profile with the given
+// id, keeping the changes made to its other fields in the meantime.
+func updateStoredProfile(id string, update func(*Profile)) error {
+	profileAccess.Lock()
+	defer profileAccess.Unlock()
+	profile, err := getProfile(id)
+	if err != nil {
+		return err
+	}
+	update(profile)
+	return profileTable().UpdateInsert(profile)
+}
+
+func (p *Profile) toPb() *pb.Profile {
+	return &pb.Profile{
+		Id:             p.Id,
+		Name:           p.Name,
+		Type:           p.Type,
+		Url:            p.Url,
+		Content:        p.Content,
+		UpdateInterval: int32(p.UpdateInterval),
+		LastUpdate:     p.LastUpdate,
+		Active:         p.Active,
+	}
+}
+
+func profileFromPb(in *pb.Profile) *Profile {
+	return &Profile{
+		Id:             in.Id,
+		Name:           in.Name,
+		Type:           in.Type,
+		Url:            in.Url,
+		Content:        in.Content,
+		UpdateInterval: int(in.UpdateInterval),
+		LastUpdate:     in.LastUpdate,
+		Active:         in.Active,
+	}
+}
+
+func validateProfile(profile *Profile) error {
+	switch profile.Type {
+	case pb.ProfileType_PROFILE_LOCAL, pb.ProfileType_PROFILE_REMOTE:
+		if profile.Url == "" {
+			return fmt.Errorf("profile %s has no url", profile.Name)
+		}
+	case pb.ProfileType_PROFILE_CONTENT:
+		if profile.Content == "" {
+			return fmt.Errorf("profile %s has no content", profile.Name)
+		}
+	default:
+		return fmt.Errorf("unknown profile type %v", profile.Type)
+	}
+	return nil
+}
+
+// readProfileContent returns the raw subscription content of a profile.
+// Remote profiles are fetched like the refresher does, recording the cache
+// headers and the provider interval, and fall back to the last good copy
+// when the fetch fails.
+func readProfileContent(profile *Profile) (ConfigResult, error) {
+	switch profile.Type {
+	case pb.ProfileType_PROFILE_CONTENT:
+		return ConfigResult{Config: profile.Content}, nil
+	case pb.ProfileType_PROFILE_REMOTE:
+		dir := profileCacheDir()
+		request := *profile
+		if _, err := os.Stat(profileCachePath(dir, profile.Id)); err != nil {
+			// Without a cached copy a not modified answer has nothing to use.
+			request.ETag, request.LastModified = "", ""
+		}
+		_, err := newSubscriptionRefresher(dir).fetch(context.Background(), &request)
+		cached, cacheErr := readProfileCache(dir, profile.Id)
+		if cacheErr != nil {
+			if err == nil {
+				err = cacheErr
+			}
+			return ConfigResult{}, err
+		}
+		if err != nil {
+			Log(pb.LogLevel_WARNING, pb.LogType_CONFIG, fmt.Sprintf("using cached copy of profile %s: %v", profile.Name, err))
+		}
+		return ConfigResult{Config: cached}, nil
+	}
+	return readConfigContent(profile.Url)
+}
+
+// loadProfileConfig reads and parses the profile with the given id and marks
+// it as updated.
+func loadProfileConfig(id string, options *config.HiddifyOptions) (string, error) {
+	profile, err := getProfile(id)
+	if err != nil {
+		return "", err
+	}
+	result, err := readProfileContent(profile)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := config.ParseConfigContent(result.Config, true, options, false)
+	if err != nil {
+		return "", err
+	}
+	lastUpdate := time.Now().Unix()
+	if err := updateStoredProfile(id, func(stored *Profile) { stored.LastUpdate = lastUpdate }); err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CONFIG, err.Error())
+	}
+	return string(parsed), nil
+}
+
+func (s *CoreService) AddProfile(ctx context.Context, in *pb.Profile) (*pb.ProfileResponse, error) {
+	return AddProfile(in)
+}
+
+func AddProfile(in *pb.Profile) (*pb.ProfileResponse, error) {
+	profile := profileFromPb(in)
+	profile.Id = uuid.Must(uuid.NewV4()).String()
+	profile.Active = false
+	return saveProfile(profile)
+}
+
+func (s *CoreService) UpdateProfile(ctx context.Context, in *pb.Profile) (*pb.ProfileResponse, error) {
+	return UpdateProfile(in)
+}
+
+func UpdateProfile(in *pb.Profile) (*pb.ProfileResponse, error) {
+	profileAccess.Lock()
+	defer profileAccess.Unlock()
+	current, err := getProfile(in.Id)
+	if err != nil {
+		return profileFailed(err)
+	}
+	profile := profileFromPb(in)
+	profile.Active = current.Active
+	if profile.Url == current.Url {
+		profile.ProviderInterval = current.ProviderInterval
+		profile.ETag = current.ETag
+		profile.LastModified = current.LastModified
+	}
+	return saveProfile(profile)
+}
+
+func saveProfile(profile *Profile) (*pb.ProfileResponse, error) {
+	if err := validateProfile(profile); err != nil {
+		return profileFailed(err)
+	}
+	if err := profileTable().UpdateInsert(profile); err != nil {
+		return profileFailed(err)
+	}
+	return &pb.ProfileResponse{
+		ResponseCode: pb.ResponseCode_OK,
+		Profile:      profile.toPb(),
+	}, nil
+}
+
+func profileFailed(err error) (*pb.ProfileResponse, error) {
+	return &pb.ProfileResponse{
+		ResponseCode: profileResponseCode(err),
+		Message:      err.Error(),
+	}, err
+}
+
+func (s *CoreService) ListProfiles(ctx context.Context, _ *pb.Empty) (*pb.ProfileList, error) {
+	return ListProfiles()
+}
+
+func ListProfiles() (*pb.ProfileList, error) {
+	profiles, err := profileTable().All()
+	if err != nil {
+		return nil, err
+	}
+	list := &pb.ProfileList{}
+	for _, profile := range profiles {
+		list.Items = append(list.Items, profile.toPb())
+	}
+	return list, nil
+}
+
+func (s *CoreService) DeleteProfile(ctx context.Context, in *pb.ProfileIdRequest) (*pb.Response, error) {
+	return DeleteProfile(in)
+}
+
+func DeleteProfile(in *pb.ProfileIdRequest) (*pb.Response, error) {
+	profileAccess.Lock()
+	defer profileAccess.Unlock()
+	profile, err := getProfile(in.Id)
+	if err == nil {
+		err = profileTable().Delete(in.Id)
+	}
+	if err != nil {
+		return &pb.Response{
+			ResponseCode: profileResponseCode(err),
+			Message:      err.Error(),
+		}, err
+	}
+	if profile.Type == pb.ProfileType_PROFILE_REMOTE {
+		if err := db.GetTable[SubscriptionInfo]().Delete(profile.Url); err != nil {
+			Log(pb.LogLevel_WARNING, pb.LogType_CONFIG, err.Error())
+		}
+		os.Remove(profileCachePath(profileCacheDir(), profile.Id))
+	}
+	return &pb.Response{
+		ResponseCode: pb.ResponseCode_OK,
+		Message:      "",
+	}, nil
+}
+
+func (s *CoreService) SetActiveProfile(ctx context.Context, in *pb.ProfileIdRequest) (*pb.Response, error) {
+	return SetActiveProfile(in)
+}
+
+// SetActiveProfile marks the profile as active. It only takes effect for the
+// running core on the next Start or Restart.
+func SetActiveProfile(in *pb.ProfileIdRequest) (*pb.Response, error) {
+	profileAccess.Lock()
+	defer profileAccess.Unlock()
+	table := profileTable()
+	profiles, err := table.All()
+	if err == nil {
+		err = fmt.Errorf("profile %s: %w", in.Id, errProfileNotFound)
+		for _, profile := range profiles {
+			if profile.Id == in.Id {
+				err = nil
+			}
+			profile.Active = profile.Id == in.Id
+		}
+	}
+	if err == nil {
+		err = table.UpdateInsert(profiles...)
+	}
+	if err != nil {
+		return &pb.Response{
+			ResponseCode: profileResponseCode(err),
+			Message:      err.Error(),
+		}, err
+	}
+	return &pb.Response{
+		ResponseCode: pb.ResponseCode_OK,
+		Message:      "",
+	}, nil
+}
+
+// activeProfile returns the profile marked as active, if any.
+func activeProfile() *Profile {
+	profiles, err := profileTable().All()
+	if err != nil {
+		return nil
+	}
+	for _, profile := range profiles {
+		if profile.Active {
+			return profile
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hiddify/hiddify-core/config"
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+)
+
+// useProfileStore points the relative database path and the working path at
+// a fresh directory for the test.
+func useProfileStore(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	workingPath := sWorkingPath
+	sWorkingPath = dir
+	t.Cleanup(func() { sWorkingPath = workingPath })
+}
+
+func addTestProfile(t *testing.T, name string) *pb.Profile {
+	t.Helper()
+	res, err := AddProfile(&pb.Profile{Name: name, Type: pb.ProfileType_PROFILE_CONTENT, Content: testSubscriptionBody, Active: true})
+	if err != nil || res.ResponseCode != pb.ResponseCode_OK {
+		t.Fatalf("AddProfile failed: %v %v", res, err)
+	}
+	return res.Profile
+}
+
+func TestProfileCRUD(t *testing.T) {
+	useProfileStore(t)
+
+	if res, err := AddProfile(&pb.Profile{Name: "empty", Type: pb.ProfileType_PROFILE_REMOTE}); err == nil || res.ResponseCode != pb.ResponseCode_FAILED {
+		t.Fatalf("profile without url accepted: %v", res)
+	}
+
+	first := addTestProfile(t, "first")
+	second := addTestProfile(t, "second")
+	if first.Id == "" || first.Id == second.Id || first.Active {
+		t.Fatalf("unexpected profiles %v %v", first, second)
+	}
+
+	first.Name = "renamed"
+	first.Active = true
+	res, err := UpdateProfile(first)
+	if err != nil || res.Profile.Name != "renamed" || res.Profile.Active {
+		t.Fatalf("UpdateProfile = %v %v", res, err)
+	}
+
+	list, err := ListProfiles()
+	if err != nil || len(list.Items) != 2 {
+		t.Fatalf("ListProfiles = %v %v", list, err)
+	}
+
+	if res, err := DeleteProfile(&pb.ProfileIdRequest{Id: second.Id}); err != nil || res.ResponseCode != pb.ResponseCode_OK {
+		t.Fatalf("DeleteProfile = %v %v", res, err)
+	}
+	list, _ = ListProfiles()
+	if len(list.Items) != 1 || list.Items[0].Id != first.Id || list.Items[0].Name != "renamed" {
+		t.Fatalf("profiles after delete = %v", list.Items)
+	}
+}
+
+func TestProfileUnknownId(t *testing.T) {
+	useProfileStore(t)
+	addTestProfile(t, "known")
+
+	unknown := &pb.ProfileIdRequest{Id: "unknown"}
+	if res, err := DeleteProfile(unknown); !errors.Is(err, errProfileNotFound) || res.ResponseCode != pb.ResponseCode_NOT_FOUND {
+		t.Fatalf("DeleteProfile = %v %v", res, err)
+	}
+	if res, err := SetActiveProfile(unknown); !errors.Is(err, errProfileNotFound) || res.ResponseCode != pb.ResponseCode_NOT_FOUND {
+		t.Fatalf("SetActiveProfile = %v %v", res, err)
+	}
+	if res, err := UpdateProfile(&pb.Profile{Id: "unknown", Type: pb.ProfileType_PROFILE_CONTENT, Content: testSubscriptionBody}); !errors.Is(err, errProfileNotFound) || res.ResponseCode != pb.ResponseCode_NOT_FOUND {
+		t.Fatalf("UpdateProfile = %v %v", res, err)
+	}
+	if _, _, err := readStartOptions(&pb.StartRequest{ProfileId: "unknown"}); !errors.Is(err, errProfileNotFound) {
+		t.Fatalf("readStartOptions error = %v", err)
+	}
+	if list, _ := ListProfiles(); len(list.Items) != 1 {
+		t.Fatalf("profiles changed: %v", list.Items)
+	}
+}
+
+func TestSetActiveProfile(t *testing.T) {
+	useProfileStore(t)
+	first := addTestProfile(t, "first")
+	second := addTestProfile(t, "second")
+
+	for _, id := range []string{first.Id, second.Id} {
+		if res, err := SetActiveProfile(&pb.ProfileIdRequest{Id: id}); err != nil || res.ResponseCode != pb.ResponseCode_OK {
+			t.Fatalf("SetActiveProfile = %v %v", res, err)
+		}
+		if active := activeProfile(); active == nil || active.Id != id {
+			t.Fatalf("active profile = %+v, want %s", active, id)
+		}
+	}
+	list, _ := ListProfiles()
+	for _, profile := range list.Items {
+		if profile.Active != (profile.Id == second.Id) {
+			t.Fatalf("profile %s active = %v", profile.Name, profile.Active)
+		}
+	}
+}
+
+func TestStartByProfileId(t *testing.T) {
+	useProfileStore(t)
+	options := HiddifyOptions
+	HiddifyOptions = config.DefaultHiddifyOptions()
+	configPath := activeConfigPath
+	t.Cleanup(func() {
+		HiddifyOptions = options
+		activeConfigPath = configPath
+	})
+
+	first := addTestProfile(t, "first")
+	second := addTestProfile(t, "second")
+	if _, err := SetActiveProfile(&pb.ProfileIdRequest{Id: first.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	activeConfigPath = "/old/config.json"
+	parsed, _, err := readStartOptions(&pb.StartRequest{ProfileId: second.Id})
+	if err != nil {
+		t.Fatalf("readStartOptions failed: %v", err)
+	}
+	if len(parsed.Outbounds) == 0 {
+		t.Fatal("profile outbounds not loaded")
+	}
+	if activeConfigPath != "" {
+		t.Fatalf("active config path still %q", activeConfigPath)
+	}
+	stored, err := getProfile(second.Id)
+	if err != nil || stored.LastUpdate == 0 {
+		t.Fatalf("last update not stored: %+v %v", stored, err)
+	}
+
+	// Without a profile id or config the active profile is started.
+	if _, _, err := readStartOptions(&pb.StartRequest{}); err != nil {
+		t.Fatalf("readStartOptions failed: %v", err)
+	}
+	if stored, _ := getProfile(first.Id); stored.LastUpdate == 0 {
+		t.Fatal("active profile not started")
+	}
+}
+
+func TestLoadRemoteProfileRecordsFetch(t *testing.T) {
+	useProfileStore(t)
+	var failing atomic.Bool
+	var conditional atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Profile-Update-Interval", "12")
+		w.Write([]byte(testSubscriptionBody))
+	}))
+	defer server.Close()
+
+	res, err := AddProfile(&pb.Profile{Name: "remote", Type: pb.ProfileType_PROFILE_REMOTE, Url: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := res.Profile.Id
+	if _, err := loadProfileConfig(id, config.DefaultHiddifyOptions()); err != nil {
+		t.Fatalf("loadProfileConfig failed: %v", err)
+	}
+	stored, err := getProfile(id)
+	if err != nil || stored.ETag != `"v1"` || stored.LastModified == "" || stored.ProviderInterval != 12 || stored.LastUpdate == 0 {
+		t.Fatalf("fetch not recorded: %+v %v", stored, err)
+	}
+
+	if _, err := loadProfileConfig(id, config.DefaultHiddifyOptions()); err != nil || conditional.Load() != 1 {
+		t.Fatalf("conditional load = %v, %d conditional requests", err, conditional.Load())
+	}
+
+	failing.Store(true)
+	if _, err := loadProfileConfig(id, config.DefaultHiddifyOptions()); err != nil {
+		t.Fatalf("cached copy not used: %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/hiddify/hiddify-core/config"
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultProfileUpdateInterval = 24 * time.Hour
+	refreshCheckInterval         = time.Minute
+	refreshMinBackoff            = time.Minute
+	refreshMaxBackoff            = 6 * time.Hour
+)
+
+var subscriptionUserAgent = "HiddifyNext/2.3.1 (" + runtime.GOOS + ") like ClashMeta v2ray sing-box 1.8.9"
+
+var (
+	refreshObserver    = NewObserver[*pb.ProfileRefreshEvent](10)
+	profileRefresher   *subscriptionRefresher
+	startRefresherOnce sync.Once
+)
+
+// subscriptionRefresher periodically refetches remote profiles using
+// conditional requests and keeps the last good copy of each in cacheDir.
+type subscriptionRefresher struct {
+	client     *http.Client
+	cacheDir   string
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time
+
+	loadProfiles  func() ([]*Profile, error)
+	updateProfile func(id string, update func(*Profile)) error
+	saveInfo      func(*SubscriptionInfo) error
+	emit          func(*pb.ProfileRefreshEvent)
+	reload        func(id string)
+
+	access   sync.Mutex
+	failures map[string]int
+	retryAt  map[string]time.Time
+}
+
+func newSubscriptionRefresher(cacheDir string) *subscriptionRefresher {
+	return &subscriptionRefresher{
+		client:        &http.Client{Timeout: time.Minute},
+		cacheDir:      cacheDir,
+		minBackoff:    refreshMinBackoff,
+		maxBackoff:    refreshMaxBackoff,
+		now:           time.Now,
+		loadProfiles:  profileTable().All,
+		updateProfile: updateStoredProfile,
+		saveInfo:      saveSubscriptionInfo,
+		emit:          refreshObserver.Emit,
+		reload:        reloadProfile,
+		failures:      make(map[string]int),
+		retryAt:       make(map[string]time.Time),
+	}
+}
+
+func startProfileRefresher() {
+	startRefresherOnce.Do(func() {
+		profileRefresher = newSubscriptionRefresher(profileCacheDir())
+		go profileRefresher.run(context.Background(), refreshCheckInterval)
+	})
+}
+
+func (r *subscriptionRefresher) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.refreshDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshDue refreshes every remote profile whose update interval elapsed
+// and whose backoff, if any, expired.
+func (r *subscriptionRefresher) refreshDue(ctx context.Context) {
+	profiles, err := r.loadProfiles()
+	if err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CONFIG, "failed to load profiles: "+err.Error())
+		return
+	}
+	now := r.now()
+	for _, profile := range profiles {
+		if profile.Type != pb.ProfileType_PROFILE_REMOTE {
+			continue
+		}
+		r.access.Lock()
+		retryAt, backingOff := r.retryAt[profile.Id]
+		r.access.Unlock()
+		if backingOff {
+			if now.Before(retryAt) {
+				continue
+			}
+		} else if now.Before(time.Unix(profile.LastUpdate, 0).Add(profile.updateInterval())) {
+			continue
+		}
+		r.refresh(ctx, profile)
+	}
+}
+
+// refresh fetches a single profile, reloads the core when it runs the profile
+// and the content changed, and emits the outcome.
+func (r *subscriptionRefresher) refresh(ctx context.Context, profile *Profile) *pb.ProfileRefreshEvent {
+	status, err := r.fetch(ctx, profile)
+	event := &pb.ProfileRefreshEvent{
+		ProfileId: profile.Id,
+		Status:    status,
+		Time:      r.now().Unix(),
+	}
+	r.access.Lock()
+	if err != nil {
+		r.failures[profile.Id]++
+		backoff := r.minBackoff << (r.failures[profile.Id] - 1)
+		if backoff > r.maxBackoff || backoff <= 0 {
+			backoff = r.maxBackoff
+		}
+		r.retryAt[profile.Id] = r.now().Add(backoff)
+		event.Message = err.Error()
+		event.RetryAt = r.retryAt[profile.Id].Unix()
+	} else {
+		delete(r.failures, profile.Id)
+		delete(r.retryAt, profile.Id)
+	}
+	r.access.Unlock()
+
+	if err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CONFIG, fmt.Sprintf("refreshing profile %s failed: %v", profile.Name, err))
+	} else if status == pb.RefreshStatus_REFRESH_SUCCESS {
+		r.reload(profile.Id)
+	}
+	r.emit(event)
+	return event
+}
+
+func (r *subscriptionRefresher) fetch(ctx context.Context, profile *Profile) (pb.RefreshStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, profile.Url, nil)
+	if err != nil {
+		return pb.RefreshStatus_REFRESH_FAILED, err
+	}
+	req.Header.Set("User-Agent", subscriptionUserAgent)
+	if profile.ETag != "" {
+		req.Header.Set("If-None-Match", profile.ETag)
+	}
+	if profile.LastModified != "" {
+		req.Header.Set("If-Modified-Since", profile.LastModified)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return pb.RefreshStatus_REFRESH_FAILED, err
+	}
+	defer resp.Body.Close()
+
+	status := pb.RefreshStatus_REFRESH_SUCCESS
+	var content string
+	var providerInterval int
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		status = pb.RefreshStatus_REFRESH_UNCHANGED
+	case http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return pb.RefreshStatus_REFRESH_FAILED, fmt.Errorf("failed to read config body: %w", err)
+		}
+		content = string(body)
+		if cached, err := readProfileCache(r.cacheDir, profile.Id); err == nil && cached == content {
+			status = pb.RefreshStatus_REFRESH_UNCHANGED
+		} else {
+			if _, err := config.ParseConfigContent(content, false, nil, false); err != nil {
+				return pb.RefreshStatus_REFRESH_FAILED, err
+			}
+			if err := writeProfileCache(r.cacheDir, profile.Id, content); err != nil {
+				return pb.RefreshStatus_REFRESH_FAILED, err
+			}
+		}
+		if err := r.saveInfo(parseSubscriptionInfo(profile.Url, resp.Header, content)); err != nil {
+			Log(pb.LogLevel_WARNING, pb.LogType_CONFIG, "failed to save subscription info: "+err.Error())
+		}
+		providerInterval, _ = extractRefreshInterval(resp.Header, content)
+	default:
+		return pb.RefreshStatus_REFRESH_FAILED, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	// The profile may have been edited during the request, only the fetch
+	// results are written back.
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	lastUpdate := r.now().Unix()
+	err = r.updateProfile(profile.Id, func(stored *Profile) {
+		if stored.Url != profile.Url {
+			return
+		}
+		if etag != "" {
+			stored.ETag = etag
+		}
+		if lastModified != "" {
+			stored.LastModified = lastModified
+		}
+		if resp.StatusCode == http.StatusOK {
+			stored.ProviderInterval = providerInterval
+		}
+		stored.LastUpdate = lastUpdate
+	})
+	if err != nil {
+		return pb.RefreshStatus_REFRESH_FAILED, err
+	}
+	return status, nil
+}
+
+func profileCacheDir() string {
+	return filepath.Join(sWorkingPath, "data", "profiles")
+}
+
+func profileCachePath(dir string, id string) string {
+	return filepath.Join(dir, id+".txt")
+}
+
+func readProfileCache(dir string, id string) (string, error) {
+	content, err := os.ReadFile(profileCachePath(dir, id))
+	return string(content), err
+}
+
+// writeProfileCache stores the last good content of a profile, replacing the
+// previous copy atomically.
+func writeProfileCache(dir string, id string, content string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp := profileCachePath(dir, id) + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, profileCachePath(dir, id))
+}
+
+// updateInterval returns how often the profile should be refreshed: the user
+// value first, then the provider value, then a day.
+func (p *Profile) updateInterval() time.Duration {
+	if p.UpdateInterval > 0 {
+		return time.Duration(p.UpdateInterval) * time.Hour
+	}
+	if p.ProviderInterval > 0 {
+		return time.Duration(p.ProviderInterval) * time.Hour
+	}
+	return defaultProfileUpdateInterval
+}
+
+func (s *CoreService) ProfileRefreshListener(req *pb.Empty, stream grpc.ServerStreamingServer[pb.ProfileRefreshEvent]) error {
+	refreshSub, done, err := refreshObserver.Subscribe()
+	if err != nil {
+		return err
+	}
+	defer refreshObserver.UnSubscribe(refreshSub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-done:
+			return nil
+		case event := <-refreshSub:
+			stream.Send(event)
+		}
+	}
+}
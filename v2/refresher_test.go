@@ -0,0 +1,142 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+)
+
+const testSubscriptionBody = "trojan://secret@example.com:443?sni=front.example.com#trojan\nss://YWVzLTI1Ni1nY206cEBzcw@203.0.113.4:8388#ss\n"
+
+func TestSubscriptionRefresher(t *testing.T) {
+	var failing atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Subscription-Userinfo", "upload=10; download=20; total=100; expire=1900000000")
+		w.Header().Set("Profile-Update-Interval", "12")
+		w.Write([]byte(testSubscriptionBody))
+	}))
+	defer server.Close()
+
+	now := time.Unix(1700000000, 0)
+	profile := &Profile{Id: "remote", Name: "remote", Type: pb.ProfileType_PROFILE_REMOTE, Url: server.URL}
+	var events []*pb.ProfileRefreshEvent
+	var info *SubscriptionInfo
+	var reloads []string
+
+	refresher := newSubscriptionRefresher(t.TempDir())
+	refresher.now = func() time.Time { return now }
+	refresher.loadProfiles = func() ([]*Profile, error) { return []*Profile{profile}, nil }
+	refresher.updateProfile = func(id string, update func(*Profile)) error { update(profile); return nil }
+	refresher.saveInfo = func(i *SubscriptionInfo) error { info = i; return nil }
+	refresher.emit = func(event *pb.ProfileRefreshEvent) { events = append(events, event) }
+	refresher.reload = func(id string) { reloads = append(reloads, id) }
+
+	expect := func(step string, status pb.RefreshStatus, count int) {
+		t.Helper()
+		refresher.refreshDue(context.Background())
+		if len(events) != count {
+			t.Fatalf("%s: got %d events, want %d", step, len(events), count)
+		}
+		if event := events[count-1]; event.Status != status {
+			t.Fatalf("%s: got status %v, want %v (%s)", step, event.Status, status, event.Message)
+		}
+	}
+
+	expect("first fetch", pb.RefreshStatus_REFRESH_SUCCESS, 1)
+	if profile.ETag != `"v1"` || profile.ProviderInterval != 12 {
+		t.Fatalf("profile not updated: %+v", profile)
+	}
+	if info == nil || info.Remaining() != 70 || info.Expire != 1900000000 {
+		t.Fatalf("subscription info not saved: %+v", info)
+	}
+	if cached, err := readProfileCache(refresher.cacheDir, profile.Id); err != nil || cached != testSubscriptionBody {
+		t.Fatalf("content not cached: %q %v", cached, err)
+	}
+
+	refresher.refreshDue(context.Background())
+	if len(events) != 1 || requests.Load() != 1 {
+		t.Fatalf("refreshed before the interval elapsed")
+	}
+
+	now = now.Add(12 * time.Hour)
+	expect("conditional fetch", pb.RefreshStatus_REFRESH_UNCHANGED, 2)
+
+	failing.Store(true)
+	now = now.Add(12 * time.Hour)
+	expect("failed fetch", pb.RefreshStatus_REFRESH_FAILED, 3)
+	if retryAt := time.Unix(events[2].RetryAt, 0); !retryAt.Equal(now.Add(refreshMinBackoff)) {
+		t.Fatalf("unexpected retry time %v", retryAt)
+	}
+
+	now = now.Add(refreshMinBackoff / 2)
+	refresher.refreshDue(context.Background())
+	if len(events) != 3 {
+		t.Fatalf("retried during backoff")
+	}
+
+	now = now.Add(refreshMinBackoff)
+	expect("retry", pb.RefreshStatus_REFRESH_FAILED, 4)
+	if retryAt := time.Unix(events[3].RetryAt, 0); !retryAt.Equal(now.Add(2 * refreshMinBackoff)) {
+		t.Fatalf("backoff not doubled: %v", retryAt)
+	}
+	if cached, _ := readProfileCache(refresher.cacheDir, profile.Id); cached != testSubscriptionBody {
+		t.Fatalf("last good copy lost after failure")
+	}
+
+	failing.Store(false)
+	now = now.Add(2 * refreshMinBackoff)
+	expect("recovery", pb.RefreshStatus_REFRESH_UNCHANGED, 5)
+	if len(refresher.retryAt) != 0 {
+		t.Fatalf("backoff not reset after success")
+	}
+	// Only new content is applied to the core.
+	if len(reloads) != 1 || reloads[0] != profile.Id {
+		t.Fatalf("unexpected reloads %v", reloads)
+	}
+}
+
+func TestSubscriptionRefresherKeepsConcurrentEdits(t *testing.T) {
+	stored := &Profile{Id: "remote", Name: "remote", Type: pb.ProfileType_PROFILE_REMOTE}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The user edits the profile while the request is in flight.
+		stored.Name = "renamed"
+		stored.Active = true
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Profile-Update-Interval", "12")
+		w.Write([]byte(testSubscriptionBody))
+	}))
+	defer server.Close()
+	stored.Url = server.URL
+	snapshot := *stored
+
+	refresher := newSubscriptionRefresher(t.TempDir())
+	refresher.updateProfile = func(id string, update func(*Profile)) error { update(stored); return nil }
+	refresher.saveInfo = func(*SubscriptionInfo) error { return nil }
+	refresher.emit = func(*pb.ProfileRefreshEvent) {}
+
+	if event := refresher.refresh(context.Background(), &snapshot); event.Status != pb.RefreshStatus_REFRESH_SUCCESS {
+		t.Fatalf("refresh failed: %+v", event)
+	}
+	if stored.Name != "renamed" || !stored.Active {
+		t.Fatalf("concurrent edit overwritten: %+v", stored)
+	}
+	if stored.ETag != `"v1"` || stored.ProviderInterval != 12 || stored.LastUpdate == 0 {
+		t.Fatalf("fetch results not saved: %+v", stored)
+	}
+}
@@ -0,0 +1,239 @@
+package v2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/hiddify/hiddify-core/config"
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+var (
+	// reloadAccess serializes starting, stopping and reloading the core.
+	reloadAccess   sync.Mutex
+	runningOptions *option.Options
+	// runningTunService is whether the running core uses the tunnel service.
+	runningTunService bool
+	// runningProfileId is the profile the running core was started from.
+	runningProfileId string
+)
+
+// sameOptions reports whether next is the configuration already running.
+func sameOptions(current *option.Options, next *option.Options) (bool, error) {
+	if current == nil {
+		return false, nil
+	}
+	currentContent, err := config.MarshalOptions(current)
+	if err != nil {
+		return false, err
+	}
+	nextContent, err := config.MarshalOptions(next)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(currentContent, nextContent), nil
+}
+
+// restoreSelections makes the selected outbounds the default of their
+// selectors, as long as they are still members. Built configs hold the
+// selector options by value, parsed ones by pointer.
+func restoreSelections(options *option.Options, selections map[string]string) {
+	for i, outbound := range options.Outbounds {
+		if outbound.Type != C.TypeSelector {
+			continue
+		}
+		switch selector := outbound.Options.(type) {
+		case *option.SelectorOutboundOptions:
+			restoreSelection(selections[outbound.Tag], selector)
+		case option.SelectorOutboundOptions:
+			restoreSelection(selections[outbound.Tag], &selector)
+			options.Outbounds[i].Options = selector
+		}
+	}
+}
+
+func restoreSelection(selected string, selector *option.SelectorOutboundOptions) {
+	if selected == "" {
+		return
+	}
+	for _, member := range selector.Outbounds {
+		if member == selected {
+			selector.Default = selected
+			return
+		}
+	}
+}
+
+// Reload applies new options to the running core. Nothing is done when the
+// options are unchanged. When only outbounds changed, the changed ones and
+// the groups and DNS servers using them are replaced in the running box and
+// the connections of the others are kept. Otherwise the new service is
+// created, the running one is closed, dropping its connections, and the new
+// one is started; the previous configuration is restored if it fails to
+// start. The outbounds selected in the running box stay selected.
+func Reload(options option.Options) (*pb.CoreInfoResponse, error) {
+	reloadAccess.Lock()
+	defer reloadAccess.Unlock()
+	return reload(options)
+}
+
+func reload(options option.Options) (*pb.CoreInfoResponse, error) {
+	if CoreState != pb.CoreState_STARTED || Box == nil {
+		return &pb.CoreInfoResponse{
+			CoreState:   CoreState,
+			MessageType: pb.MessageType_INSTANCE_NOT_STARTED,
+			Message:     "instance is not started",
+		}, fmt.Errorf("instance not started")
+	}
+	selections, err := boxSelections(Box)
+	if err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CORE, "failed to read selected outbounds: "+err.Error())
+	}
+	restoreSelections(&options, selections)
+	if runningOptions != nil {
+		restoreSelections(runningOptions, selections)
+	}
+	same, err := sameOptions(runningOptions, &options)
+	if err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CORE, "failed to compare configs: "+err.Error())
+	}
+	if same {
+		Log(pb.LogLevel_INFO, pb.LogType_CORE, "Config unchanged, skipping reload")
+		return &pb.CoreInfoResponse{
+			CoreState:   CoreState,
+			MessageType: pb.MessageType_EMPTY,
+		}, nil
+	}
+
+	if err := updateOutbounds(options); err == nil {
+		runningOptions = &options
+		config.SaveCurrentConfig(filepath.Join(sWorkingPath, "current-config.json"), options)
+		return &pb.CoreInfoResponse{
+			CoreState:   CoreState,
+			MessageType: pb.MessageType_EMPTY,
+		}, nil
+	} else if err != errRestartRequired {
+		Log(pb.LogLevel_WARNING, pb.LogType_CORE, "failed to update outbounds, restarting: "+err.Error())
+	}
+	Log(pb.LogLevel_INFO, pb.LogType_CORE, "Reloading config")
+
+	instance, err := newServiceWithTimeout(options)
+	if err != nil {
+		Log(pb.LogLevel_ERROR, pb.LogType_CORE, err.Error())
+		return &pb.CoreInfoResponse{
+			CoreState:   CoreState,
+			MessageType: pb.MessageType_CREATE_SERVICE,
+			Message:     err.Error(),
+		}, err
+	}
+
+	SetCoreStatus(pb.CoreState_STARTING, pb.MessageType_EMPTY, "")
+	if oldCommandServer != nil {
+		oldCommandServer.SetService(nil)
+	}
+	if err := Box.Close(); err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CORE, "failed to close previous service: "+err.Error())
+	}
+	Box = nil
+
+	if err := startInstanceWithTimeout(instance); err != nil {
+		instance.Close()
+		Log(pb.LogLevel_ERROR, pb.LogType_CORE, "reload failed, restoring previous config: "+err.Error())
+		if rollbackErr := startRunningOptions(); rollbackErr != nil {
+			Log(pb.LogLevel_FATAL, pb.LogType_CORE, rollbackErr.Error())
+			resp := SetCoreStatus(pb.CoreState_STOPPED, pb.MessageType_START_SERVICE, rollbackErr.Error())
+			StopAndAlert(pb.MessageType_UNEXPECTED_ERROR, rollbackErr.Error())
+			return resp, rollbackErr
+		}
+		resp := SetCoreStatus(pb.CoreState_STARTED, pb.MessageType_START_SERVICE, err.Error())
+		return resp, err
+	}
+	Box = instance
+	runningOptions = &options
+	if oldCommandServer != nil {
+		oldCommandServer.SetService(Box)
+	}
+	config.SaveCurrentConfig(filepath.Join(sWorkingPath, "current-config.json"), options)
+	return SetCoreStatus(pb.CoreState_STARTED, pb.MessageType_EMPTY, ""), nil
+}
+
+var errRestartRequired = errors.New("restart required")
+
+// updateOutbounds applies options to the running box in place. It returns
+// errRestartRequired when more than the outbounds changed. On any other error
+// the box may be partly updated and has to be restarted.
+func updateOutbounds(options option.Options) error {
+	if runningOptions == nil {
+		return errRestartRequired
+	}
+	content, err := config.MarshalOptions(&options)
+	if err != nil {
+		return err
+	}
+	parsed, err := config.UnmarshalOptions(content)
+	if err != nil {
+		return err
+	}
+	update, err := planOutboundUpdate(runningOptions, parsed)
+	if err != nil {
+		return err
+	}
+	if update == nil {
+		return errRestartRequired
+	}
+	Log(pb.LogLevel_INFO, pb.LogType_CORE, fmt.Sprintf("Updating %d outbounds in place", len(update.create)))
+	return applyOutboundUpdate(Box, update)
+}
+
+// startRunningOptions starts a new service from the last good options.
+func startRunningOptions() error {
+	if runningOptions == nil {
+		return fmt.Errorf("no previous config to restore")
+	}
+	instance, err := newServiceWithTimeout(*runningOptions)
+	if err != nil {
+		return err
+	}
+	if err := startInstanceWithTimeout(instance); err != nil {
+		instance.Close()
+		return err
+	}
+	Box = instance
+	if oldCommandServer != nil {
+		oldCommandServer.SetService(Box)
+	}
+	return nil
+}
+
+// reloadProfile applies the cached content of a refreshed profile to the
+// running core, if the core runs that profile.
+func reloadProfile(id string) {
+	reloadAccess.Lock()
+	defer reloadAccess.Unlock()
+	if CoreState != pb.CoreState_STARTED || runningProfileId != id {
+		return
+	}
+	content, err := readProfileCache(profileCacheDir(), id)
+	if err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CONFIG, "failed to read refreshed profile: "+err.Error())
+		return
+	}
+	parsed, err := config.ParseConfigContent(content, true, ensureHiddifyOptions(), false)
+	if err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CONFIG, "failed to parse refreshed profile: "+err.Error())
+		return
+	}
+	options, _, err := readStartOptions(&pb.StartRequest{ConfigContent: string(parsed)})
+	if err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CONFIG, "failed to build refreshed profile: "+err.Error())
+		return
+	}
+	if _, err := reload(options); err != nil {
+		Log(pb.LogLevel_WARNING, pb.LogType_CORE, "failed to reload refreshed profile: "+err.Error())
+	}
+}
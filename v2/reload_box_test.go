@@ -0,0 +1,94 @@
+//go:build with_clash_api
+
+package v2
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hiddify/hiddify-core/config"
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/experimental/libbox"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/service"
+)
+
+func TestReloadUpdatesOutboundsInPlace(t *testing.T) {
+	useProfileStore(t)
+	withDNS := func(options *option.Options) *option.Options {
+		options.DNS = &option.DNSOptions{RawDNSOptions: option.RawDNSOptions{
+			Servers: []option.DNSServerOptions{{Type: C.DNSTypeUDP, Tag: "remote", Options: &option.RemoteDNSServerOptions{
+				LocalDNSServerOptions:   option.LocalDNSServerOptions{DialerOptions: option.DialerOptions{Detour: "select"}},
+				DNSServerAddressOptions: option.DNSServerAddressOptions{Server: "1.1.1.1"},
+			}}},
+		}}
+		return options
+	}
+	options := withDNS(testReloadOptions("a", "b"))
+	content, err := config.MarshalOptions(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := libbox.NewService(string(content), newPlatformInterface())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := instance.Start(); err != nil {
+		t.Fatal(err)
+	}
+	box, err := boxInstance(instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, bridge := CoreState, useFlutterBridge
+	Box, CoreState, runningOptions, useFlutterBridge = instance, pb.CoreState_STARTED, options, false
+	t.Cleanup(func() {
+		useFlutterBridge = bridge
+		if Box != nil {
+			Box.Close()
+		}
+		Box, CoreState, runningOptions = nil, state, nil
+	})
+
+	outbound := func(tag string) adapter.Outbound {
+		t.Helper()
+		outbound, found := box.Outbound().Outbound(tag)
+		if !found {
+			t.Fatalf("outbound %s not found", tag)
+		}
+		return outbound
+	}
+	ctx, err := boxContext(box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transports := service.FromContext[adapter.DNSTransportManager](ctx)
+	transport, _ := transports.Transport("remote")
+	kept := outbound("a")
+	if !outbound("select").(interface{ SelectOutbound(string) bool }).SelectOutbound("b") {
+		t.Fatal("select failed")
+	}
+
+	if _, err := Reload(*withDNS(testReloadOptions("a", "b", "c"))); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if Box != instance {
+		t.Fatal("service restarted")
+	}
+	if outbound("a") != kept {
+		t.Fatal("unchanged outbound replaced")
+	}
+	selector := outbound("select").(adapter.OutboundGroup)
+	if !slices.Equal(selector.All(), []string{"a", "b", "c"}) || selector.Now() != "b" {
+		t.Fatalf("selector = %v %s", selector.All(), selector.Now())
+	}
+	if replaced, _ := transports.Transport("remote"); replaced == transport {
+		t.Fatal("dns server detouring through the selector not replaced")
+	}
+
+	if _, err := Stop(); err != nil || Box != nil || runningOptions != nil {
+		t.Fatalf("Stop = %v, left %v %v", err, Box, runningOptions)
+	}
+}
@@ -0,0 +1,339 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unsafe"
+
+	"github.com/hiddify/hiddify-core/config"
+	box "github.com/sagernet/sing-box"
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/experimental/libbox"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/service"
+)
+
+// outboundUpdate changes the outbounds of a running box: the tags to remove,
+// dependents first, the outbounds to create, dependencies first, and the DNS
+// servers to recreate because they detour through a replaced outbound.
+type outboundUpdate struct {
+	remove     []string
+	create     []option.Outbound
+	dnsServers []option.DNSServerOptions
+}
+
+// planOutboundUpdate returns the update turning the outbounds of current into
+// those of next, or nil when the change cannot be applied to the running box:
+// when anything but the outbounds changed, an outbound has no tag, or a detour
+// other than a DNS server's goes through a replaced outbound. Next must hold
+// parsed options, the form the outbound constructors take.
+func planOutboundUpdate(current *option.Options, next *option.Options) (*outboundUpdate, error) {
+	currentRest, nextRest := *current, *next
+	currentRest.Outbounds, nextRest.Outbounds = nil, nil
+	if same, err := sameOptions(&currentRest, &nextRest); err != nil || !same {
+		return nil, err
+	}
+	currentGraph, err := newOutboundGraph(current.Outbounds)
+	if currentGraph == nil || err != nil {
+		return nil, err
+	}
+	nextGraph, err := newOutboundGraph(next.Outbounds)
+	if nextGraph == nil || err != nil {
+		return nil, err
+	}
+
+	replaced := make(map[string]bool)
+	for _, tag := range nextGraph.tags {
+		if content, found := currentGraph.content[tag]; !found || content != nextGraph.content[tag] {
+			replaced[tag] = true
+		}
+	}
+	gone := make(map[string]bool)
+	for _, tag := range currentGraph.tags {
+		if _, found := nextGraph.content[tag]; !found {
+			gone[tag] = true
+		}
+	}
+	// Groups and detours resolve their outbounds when they start, so every
+	// outbound depending on a replaced or removed one is replaced as well.
+	for changed := true; changed; {
+		changed = false
+		for _, tag := range nextGraph.tags {
+			if replaced[tag] {
+				continue
+			}
+			for _, dependency := range slices.Concat(nextGraph.dependencies[tag], currentGraph.dependencies[tag]) {
+				if replaced[dependency] || gone[dependency] {
+					replaced[tag] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	update := &outboundUpdate{}
+	if next.DNS != nil {
+		for _, server := range next.DNS.Servers {
+			detours, err := optionDetours(&server)
+			if err != nil {
+				return nil, err
+			}
+			for _, detour := range detours {
+				if replaced[detour] || gone[detour] {
+					update.dnsServers = append(update.dnsServers, server)
+					break
+				}
+			}
+		}
+	}
+	rest := nextRest
+	if rest.DNS != nil {
+		dns := *rest.DNS
+		dns.Servers = nil
+		rest.DNS = &dns
+	}
+	detours, err := optionDetours(&rest)
+	if err != nil {
+		return nil, err
+	}
+	for _, detour := range detours {
+		if replaced[detour] || gone[detour] {
+			return nil, nil
+		}
+	}
+
+	removed := currentGraph.sorted(func(tag string) bool { return replaced[tag] || gone[tag] })
+	for i := len(removed) - 1; i >= 0; i-- {
+		update.remove = append(update.remove, removed[i])
+	}
+	for _, tag := range nextGraph.sorted(func(tag string) bool { return replaced[tag] }) {
+		update.create = append(update.create, next.Outbounds[nextGraph.index[tag]])
+	}
+	return update, nil
+}
+
+// outboundGraph holds the outbounds of a config by tag with the tags each of
+// them depends on.
+type outboundGraph struct {
+	tags         []string
+	index        map[string]int
+	content      map[string]string
+	dependencies map[string][]string
+}
+
+// newOutboundGraph returns nil for outbounds without a tag, which the box
+// names by their position.
+func newOutboundGraph(outbounds []option.Outbound) (*outboundGraph, error) {
+	graph := &outboundGraph{
+		index:        make(map[string]int),
+		content:      make(map[string]string),
+		dependencies: make(map[string][]string),
+	}
+	for i, outbound := range outbounds {
+		if outbound.Tag == "" {
+			return nil, nil
+		}
+		content, err := json.MarshalContext(config.OptionsContext(), &outbound)
+		if err != nil {
+			return nil, err
+		}
+		var fields struct {
+			Outbounds []string `json:"outbounds"`
+			Detour    string   `json:"detour"`
+		}
+		if err := json.Unmarshal(content, &fields); err != nil {
+			return nil, err
+		}
+		graph.tags = append(graph.tags, outbound.Tag)
+		graph.index[outbound.Tag] = i
+		graph.content[outbound.Tag] = string(content)
+		graph.dependencies[outbound.Tag] = fields.Outbounds
+		if fields.Detour != "" {
+			graph.dependencies[outbound.Tag] = append(graph.dependencies[outbound.Tag], fields.Detour)
+		}
+	}
+	return graph, nil
+}
+
+// sorted returns the selected tags with each one after the tags it depends on.
+func (g *outboundGraph) sorted(selected func(tag string) bool) []string {
+	var sorted []string
+	visited := make(map[string]bool)
+	var visit func(tag string)
+	visit = func(tag string) {
+		if visited[tag] {
+			return
+		}
+		visited[tag] = true
+		for _, dependency := range g.dependencies[tag] {
+			if _, found := g.index[dependency]; found {
+				visit(dependency)
+			}
+		}
+		if selected(tag) {
+			sorted = append(sorted, tag)
+		}
+	}
+	for _, tag := range g.tags {
+		visit(tag)
+	}
+	return sorted
+}
+
+// optionDetours returns the values of every detour field in the JSON form of
+// options.
+func optionDetours(options any) ([]string, error) {
+	content, err := json.MarshalContext(config.OptionsContext(), options)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(content, &value); err != nil {
+		return nil, err
+	}
+	var detours []string
+	var walk func(value any)
+	walk = func(value any) {
+		switch value := value.(type) {
+		case map[string]any:
+			for key, field := range value {
+				if detour, isString := field.(string); isString && strings.HasSuffix(key, "detour") {
+					detours = append(detours, detour)
+					continue
+				}
+				walk(field)
+			}
+		case []any:
+			for _, item := range value {
+				walk(item)
+			}
+		}
+	}
+	walk(value)
+	return detours, nil
+}
+
+// applyOutboundUpdate applies update to the box run by boxService. Outbounds
+// and DNS servers that are left alone keep their connections.
+func applyOutboundUpdate(boxService *libbox.BoxService, update *outboundUpdate) error {
+	instance, err := boxInstance(boxService)
+	if err != nil {
+		return err
+	}
+	ctx, err := boxContext(instance)
+	if err != nil {
+		return err
+	}
+	logFactory, err := boxLogFactory(instance)
+	if err != nil {
+		return err
+	}
+	outboundManager := instance.Outbound()
+	for _, tag := range update.remove {
+		if err := outboundManager.Remove(tag); err != nil {
+			return fmt.Errorf("remove outbound %s: %w", tag, err)
+		}
+	}
+	for _, outbound := range update.create {
+		err := outboundManager.Create(
+			adapter.WithContext(ctx, &adapter.InboundContext{Outbound: outbound.Tag}),
+			instance.Router(),
+			logFactory.NewLogger(F.ToString("outbound/", outbound.Type, "[", outbound.Tag, "]")),
+			outbound.Tag,
+			outbound.Type,
+			outbound.Options,
+		)
+		if err != nil {
+			return fmt.Errorf("create outbound %s: %w", outbound.Tag, err)
+		}
+	}
+	transportManager := service.FromContext[adapter.DNSTransportManager](ctx)
+	for _, server := range update.dnsServers {
+		err := transportManager.Create(
+			ctx,
+			logFactory.NewLogger(F.ToString("dns/", server.Type, "[", server.Tag, "]")),
+			server.Tag,
+			server.Type,
+			server.Options,
+		)
+		if err != nil {
+			return fmt.Errorf("create dns server %s: %w", server.Tag, err)
+		}
+	}
+	return nil
+}
+
+// boxSelections returns the outbound selected in each selector of the box run
+// by boxService.
+func boxSelections(boxService *libbox.BoxService) (map[string]string, error) {
+	instance, err := boxInstance(boxService)
+	if err != nil {
+		return nil, err
+	}
+	selections := make(map[string]string)
+	for _, outbound := range instance.Outbound().Outbounds() {
+		if group, isGroup := outbound.(adapter.OutboundGroup); isGroup && group.Type() == C.TypeSelector {
+			selections[group.Tag()] = group.Now()
+		}
+	}
+	return selections, nil
+}
+
+// boxInstance returns the box libbox keeps unexported in its service.
+func boxInstance(boxService *libbox.BoxService) (*box.Box, error) {
+	field, err := unexportedField(boxService, "instance")
+	if err != nil {
+		return nil, err
+	}
+	instance, ok := field.(*box.Box)
+	if !ok || instance == nil {
+		return nil, fmt.Errorf("service has no box")
+	}
+	return instance, nil
+}
+
+// boxContext returns the context the box was created with, which holds its
+// services. The router keeps it.
+func boxContext(instance *box.Box) (context.Context, error) {
+	field, err := unexportedField(instance.Router(), "ctx")
+	if err != nil {
+		return nil, err
+	}
+	ctx, ok := field.(context.Context)
+	if !ok || ctx == nil {
+		return nil, fmt.Errorf("router has no context")
+	}
+	return ctx, nil
+}
+
+func boxLogFactory(instance *box.Box) (log.Factory, error) {
+	field, err := unexportedField(instance, "logFactory")
+	if err != nil {
+		return nil, err
+	}
+	logFactory, ok := field.(log.Factory)
+	if !ok || logFactory == nil {
+		return nil, fmt.Errorf("box has no log factory")
+	}
+	return logFactory, nil
+}
+
+func unexportedField(object any, name string) (any, error) {
+	value := reflect.ValueOf(object)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unexpected %T", object)
+	}
+	field := value.Elem().FieldByName(name)
+	if !field.IsValid() {
+		return nil, fmt.Errorf("%T is missing %s", object, name)
+	}
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Interface(), nil
+}
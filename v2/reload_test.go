@@ -0,0 +1,188 @@
+package v2
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hiddify/hiddify-core/config"
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+func testReloadOptions(servers ...string) *option.Options {
+	options := &option.Options{
+		Log: &option.LogOptions{Level: "warn"},
+	}
+	var tags []string
+	for _, server := range servers {
+		options.Outbounds = append(options.Outbounds, option.Outbound{
+			Type: C.TypeSOCKS,
+			Tag:  server,
+			Options: &option.SOCKSOutboundOptions{
+				ServerOptions: option.ServerOptions{Server: server, ServerPort: 1080},
+			},
+		})
+		tags = append(tags, server)
+	}
+	options.Outbounds = append(options.Outbounds, option.Outbound{
+		Type:    C.TypeSelector,
+		Tag:     "select",
+		Options: &option.SelectorOutboundOptions{Outbounds: tags},
+	})
+	return options
+}
+
+func TestSameOptions(t *testing.T) {
+	if same, err := sameOptions(nil, testReloadOptions("a")); err != nil || same {
+		t.Fatalf("options equal to no running options: %v %v", same, err)
+	}
+	if same, err := sameOptions(testReloadOptions("a", "b"), testReloadOptions("a", "b")); err != nil || !same {
+		t.Fatalf("identical options not equal: %v %v", same, err)
+	}
+	if same, err := sameOptions(testReloadOptions("a", "b"), testReloadOptions("b", "c")); err != nil || same {
+		t.Fatalf("outbound change not detected: %v %v", same, err)
+	}
+
+	next := testReloadOptions("a", "b")
+	next.Log.Level = "debug"
+	if same, err := sameOptions(testReloadOptions("a", "b"), next); err != nil || same {
+		t.Fatalf("log change not detected: %v %v", same, err)
+	}
+}
+
+func TestRestoreSelections(t *testing.T) {
+	selections := map[string]string{config.OutboundSelectTag: "b"}
+	build := func(servers ...string) *option.Options {
+		t.Helper()
+		options, err := config.BuildConfig(*config.DefaultHiddifyOptions(), option.Options{
+			Outbounds: testReloadOptions(servers...).Outbounds,
+		})
+		if err != nil {
+			t.Fatalf("BuildConfig failed: %v", err)
+		}
+		return options
+	}
+	selectorDefault := func(options *option.Options) string {
+		t.Helper()
+		for _, outbound := range options.Outbounds {
+			if outbound.Tag == config.OutboundSelectTag {
+				return outbound.Options.(option.SelectorOutboundOptions).Default
+			}
+		}
+		t.Fatal("selector not found")
+		return ""
+	}
+
+	options := build("a", "b")
+	restoreSelections(options, selections)
+	if selected := selectorDefault(options); selected != "b" {
+		t.Fatalf("selection not restored: %q", selected)
+	}
+
+	options = build("a", "c")
+	restoreSelections(options, selections)
+	if selected := selectorDefault(options); selected == "b" {
+		t.Fatalf("selection restored for a removed member")
+	}
+
+	parsed := testReloadOptions("a", "b")
+	restoreSelections(parsed, selections)
+	if selector := parsed.Outbounds[2].Options.(*option.SelectorOutboundOptions); selector.Default != "b" {
+		t.Fatalf("selection not restored in parsed options: %q", selector.Default)
+	}
+}
+
+func TestPlanOutboundUpdate(t *testing.T) {
+	withDNS := func(options *option.Options) *option.Options {
+		options.DNS = &option.DNSOptions{RawDNSOptions: option.RawDNSOptions{
+			Servers: []option.DNSServerOptions{
+				{Type: C.DNSTypeUDP, Tag: "remote", Options: &option.RemoteDNSServerOptions{
+					LocalDNSServerOptions:   option.LocalDNSServerOptions{DialerOptions: option.DialerOptions{Detour: "select"}},
+					DNSServerAddressOptions: option.DNSServerAddressOptions{Server: "1.1.1.1"},
+				}},
+				{Type: C.DNSTypeUDP, Tag: "local", Options: &option.RemoteDNSServerOptions{
+					DNSServerAddressOptions: option.DNSServerAddressOptions{Server: "8.8.8.8"},
+				}},
+			},
+		}}
+		return options
+	}
+	creates := func(update *outboundUpdate) []string {
+		var tags []string
+		for _, outbound := range update.create {
+			tags = append(tags, outbound.Tag)
+		}
+		return tags
+	}
+
+	for _, test := range []struct {
+		name    string
+		next    *option.Options
+		remove  []string
+		create  []string
+		servers int
+	}{
+		{name: "added", next: withDNS(testReloadOptions("a", "b", "c")), remove: []string{"select"}, create: []string{"c", "select"}, servers: 1},
+		{name: "removed", next: withDNS(testReloadOptions("a")), remove: []string{"select", "b"}, create: []string{"select"}, servers: 1},
+		{name: "unchanged", next: withDNS(testReloadOptions("a", "b"))},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			update, err := planOutboundUpdate(withDNS(testReloadOptions("a", "b")), test.next)
+			if err != nil || update == nil {
+				t.Fatalf("planOutboundUpdate = %v %v", update, err)
+			}
+			if !slices.Equal(update.remove, test.remove) || !slices.Equal(creates(update), test.create) || len(update.dnsServers) != test.servers {
+				t.Fatalf("update = %v %v %d", update.remove, creates(update), len(update.dnsServers))
+			}
+			if test.servers > 0 && update.dnsServers[0].Tag != "remote" {
+				t.Fatalf("recreated dns server %s", update.dnsServers[0].Tag)
+			}
+		})
+	}
+
+	changed := testReloadOptions("a", "b")
+	changed.Outbounds[0].Options.(*option.SOCKSOutboundOptions).ServerPort = 1081
+	update, err := planOutboundUpdate(testReloadOptions("a", "b"), changed)
+	if err != nil || !slices.Equal(update.remove, []string{"select", "a"}) || !slices.Equal(creates(update), []string{"a", "select"}) {
+		t.Fatalf("changed server: %v %v", update, err)
+	}
+
+	next := testReloadOptions("a", "b", "c")
+	next.Log.Level = "debug"
+	if update, err := planOutboundUpdate(testReloadOptions("a", "b"), next); err != nil || update != nil {
+		t.Fatalf("log change updated in place: %v %v", update, err)
+	}
+
+	withNTP := func(options *option.Options) *option.Options {
+		options.NTP = &option.NTPOptions{Enabled: true, DialerOptions: option.DialerOptions{Detour: "select"}}
+		return options
+	}
+	if update, err := planOutboundUpdate(withNTP(testReloadOptions("a", "b")), withNTP(testReloadOptions("a", "c"))); err != nil || update != nil {
+		t.Fatalf("detour through a replaced outbound updated in place: %v %v", update, err)
+	}
+}
+
+func TestRestartNeedsStop(t *testing.T) {
+	options, tunService := HiddifyOptions, runningTunService
+	HiddifyOptions = config.DefaultHiddifyOptions()
+	t.Cleanup(func() { HiddifyOptions, runningTunService = options, tunService })
+
+	runningTunService = false
+	if restartNeedsStop(&pb.StartRequest{}) {
+		t.Fatal("unchanged request needs a stop")
+	}
+	for _, in := range []*pb.StartRequest{{EnableOldCommandServer: true}, {DelayStart: true}} {
+		if !restartNeedsStop(in) {
+			t.Fatalf("%v reloaded in place", in)
+		}
+	}
+	HiddifyOptions.EnableTunService = true
+	if !restartNeedsStop(&pb.StartRequest{}) {
+		t.Fatal("enabling the tunnel service reloaded in place")
+	}
+	runningTunService = true
+	if restartNeedsStop(&pb.StartRequest{}) {
+		t.Fatal("running tunnel service needs a stop")
+	}
+}
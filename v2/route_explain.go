@@ -0,0 +1,76 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hiddify/hiddify-core/config"
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+)
+
+func routeExplanationFailed(err error) (*pb.RouteExplanation, error) {
+	return &pb.RouteExplanation{
+		ResponseCode: pb.ResponseCode_FAILED,
+		Message:      err.Error(),
+	}, err
+}
+
+func (s *CoreService) ExplainRoute(ctx context.Context, in *pb.RouteQuery) (*pb.RouteExplanation, error) {
+	return ExplainRoute(in)
+}
+
+// ExplainRoute tells which route and DNS rules of the running config the
+// described connection matches. The current clash mode is used unless the
+// query names one.
+func ExplainRoute(in *pb.RouteQuery) (*pb.RouteExplanation, error) {
+	query := RouteQueryFromPb(in)
+	if query.ClashMode == "" {
+		if mode, err := GetMode(); err == nil {
+			query.ClashMode = mode.Mode
+		}
+	}
+
+	// Reload replaces and updates the running options.
+	reloadAccess.Lock()
+	defer reloadAccess.Unlock()
+	if CoreState != pb.CoreState_STARTED || runningOptions == nil {
+		return routeExplanationFailed(fmt.Errorf("instance not started"))
+	}
+	explanation, err := config.ExplainRoute(runningOptions, query)
+	if err != nil {
+		return routeExplanationFailed(err)
+	}
+	return RouteExplanationToPb(explanation), nil
+}
+
+func RouteQueryFromPb(in *pb.RouteQuery) config.RouteQuery {
+	return config.RouteQuery{
+		Domain:      in.Domain,
+		IP:          in.Ip,
+		Port:        uint16(in.Port),
+		Network:     in.Network,
+		Protocol:    in.Protocol,
+		ProcessName: in.ProcessName,
+		ProcessPath: in.ProcessPath,
+		PackageName: in.PackageName,
+		Inbound:     in.Inbound,
+		SourceIP:    in.SourceIp,
+		SourcePort:  uint16(in.SourcePort),
+		ClashMode:   in.ClashMode,
+		WifiSSID:    in.WifiSsid,
+	}
+}
+
+func RouteExplanationToPb(explanation *config.RouteExplanation) *pb.RouteExplanation {
+	return &pb.RouteExplanation{
+		ResponseCode: pb.ResponseCode_OK,
+		RuleIndex:    int32(explanation.RuleIndex),
+		Rule:         explanation.Rule,
+		Action:       explanation.Action,
+		Outbound:     explanation.Outbound,
+		DnsRuleIndex: int32(explanation.DNSRuleIndex),
+		DnsRule:      explanation.DNSRule,
+		DnsServer:    explanation.DNSServer,
+		Notes:        explanation.Notes,
+	}
+}
@@ -58,6 +58,8 @@ func Setup(basePath string, workingPath string, tempPath string, statusPort int6
 	if err != nil {
 		return E.Cause(err, "create logger")
 	}
+	startProfileRefresher()
+	startAssetManager(basePath)
 	return InitHiddifyService()
 }
 
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
@@ -51,6 +50,7 @@ type ConfigResult struct {
 	Config                string
 	RefreshInterval       int
 	HiddifyHiddifyOptions *config.HiddifyOptions
+	Subscription          *SubscriptionInfo
 }
 
 func readAndBuildConfig(hiddifySettingPath string, configPath string, defaultConfig *config.HiddifyOptions) (ConfigResult, error) {
@@ -86,8 +86,10 @@ func readAndBuildConfig(hiddifySettingPath string, configPath string, defaultCon
 func readConfigContent(configPath string) (ConfigResult, error) {
 	var content string
 	var refreshInterval int
+	var header http.Header
 
-	if strings.HasPrefix(configPath, "http://") || strings.HasPrefix(configPath, "https://") {
+	remote := strings.HasPrefix(configPath, "http://") || strings.HasPrefix(configPath, "https://")
+	if remote {
 		client := &http.Client{}
 
 		// Create a new request
@@ -96,19 +98,23 @@ func readConfigContent(configPath string) (ConfigResult, error) {
 			fmt.Println("Error creating request:", err)
 			return ConfigResult{}, err
 		}
-		req.Header.Set("User-Agent", "HiddifyNext/2.3.1 ("+runtime.GOOS+") like ClashMeta v2ray sing-box 1.8.9")
+		req.Header.Set("User-Agent", subscriptionUserAgent)
 		resp, err := client.Do(req)
 		if err != nil {
 			fmt.Println("Error making GET request:", err)
 			return ConfigResult{}, err
 		}
 		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return ConfigResult{}, fmt.Errorf("failed to fetch config: unexpected status %s", resp.Status)
+		}
 
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
 			return ConfigResult{}, fmt.Errorf("failed to read config body: %w", err)
 		}
 		content = string(body)
+		header = resp.Header
 		refreshInterval, _ = extractRefreshInterval(resp.Header, content)
 		fmt.Printf("Refresh interval: %d\n", refreshInterval)
 	} else {
@@ -119,9 +125,19 @@ func readConfigContent(configPath string) (ConfigResult, error) {
 		content = string(data)
 	}
 
+	subscription := parseSubscriptionInfo(configPath, header, content)
+	// Local files have no provider to report usage, so nothing is stored for
+	// them.
+	if remote {
+		if err := saveSubscriptionInfo(subscription); err != nil {
+			fmt.Printf("Error saving subscription info: %v\n", err)
+		}
+	}
+
 	return ConfigResult{
 		Config:          content,
 		RefreshInterval: refreshInterval,
+		Subscription:    subscription,
 	}, nil
 }
 
@@ -197,17 +213,18 @@ func updateConfigInterval(current ConfigResult, hiddifySettingPath string, confi
 		<-time.After(time.Duration(current.RefreshInterval) * time.Hour)
 		new, err := readAndBuildConfig(hiddifySettingPath, configPath, current.HiddifyHiddifyOptions)
 		if err != nil {
+			Log(pb.LogLevel_ERROR, pb.LogType_CONFIG, "failed to refresh config: "+err.Error())
 			continue
 		}
 		if new.Config != current.Config {
-			go Stop()
-			go StartService(&pb.StartRequest{
-				ConfigContent:          new.Config,
-				DelayStart:             false,
-				EnableOldCommandServer: false,
-				DisableMemoryLimit:     false,
-				EnableRawConfig:        true,
-			})
+			options, err := readOptions(new.Config)
+			if err != nil {
+				Log(pb.LogLevel_ERROR, pb.LogType_CONFIG, err.Error())
+				continue
+			}
+			if _, err := Reload(options); err != nil {
+				continue
+			}
 		}
 		current = new
 	}
@@ -0,0 +1,151 @@
+package v2
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	"github.com/hiddify/hiddify-core/v2/db"
+)
+
+// SubscriptionInfo holds the metadata a subscription provider sends along with
+// the profile. It is stored per profile, keyed by the profile url or path.
+type SubscriptionInfo struct {
+	Id         string
+	Upload     int64
+	Download   int64
+	Total      int64
+	Expire     int64 // unix seconds, 0 if unknown
+	Title      string
+	SupportUrl string
+	UpdatedAt  int64
+}
+
+// Remaining returns the traffic left in bytes, or -1 if the quota is unknown.
+func (info *SubscriptionInfo) Remaining() int64 {
+	if info.Total <= 0 {
+		return -1
+	}
+	remaining := info.Total - info.Upload - info.Download
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// parseSubscriptionInfo reads subscription metadata from the response headers,
+// falling back to "#name: value" or "//name: value" comments in the body.
+func parseSubscriptionInfo(id string, header http.Header, body string) *SubscriptionInfo {
+	info := &SubscriptionInfo{
+		Id:        id,
+		UpdatedAt: time.Now().Unix(),
+	}
+	parseSubscriptionUserinfo(info, subscriptionHeader(header, body, "subscription-userinfo"))
+	info.Title = decodeProfileTitle(subscriptionHeader(header, body, "profile-title"))
+	if info.Title == "" {
+		info.Title = contentDispositionFilename(header.Get("content-disposition"))
+	}
+	info.SupportUrl = subscriptionHeader(header, body, "support-url")
+	return info
+}
+
+func subscriptionHeader(header http.Header, body string, name string) string {
+	if value := strings.TrimSpace(header.Get(name)); value != "" {
+		return value
+	}
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"#", "//"} {
+			if value, found := strings.CutPrefix(line, prefix+name+":"); found {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+	return ""
+}
+
+// parseSubscriptionUserinfo parses "upload=1; download=2; total=3; expire=4".
+func parseSubscriptionUserinfo(info *SubscriptionInfo, value string) {
+	for _, part := range strings.Split(value, ";") {
+		key, val, found := strings.Cut(part, "=")
+		if !found {
+			continue
+		}
+		number, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "upload":
+			info.Upload = int64(number)
+		case "download":
+			info.Download = int64(number)
+		case "total":
+			info.Total = int64(number)
+		case "expire":
+			info.Expire = int64(number)
+		}
+	}
+}
+
+// decodeProfileTitle handles the "base64:" prefix some providers use for
+// non-ascii titles.
+func decodeProfileTitle(title string) string {
+	encoded, found := strings.CutPrefix(title, "base64:")
+	if !found {
+		return title
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(encoded); err == nil {
+			return strings.TrimSpace(string(decoded))
+		}
+	}
+	return title
+}
+
+func contentDispositionFilename(value string) string {
+	if value == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
+
+func saveSubscriptionInfo(info *SubscriptionInfo) error {
+	return db.GetTable[SubscriptionInfo]().UpdateInsert(info)
+}
+
+func (s *CoreService) GetSubscriptionInfo(ctx context.Context, in *pb.SubscriptionInfoRequest) (*pb.SubscriptionInfoResponse, error) {
+	return GetSubscriptionInfo(in)
+}
+
+func GetSubscriptionInfo(in *pb.SubscriptionInfoRequest) (*pb.SubscriptionInfoResponse, error) {
+	info, err := db.GetTable[SubscriptionInfo]().Get(in.ConfigPath)
+	if err != nil {
+		err = fmt.Errorf("no subscription info for %s: %w", in.ConfigPath, err)
+		return &pb.SubscriptionInfoResponse{
+			ResponseCode: pb.ResponseCode_FAILED,
+			Message:      err.Error(),
+		}, err
+	}
+	return &pb.SubscriptionInfoResponse{
+		ResponseCode: pb.ResponseCode_OK,
+		Upload:       info.Upload,
+		Download:     info.Download,
+		Total:        info.Total,
+		Remaining:    info.Remaining(),
+		Expire:       info.Expire,
+		Title:        info.Title,
+		SupportUrl:   info.SupportUrl,
+		UpdatedAt:    info.UpdatedAt,
+	}, nil
+}
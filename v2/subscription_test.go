@@ -0,0 +1,106 @@
+package v2
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hiddify/hiddify-core/v2/db"
+)
+
+func TestParseSubscriptionInfo(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		header http.Header
+		body   string
+		want   SubscriptionInfo
+	}{
+		{
+			name:   "userinfo header",
+			header: http.Header{"Subscription-Userinfo": {"upload=10; download=20; total=100; expire=1900000000"}},
+			want:   SubscriptionInfo{Upload: 10, Download: 20, Total: 100, Expire: 1900000000},
+		},
+		{
+			name:   "userinfo with floats and unknown keys",
+			header: http.Header{"Subscription-Userinfo": {"Upload=1.5e3;download=2;bogus;extra=7;total=x"}},
+			want:   SubscriptionInfo{Upload: 1500, Download: 2},
+		},
+		{
+			name: "userinfo and support url in body comments",
+			body: "#subscription-userinfo: upload=1; download=2; total=3\n//support-url: https://t.me/support\nvless://...",
+			want: SubscriptionInfo{Upload: 1, Download: 2, Total: 3, SupportUrl: "https://t.me/support"},
+		},
+		{
+			name:   "header wins over body",
+			header: http.Header{"Profile-Title": {"Header"}},
+			body:   "#profile-title: Body",
+			want:   SubscriptionInfo{Title: "Header"},
+		},
+		{
+			name:   "plain title",
+			header: http.Header{"Profile-Title": {"My VPN"}},
+			want:   SubscriptionInfo{Title: "My VPN"},
+		},
+		{
+			name:   "base64 title",
+			header: http.Header{"Profile-Title": {"base64:2YXYtNiq2LHaqQ=="}},
+			want:   SubscriptionInfo{Title: "مشترک"},
+		},
+		{
+			name:   "unpadded base64 title",
+			header: http.Header{"Profile-Title": {"base64:TXkgVlBO"}},
+			want:   SubscriptionInfo{Title: "My VPN"},
+		},
+		{
+			name:   "invalid base64 title is kept",
+			header: http.Header{"Profile-Title": {"base64:***"}},
+			want:   SubscriptionInfo{Title: "base64:***"},
+		},
+		{
+			name:   "content-disposition filename",
+			header: http.Header{"Content-Disposition": {`attachment; filename="provider.txt"`}},
+			want:   SubscriptionInfo{Title: "provider.txt"},
+		},
+		{
+			name: "profile title wins over content-disposition",
+			header: http.Header{
+				"Profile-Title":       {"Title"},
+				"Content-Disposition": {`attachment; filename="provider.txt"`},
+			},
+			want: SubscriptionInfo{Title: "Title"},
+		},
+		{
+			name:   "invalid content-disposition",
+			header: http.Header{"Content-Disposition": {`attachment; filename="unterminated`}},
+			want:   SubscriptionInfo{},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			info := parseSubscriptionInfo("id", test.header, test.body)
+			test.want.Id = "id"
+			test.want.UpdatedAt = info.UpdatedAt
+			if *info != test.want {
+				t.Fatalf("parseSubscriptionInfo = %+v, want %+v", *info, test.want)
+			}
+		})
+	}
+}
+
+func TestReadConfigContentDoesNotStoreLocalFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	path := filepath.Join(t.TempDir(), "profile.txt")
+	if err := os.WriteFile(path, []byte("#profile-title: Local\n"+testSubscriptionBody), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := readConfigContent(path)
+	if err != nil {
+		t.Fatalf("readConfigContent failed: %v", err)
+	}
+	if result.Subscription == nil || result.Subscription.Title != "Local" {
+		t.Fatalf("subscription = %+v", result.Subscription)
+	}
+	if _, err := db.GetTable[SubscriptionInfo]().Get(path); err == nil {
+		t.Fatal("subscription info stored for a local file")
+	}
+}
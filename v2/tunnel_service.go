@@ -6,7 +6,10 @@ import (
 	"log"
 	"os"
 
+	"github.com/hiddify/hiddify-core/config"
 	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	"github.com/sagernet/sing-box/option"
+	dns "github.com/sagernet/sing-dns"
 )
 
 func (s *TunnelService) Start(ctx context.Context, in *pb.TunnelStartRequest) (*pb.TunnelResponse, error) {
@@ -14,8 +17,14 @@ func (s *TunnelService) Start(ctx context.Context, in *pb.TunnelStartRequest) (*
 		in.ServerPort = 12334
 	}
 	useFlutterBridge = false
+	content, err := makeTunnelConfig(in)
+	if err != nil {
+		return &pb.TunnelResponse{
+			Message: err.Error(),
+		}, err
+	}
 	res, err := Start(&pb.StartRequest{
-		ConfigContent:          makeTunnelConfig(in.Ipv6, in.ServerPort, in.StrictRoute, in.EndpointIndependentNat, in.Stack),
+		ConfigContent:          content,
 		EnableOldCommandServer: false,
 		DisableMemoryLimit:     true,
 		EnableRawConfig:        true,
@@ -31,59 +40,43 @@ func (s *TunnelService) Start(ctx context.Context, in *pb.TunnelStartRequest) (*
 	}, err
 }
 
-func makeTunnelConfig(Ipv6 bool, ServerPort int32, StrictRoute bool, EndpointIndependentNat bool, Stack string) string {
-	var ipv6 string
-	if Ipv6 {
-		ipv6 = `      "inet6_address": "fdfe:dcba:9876::1/126",`
-	} else {
-		ipv6 = ""
+// tunnelOptions returns the options of the tunnel service described by in.
+func tunnelOptions(in *pb.TunnelStartRequest) config.HiddifyOptions {
+	var opt config.HiddifyOptions
+	opt.MixedPort = uint16(in.ServerPort)
+	opt.MTU = in.Mtu
+	opt.StrictRoute = in.StrictRoute
+	opt.TUNStack = in.Stack
+	opt.TUNInterfaceName = in.InterfaceName
+	if opt.TUNInterfaceName == "" {
+		opt.TUNInterfaceName = "HiddifyTunnel"
+	}
+	opt.TUNInet4Address = in.Inet4Address
+	opt.TUNInet6Address = in.Inet6Address
+	opt.TUNRouteAddress = in.RouteAddress
+	opt.TUNExcludeAddress = in.RouteExcludeAddress
+	opt.TUNAutoRedirect = in.AutoRedirect
+	opt.TUNDisableEndpointIndependentNat = !in.EndpointIndependentNat
+	if !in.Ipv6 {
+		opt.IPv6Mode = option.DomainStrategy(dns.DomainStrategyUseIPv4)
 	}
-	base := `{
-		"log":{
-			"level": "warn"
-		},
-		"inbounds": [
-		  {
-			"type": "tun",
-			"tag": "tun-in",
-			"interface_name": "HiddifyTunnel",
-			"inet4_address": "172.19.0.1/30",
-			` + ipv6 + `
-			"auto_route": true,
-			"strict_route": ` + fmt.Sprintf("%t", StrictRoute) + `,
-			"endpoint_independent_nat": ` + fmt.Sprintf("%t", EndpointIndependentNat) + `,
-			"stack": "` + Stack + `"
-		  }
-		],
-		"outbounds": [
-		  {
-			"type": "socks",
-			"tag": "socks-out",
-			"server": "127.0.0.1",
-			"server_port": ` + fmt.Sprintf("%d", ServerPort) + `,
-			"version": "5"
-		  },
-		  {
-			"type": "direct",
-			"tag": "direct-out"
-		  }
-		],
-		"route": {
-		  "rules": [
-			{
-				"process_name":[
-					"Hiddify.exe",
-					"Hiddify",
-					"HiddifyCli",
-					"HiddifyCli.exe"
-					],
-				"outbound": "direct-out"
-			}
-		  ]
-		}
-	  }`
+	if in.ProxyUsername != "" {
+		opt.LANSharing.Users = []config.LANSharingUser{{Username: in.ProxyUsername, Password: in.ProxyPassword}}
+	}
+	opt.PerAppProxy = config.PerAppProxyOptions{
+		Mode:         in.PerAppMode,
+		ProcessNames: in.ProcessNames,
+		ProcessPaths: in.ProcessPaths,
+	}
+	return opt
+}
 
-	return base
+func makeTunnelConfig(in *pb.TunnelStartRequest) (string, error) {
+	options, err := config.BuildTunnelServiceConfig(tunnelOptions(in))
+	if err != nil {
+		return "", err
+	}
+	return config.ToJson(*options)
 }
 
 func (s *TunnelService) Stop(ctx context.Context, _ *pb.Empty) (*pb.TunnelResponse, error) {
@@ -0,0 +1,85 @@
+package v2
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hiddify/hiddify-core/config"
+	pb "github.com/hiddify/hiddify-core/hiddifyrpc"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/experimental/libbox"
+	"github.com/sagernet/sing-box/option"
+)
+
+func TestMakeTunnelConfig(t *testing.T) {
+	for _, in := range []*pb.TunnelStartRequest{
+		{ServerPort: 12334, StrictRoute: true, EndpointIndependentNat: true, Stack: "mixed"},
+		{
+			Ipv6:                true,
+			ServerPort:          2334,
+			Stack:               "system",
+			PerAppMode:          config.PerAppProxyModeExclude,
+			ProcessNames:        []string{"game.exe"},
+			ProcessPaths:        []string{"/opt/app/bin/app"},
+			InterfaceName:       "hiddify0",
+			Inet4Address:        "10.255.0.1/30",
+			Inet6Address:        "fd00:1::1/126",
+			RouteAddress:        []string{"10.0.0.0/8", "fd00::/8"},
+			RouteExcludeAddress: []string{"10.1.0.0/16"},
+			AutoRedirect:        true,
+			Mtu:                 1500,
+			ProxyUsername:       "guest",
+			ProxyPassword:       "secret",
+		},
+		{PerAppMode: config.PerAppProxyModeInclude, ProcessNames: []string{"browser"}},
+	} {
+		content, err := makeTunnelConfig(in)
+		if err != nil {
+			t.Fatalf("makeTunnelConfig(%v) failed: %v", in, err)
+		}
+		if err := libbox.CheckConfig(content); err != nil {
+			t.Fatalf("invalid tunnel config for %v: %v\n%s", in, err, content)
+		}
+		for _, legacy := range []string{"inet4_address", "inet6_address"} {
+			if strings.Contains(content, legacy) {
+				t.Fatalf("tunnel config uses legacy %s:\n%s", legacy, content)
+			}
+		}
+	}
+
+	if _, err := makeTunnelConfig(&pb.TunnelStartRequest{Inet4Address: "fd00:1::1/126"}); err == nil {
+		t.Fatalf("invalid tun address accepted")
+	}
+}
+
+func TestTunnelConfigOptions(t *testing.T) {
+	options, err := config.BuildTunnelServiceConfig(tunnelOptions(&pb.TunnelStartRequest{
+		ServerPort:    2334,
+		ProxyUsername: "guest",
+		ProxyPassword: "secret",
+	}))
+	if err != nil {
+		t.Fatalf("BuildTunnelServiceConfig failed: %v", err)
+	}
+	tun := options.Inbounds[0].Options.(option.TunInboundOptions)
+	if len(tun.Address) != 1 || !tun.Address[0].Addr().Is4() || tun.InterfaceName != "HiddifyTunnel" {
+		t.Fatalf("unexpected tun options without ipv6: %+v", tun)
+	}
+	for _, outbound := range options.Outbounds {
+		if outbound.Type != C.TypeSOCKS {
+			continue
+		}
+		socks := outbound.Options.(option.SOCKSOutboundOptions)
+		if socks.ServerPort != 2334 || socks.Username != "guest" || socks.Password != "secret" {
+			t.Fatalf("unexpected socks outbound: %+v", socks)
+		}
+	}
+
+	options, err = config.BuildTunnelServiceConfig(tunnelOptions(&pb.TunnelStartRequest{Ipv6: true}))
+	if err != nil {
+		t.Fatalf("BuildTunnelServiceConfig failed: %v", err)
+	}
+	if tun := options.Inbounds[0].Options.(option.TunInboundOptions); len(tun.Address) != 2 {
+		t.Fatalf("ipv6 address missing: %v", tun.Address)
+	}
+}